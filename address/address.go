@@ -0,0 +1,263 @@
+package address
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/currency"
+	"github.com/iancoleman/orderedmap"
+)
+
+// Keys of addr_extra_info entries
+const (
+	// KeyTag is the destination tag of XRP
+	KeyTag = "tag"
+	// KeyMemo is the memo of EOS, BNB and XLM
+	KeyMemo = "memo"
+	// KeyPaymentID is the payment id of Monero style chains
+	KeyPaymentID = "payment_id"
+)
+
+// extraInfoKeys are the addr_extra_info keys each chain uses
+var extraInfoKeys = map[uint32][]string{
+	currency.CoinTypeXRP: {KeyTag},
+	currency.CoinTypeEOS: {KeyMemo},
+	currency.CoinTypeBNB: {KeyMemo},
+	currency.CoinTypeXLM: {KeyMemo},
+}
+
+// uriSchemes map the schemes of payment URIs to their chain
+var uriSchemes = map[string]uint32{
+	"bitcoin":     currency.CoinTypeBTC,
+	"litecoin":    currency.CoinTypeLTC,
+	"ethereum":    currency.CoinTypeETH,
+	"xrp":         currency.CoinTypeXRP,
+	"ripple":      currency.CoinTypeXRP,
+	"eos":         currency.CoinTypeEOS,
+	"tron":        currency.CoinTypeTRX,
+	"bnb":         currency.CoinTypeBNB,
+	"binance":     currency.CoinTypeBNB,
+	"stellar":     currency.CoinTypeXLM,
+	"web+stellar": currency.CoinTypeXLM,
+}
+
+// uriParams map the query parameters of payment URIs to addr_extra_info keys,
+// the first parameter present for a key wins
+var uriParams = []struct{ param, key string }{
+	{"dt", KeyTag},
+	{"tag", KeyTag},
+	{"destination_tag", KeyTag},
+	{"memo", KeyMemo},
+	{"payment_id", KeyPaymentID},
+	{"tx_payment_id", KeyPaymentID},
+}
+
+// ExtraInfoKeys returns the addr_extra_info keys addresses of the currency take
+func ExtraInfoKeys(id currency.ID) []string {
+	return append([]string(nil), extraInfoKeys[id.CoinType]...)
+}
+
+// AddressExtraInfo is the addr_extra_info of an address
+type AddressExtraInfo struct {
+	Tag       string
+	Memo      string
+	PaymentID string
+	// Other holds keys without a dedicated field
+	Other map[string]string
+}
+
+// Map returns the non-empty entries of the extra info keyed by their addr_extra_info key
+func (e AddressExtraInfo) Map() map[string]string {
+	m := make(map[string]string, len(e.Other)+3)
+	for k, v := range e.Other {
+		m[k] = v
+	}
+	for k, v := range map[string]string{KeyTag: e.Tag, KeyMemo: e.Memo, KeyPaymentID: e.PaymentID} {
+		if v != "" {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// IsEmpty reports whether the extra info has no entries
+func (e AddressExtraInfo) IsEmpty() bool {
+	return len(e.Map()) == 0
+}
+
+func (e *AddressExtraInfo) set(key, value string) {
+	switch key {
+	case KeyTag:
+		e.Tag = value
+	case KeyMemo:
+		e.Memo = value
+	case KeyPaymentID:
+		e.PaymentID = value
+	default:
+		if e.Other == nil {
+			e.Other = map[string]string{}
+		}
+		e.Other[key] = value
+	}
+}
+
+// keys returns the keys of the entries, known keys first
+func (e AddressExtraInfo) keys() []string {
+	m := e.Map()
+	keys := make([]string, 0, len(m))
+	for _, k := range []string{KeyTag, KeyMemo, KeyPaymentID} {
+		if _, ok := m[k]; ok {
+			keys = append(keys, k)
+			delete(m, k)
+		}
+	}
+	other := make([]string, 0, len(m))
+	for k := range m {
+		other = append(other, k)
+	}
+	sort.Strings(other)
+	return append(keys, other...)
+}
+
+// Address is a blockchain address with its addr_extra_info
+type Address struct {
+	Address   string
+	ExtraInfo AddressExtraInfo
+}
+
+// Validate checks the format of the address and its extra info for the currency, see the package function Validate
+func (a Address) Validate(currencyID string) error {
+	return Validate(currencyID, a.Address, a.ExtraInfo.Map())
+}
+
+// ParseURI parses a payment URI such as xrp:rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh?dt=123 and returns
+// the address and the currency id of the chain. Query parameters other than tags, memos and
+// payment ids, e.g. amount, are ignored.
+func ParseURI(uri string) (Address, currency.ID, error) {
+	scheme, rest, ok := strings.Cut(uri, ":")
+	if !ok {
+		return Address{}, currency.ID{}, fmt.Errorf("invalid address uri %q", uri)
+	}
+	coinType, ok := uriSchemes[strings.ToLower(scheme)]
+	if !ok {
+		return Address{}, currency.ID{}, fmt.Errorf("unsupported address uri scheme %q", scheme)
+	}
+	rest = strings.TrimPrefix(rest, "//")
+	addr, rawQuery, _ := strings.Cut(rest, "?")
+	if coinType == currency.CoinTypeETH {
+		// EIP-681: ethereum:<address>[@<chain id>][/<function>]
+		addr, _, _ = strings.Cut(addr, "@")
+		addr, _, _ = strings.Cut(addr, "/")
+	}
+	if coinType == currency.CoinTypeXLM {
+		// SEP-0007: web+stellar:pay?destination=<address>
+		addr = strings.TrimPrefix(addr, "pay")
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Address{}, currency.ID{}, fmt.Errorf("invalid address uri %q: %w", uri, err)
+	}
+	if destination := query.Get("destination"); addr == "" && destination != "" {
+		addr = destination
+	}
+	if addr == "" {
+		return Address{}, currency.ID{}, fmt.Errorf("invalid address uri %q: empty address", uri)
+	}
+
+	result := Address{Address: addr}
+	found := map[string]bool{}
+	for _, p := range uriParams {
+		if v := query.Get(p.param); v != "" && !found[p.key] {
+			result.ExtraInfo.set(p.key, v)
+			found[p.key] = true
+		}
+	}
+	return result, currency.NewID(coinType, ""), nil
+}
+
+// ToOrderedMap returns the address in the shape of transaction addrs of PostPermissionRequest,
+// {"address": ..., "addr_extra_info": [{"tag": ...}]}
+func (a Address) ToOrderedMap() *orderedmap.OrderedMap {
+	infos := make([]*orderedmap.OrderedMap, 0)
+	m := a.ExtraInfo.Map()
+	for _, k := range a.ExtraInfo.keys() {
+		info := orderedmap.New()
+		info.Set(k, m[k])
+		infos = append(infos, info)
+	}
+	o := orderedmap.New()
+	o.Set("address", a.Address)
+	o.Set("addr_extra_info", infos)
+	return o
+}
+
+// ToAddressValidationOrderedMap returns the address in the shape of addrs of PostAddressValidation,
+// {"address": ..., "addr_extra_info": {"tag": ...}}
+func (a Address) ToAddressValidationOrderedMap() *orderedmap.OrderedMap {
+	info := orderedmap.New()
+	m := a.ExtraInfo.Map()
+	for _, k := range a.ExtraInfo.keys() {
+		info.Set(k, m[k])
+	}
+	o := orderedmap.New()
+	o.Set("address", a.Address)
+	o.Set("addr_extra_info", info)
+	return o
+}
+
+// PermissionRequestAddrs returns addrs for the originator_vasp or beneficiary_vasp of PostPermissionRequest
+func PermissionRequestAddrs(addrs ...Address) []*orderedmap.OrderedMap {
+	result := make([]*orderedmap.OrderedMap, len(addrs))
+	for i, a := range addrs {
+		result[i] = a.ToOrderedMap()
+	}
+	return result
+}
+
+// AddressValidationAddrs returns addrs for PostAddressValidation
+func AddressValidationAddrs(addrs ...Address) []*orderedmap.OrderedMap {
+	result := make([]*orderedmap.OrderedMap, len(addrs))
+	for i, a := range addrs {
+		result[i] = a.ToAddressValidationOrderedMap()
+	}
+	return result
+}
+
+// WalletAddressFilterAddrs returns addrs for PostWalletAddressFilter, which takes plain addresses
+func WalletAddressFilterAddrs(addrs ...Address) []string {
+	result := make([]string, len(addrs))
+	for i, a := range addrs {
+		result[i] = a.Address
+	}
+	return result
+}
+
+// FromOrderedMap decodes an address of any of the shapes above
+func FromOrderedMap(o *orderedmap.OrderedMap) (Address, error) {
+	addr, _ := o.Get("address")
+	strAddr, ok := addr.(string)
+	if !ok || strAddr == "" {
+		return Address{}, errors.New("address must contain address")
+	}
+	result := Address{Address: strAddr}
+	extraInfo, _ := o.Get("addr_extra_info")
+	if extraInfo == nil {
+		return result, nil
+	}
+	var infos []*orderedmap.OrderedMap
+	switch extraInfo.(type) {
+	case []*orderedmap.OrderedMap, []interface{}:
+		infos = toOrderedMaps(extraInfo)
+	case *orderedmap.OrderedMap, orderedmap.OrderedMap:
+		infos = []*orderedmap.OrderedMap{toOrderedMap(extraInfo)}
+	default:
+		return Address{}, fmt.Errorf("invalid addr_extra_info of %s", strAddr)
+	}
+	for k, v := range flattenExtraInfo(infos) {
+		result.ExtraInfo.set(k, v)
+	}
+	return result, nil
+}
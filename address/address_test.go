@@ -0,0 +1,156 @@
+package address
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/currency"
+	"github.com/iancoleman/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	var tests = []struct {
+		currencyID string
+		address    string
+		extraInfo  map[string]string
+		valid      bool
+	}{
+		{"sygna:0x80000000", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", nil, true},
+		{"sygna:0x80000000", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", nil, true},
+		{"sygna:0x80000000", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", nil, true},
+		{"sygna:0x80000000", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", nil, true},
+		{"sygna:0x80000000", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", nil, true},
+		{"sygna:0x80000000", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3", nil, false},
+		{"sygna:0x80000000", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdp", nil, false},
+		{"sygna:0x80000000", "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7k7grplx", nil, false},
+		{"sygna:0x80000000", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", nil, false},
+		{"sygna:0x80000000", "LVg2kJoFNg45Nbpy53h7Fe1wKyeXVRhMH9", nil, false},
+		{"sygna:0x80000002", "LVg2kJoFNg45Nbpy53h7Fe1wKyeXVRhMH9", nil, true},
+		{"sygna:0x80000002", "ltc1qg82wuvelr2eqj4p3k7g4e98d8mq7gm7nq9dqc6", nil, false},
+		{"sygna:0x8000003c", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", nil, true},
+		{"sygna:0x8000003c", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", nil, true},
+		{"sygna:0x8000003c", "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", nil, true},
+		{"sygna:0x8000003c", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", nil, false},
+		{"sygna:0x8000003c", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea", nil, false},
+		{"sygna:0x8000003c.dac17f958d2ee523a2206206994597c13d831ec7", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", nil, true},
+		{"sygna:0x80000090", "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh", map[string]string{"tag": "123"}, true},
+		{"sygna:0x80000090", "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh", map[string]string{"tag": "4294967296"}, false},
+		{"sygna:0x80000090", "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLi", nil, false},
+		{"sygna:0x800002ca", "bnb1grpf0955h0ykzq3ar5nmum7y6gdfl6lxfn46h2", nil, true},
+		{"sygna:0x800002ca", "bnb1grpf0955h0ykzq3ar5nmum7y6gdfl6lxfn46h3", nil, false},
+		{"sygna:0x800000c3", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", nil, true},
+		{"sygna:0x800000c3", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u", nil, false},
+		{"sygna:0x800000c3", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", nil, false},
+	}
+	for _, test := range tests {
+		err := Validate(test.currencyID, test.address, test.extraInfo)
+		assert.Equal(t, test.valid, err == nil, "%s %s: %v", test.currencyID, test.address, err)
+	}
+}
+
+func TestValidateUnsupported(t *testing.T) {
+	err := Validate("sygna:0x800007e5", "addr1", nil)
+	assert.True(t, errors.Is(err, ErrUnsupportedCurrency))
+	assert.False(t, Supported("sygna:0x800007e5"))
+	assert.True(t, Supported("sygna:0x8000003c.dac17f958d2ee523a2206206994597c13d831ec7"))
+
+	assert.False(t, Supported("invalid"))
+	assert.Error(t, Validate("invalid", "addr", nil))
+}
+
+func TestValidateTransaction(t *testing.T) {
+	transaction := orderedmap.New()
+	assert.NoError(t, transaction.UnmarshalJSON([]byte(`{
+		"originator_vasp": {"vasp_code": "VASPUSNY1", "addrs": [{"address": "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh", "addr_extra_info": [{"tag": "123"}]}]},
+		"beneficiary_vasp": {"vasp_code": "VASPJPJT4", "addrs": [{"address": "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh"}]},
+		"currency_id": "sygna:0x80000090",
+		"amount": "4.51120"
+	}`)))
+	assert.NoError(t, ValidateTransaction(transaction))
+
+	assert.NoError(t, transaction.UnmarshalJSON([]byte(`{
+		"originator_vasp": {"vasp_code": "VASPUSNY1", "addrs": [{"address": "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh", "addr_extra_info": [{"tag": "abc"}]}]},
+		"currency_id": "sygna:0x80000090"
+	}`)))
+	assert.EqualError(t, ValidateTransaction(transaction),
+		`originator_vasp.addrs[0]: invalid sygna:0x80000090 address "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh": destination tag "abc" must be an unsigned 32 bit integer`)
+
+	transaction = orderedmap.New()
+	transaction.Set("currency_id", "sygna:0x800007e5")
+	assert.NoError(t, ValidateTransaction(transaction))
+
+	assert.Error(t, ValidateTransaction(orderedmap.New()))
+}
+
+func TestParseURI(t *testing.T) {
+	var tests = []struct {
+		uri      string
+		id       string
+		expected Address
+	}{
+		{"xrp:rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh?dt=123", "sygna:0x80000090", Address{Address: "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh", ExtraInfo: AddressExtraInfo{Tag: "123"}}},
+		{"ripple:rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh?amount=5&tag=7", "sygna:0x80000090", Address{Address: "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh", ExtraInfo: AddressExtraInfo{Tag: "7"}}},
+		{"xrp:rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh?dt=1&tag=2", "sygna:0x80000090", Address{Address: "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh", ExtraInfo: AddressExtraInfo{Tag: "1"}}},
+		{"xrp:rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh?tag=2&dt=1", "sygna:0x80000090", Address{Address: "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh", ExtraInfo: AddressExtraInfo{Tag: "1"}}},
+		{"bitcoin:bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq?amount=0.1&label=Alice", "sygna:0x80000000", Address{Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"}},
+		{"ethereum:0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed@1/transfer?value=1", "sygna:0x8000003c", Address{Address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"}},
+		{"bnb:bnb1grpf0955h0ykzq3ar5nmum7y6gdfl6lxfn46h2?memo=hello%20world", "sygna:0x800002ca", Address{Address: "bnb1grpf0955h0ykzq3ar5nmum7y6gdfl6lxfn46h2", ExtraInfo: AddressExtraInfo{Memo: "hello world"}}},
+		{"web+stellar:pay?destination=GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO&memo=42", "sygna:0x80000094", Address{Address: "GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO", ExtraInfo: AddressExtraInfo{Memo: "42"}}},
+	}
+	for _, test := range tests {
+		a, id, err := ParseURI(test.uri)
+		assert.Nil(t, err, test.uri)
+		assert.Equal(t, test.id, id.String())
+		assert.Equal(t, test.expected, a)
+	}
+
+	for _, invalid := range []string{"rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh", "dogecoin:D8vFz4p1L37jdg47HXKtSHA5uYLYxbGgPD", "xrp:?dt=1", "xrp:r?dt=%zz"} {
+		_, _, err := ParseURI(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestAddressOrderedMap(t *testing.T) {
+	a := Address{
+		Address:   "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh",
+		ExtraInfo: AddressExtraInfo{Tag: "123", Other: map[string]string{"note": "x"}},
+	}
+
+	b, err := a.ToOrderedMap().MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"address":"rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh","addr_extra_info":[{"tag":"123"},{"note":"x"}]}`, string(b))
+
+	b, err = a.ToAddressValidationOrderedMap().MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"address":"rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh","addr_extra_info":{"tag":"123","note":"x"}}`, string(b))
+
+	b, err = Address{Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"}.ToOrderedMap().MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"address":"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq","addr_extra_info":[]}`, string(b))
+
+	assert.Equal(t, []string{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh"},
+		WalletAddressFilterAddrs(Address{Address: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"}, a))
+	assert.Len(t, PermissionRequestAddrs(a, a), 2)
+	assert.Len(t, AddressValidationAddrs(a), 1)
+
+	for _, o := range []*orderedmap.OrderedMap{a.ToOrderedMap(), a.ToAddressValidationOrderedMap()} {
+		b, _ := o.MarshalJSON()
+		decoded := orderedmap.New()
+		assert.Nil(t, decoded.UnmarshalJSON(b))
+		parsed, err := FromOrderedMap(decoded)
+		assert.Nil(t, err)
+		assert.Equal(t, a, parsed)
+
+		parsed, err = FromOrderedMap(o)
+		assert.Nil(t, err)
+		assert.Equal(t, a, parsed)
+	}
+
+	_, err = FromOrderedMap(orderedmap.New())
+	assert.NotNil(t, err)
+
+	assert.Nil(t, a.Validate("sygna:0x80000090"))
+	assert.Equal(t, []string{KeyTag}, ExtraInfoKeys(currency.NewID(currency.CoinTypeXRP, "")))
+	assert.Empty(t, ExtraInfoKeys(currency.NewID(currency.CoinTypeBTC, "")))
+}
@@ -0,0 +1,138 @@
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	bitcoinAlphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	rippleAlphabet  = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz"
+	bech32Charset   = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// base58CheckDecode decodes a base58check string and returns its payload, version byte included
+func base58CheckDecode(s, alphabet string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("empty base58 string")
+	}
+	value := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range s {
+		i := strings.IndexRune(alphabet, r)
+		if i < 0 {
+			return nil, errors.New("invalid base58 character")
+		}
+		value.Mul(value, radix)
+		value.Add(value, big.NewInt(int64(i)))
+	}
+
+	leadingZeros := 0
+	for leadingZeros < len(s) && s[leadingZeros] == alphabet[0] {
+		leadingZeros++
+	}
+	decoded := append(make([]byte, leadingZeros), value.Bytes()...)
+	if len(decoded) < 5 {
+		return nil, errors.New("base58 string is too short")
+	}
+
+	payload, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return nil, errors.New("invalid base58 checksum")
+	}
+	return payload, nil
+}
+
+type bech32Variant int
+
+const (
+	bech32 bech32Variant = iota + 1
+	bech32m
+)
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	result := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		result = append(result, hrp[i]>>5)
+	}
+	result = append(result, 0)
+	for i := 0; i < len(hrp); i++ {
+		result = append(result, hrp[i]&31)
+	}
+	return result
+}
+
+// bech32Decode decodes a bech32 or bech32m string and returns its hrp, 5 bit data without checksum and variant
+func bech32Decode(s string) (string, []byte, bech32Variant, error) {
+	if len(s) > 90 {
+		return "", nil, 0, errors.New("bech32 string is too long")
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, errors.New("bech32 string has mixed case")
+	}
+	s = strings.ToLower(s)
+	separator := strings.LastIndexByte(s, '1')
+	if separator < 1 || separator+7 > len(s) {
+		return "", nil, 0, errors.New("invalid bech32 separator position")
+	}
+	hrp := s[:separator]
+	data := make([]byte, 0, len(s)-separator-1)
+	for _, r := range s[separator+1:] {
+		i := strings.IndexRune(bech32Charset, r)
+		if i < 0 {
+			return "", nil, 0, errors.New("invalid bech32 character")
+		}
+		data = append(data, byte(i))
+	}
+
+	switch bech32Polymod(append(bech32HRPExpand(hrp), data...)) {
+	case 1:
+		return hrp, data[:len(data)-6], bech32, nil
+	case 0x2bc830a3:
+		return hrp, data[:len(data)-6], bech32m, nil
+	default:
+		return "", nil, 0, errors.New("invalid bech32 checksum")
+	}
+}
+
+// convertBits regroups 5 bit values into bytes, rejecting non zero padding
+func convertBits(data []byte, from, to uint) ([]byte, error) {
+	acc, bits := uint32(0), uint(0)
+	maxv := uint32(1)<<to - 1
+	result := make([]byte, 0, len(data)*int(from)/int(to))
+	for _, v := range data {
+		if uint32(v)>>from != 0 {
+			return nil, errors.New("invalid data range")
+		}
+		acc = acc<<from | uint32(v)
+		bits += from
+		for bits >= to {
+			bits -= to
+			result = append(result, byte(acc>>bits&maxv))
+		}
+	}
+	if bits >= from || (acc<<(to-bits))&maxv != 0 {
+		return nil, errors.New("invalid padding")
+	}
+	return result, nil
+}
@@ -0,0 +1,269 @@
+/*
+Package address validates the format of blockchain addresses offline, to catch
+typos before calling PostAddressValidation or PostWalletAddressFilter.
+Only mainnet address formats are accepted.
+*/
+package address
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/currency"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/iancoleman/orderedmap"
+)
+
+// ErrUnsupportedCurrency is returned for currencies without an offline validator
+var ErrUnsupportedCurrency = errors.New("no offline address validator for currency")
+
+// Validator checks the format of an address and its extra info such as a destination tag
+type Validator func(address string, extraInfo map[string]string) error
+
+// validators are keyed by the coin type of the chain; tokens use the validator of their chain
+var validators = map[uint32]Validator{
+	currency.CoinTypeBTC: validateBitcoin,
+	currency.CoinTypeLTC: validateLitecoin,
+	currency.CoinTypeETH: validateEVM,
+	currency.CoinTypeETC: validateEVM,
+	currency.CoinTypeXRP: validateXRP,
+	currency.CoinTypeBNB: validateBNB,
+	currency.CoinTypeTRX: validateTRON,
+}
+
+// Supported reports whether addresses of the currency can be validated offline
+func Supported(currencyID string) bool {
+	id, err := currency.ParseID(currencyID)
+	if err != nil {
+		return false
+	}
+	_, ok := validators[id.CoinType]
+	return ok
+}
+
+// Validate checks the format of address for the currency. extraInfo holds the
+// addr_extra_info entries such as {"tag": "123"}. It returns ErrUnsupportedCurrency
+// if the currency has no offline validator.
+func Validate(currencyID, address string, extraInfo map[string]string) error {
+	id, err := currency.ParseID(currencyID)
+	if err != nil {
+		return err
+	}
+	validator, ok := validators[id.CoinType]
+	if !ok {
+		return fmt.Errorf("%w %s", ErrUnsupportedCurrency, currencyID)
+	}
+	if err := validator(address, extraInfo); err != nil {
+		return fmt.Errorf("invalid %s address %q: %w", currencyID, address, err)
+	}
+	return nil
+}
+
+// ValidateTransaction checks every address of the originator and beneficiary VASP of a
+// permission request transaction. Currencies without an offline validator are skipped.
+func ValidateTransaction(transaction *orderedmap.OrderedMap) error {
+	currencyID, _ := transaction.Get("currency_id")
+	strCurrencyID, ok := currencyID.(string)
+	if !ok {
+		return errors.New("transaction must contain currency_id")
+	}
+	if !Supported(strCurrencyID) {
+		return nil
+	}
+
+	for _, side := range []string{"originator_vasp", "beneficiary_vasp"} {
+		vasp, ok := transaction.Get(side)
+		if !ok {
+			continue
+		}
+		addrs, _ := toOrderedMap(vasp).Get("addrs")
+		for i, addr := range toOrderedMaps(addrs) {
+			a, err := FromOrderedMap(addr)
+			if err == nil {
+				err = a.Validate(strCurrencyID)
+			}
+			if err != nil {
+				return fmt.Errorf("%s.addrs[%d]: %w", side, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func toOrderedMap(v interface{}) *orderedmap.OrderedMap {
+	switch o := v.(type) {
+	case *orderedmap.OrderedMap:
+		return o
+	case orderedmap.OrderedMap:
+		return &o
+	default:
+		return orderedmap.New()
+	}
+}
+
+func toOrderedMaps(v interface{}) []*orderedmap.OrderedMap {
+	switch values := v.(type) {
+	case []*orderedmap.OrderedMap:
+		return values
+	case []interface{}:
+		result := make([]*orderedmap.OrderedMap, len(values))
+		for i, value := range values {
+			result[i] = toOrderedMap(value)
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+func flattenExtraInfo(extraInfo []*orderedmap.OrderedMap) map[string]string {
+	result := map[string]string{}
+	for _, info := range extraInfo {
+		for _, k := range info.Keys() {
+			v, _ := info.Get(k)
+			result[k] = fmt.Sprint(v)
+		}
+	}
+	return result
+}
+
+func validateBitcoin(address string, _ map[string]string) error {
+	return validateUTXO(address, []byte{0x00, 0x05}, "bc")
+}
+
+func validateLitecoin(address string, _ map[string]string) error {
+	return validateUTXO(address, []byte{0x30, 0x32, 0x05}, "ltc")
+}
+
+// validateUTXO accepts base58check addresses with one of versions and segwit addresses with hrp
+func validateUTXO(address string, versions []byte, hrp string) error {
+	if strings.HasPrefix(strings.ToLower(address), hrp+"1") {
+		return validateSegwit(address, hrp)
+	}
+	payload, err := base58CheckDecode(address, bitcoinAlphabet)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 21 {
+		return errors.New("invalid length")
+	}
+	for _, version := range versions {
+		if payload[0] == version {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown version byte 0x%02x", payload[0])
+}
+
+// validateSegwit follows BIP-173 and BIP-350: version 0 uses bech32, later versions bech32m
+func validateSegwit(address, hrp string) error {
+	decodedHRP, data, variant, err := bech32Decode(address)
+	if err != nil {
+		return err
+	}
+	if decodedHRP != hrp {
+		return fmt.Errorf("unexpected prefix %q", decodedHRP)
+	}
+	if len(data) == 0 || data[0] > 16 {
+		return errors.New("invalid witness version")
+	}
+	program, err := convertBits(data[1:], 5, 8)
+	if err != nil {
+		return err
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return errors.New("invalid witness program length")
+	}
+	if data[0] == 0 {
+		if len(program) != 20 && len(program) != 32 {
+			return errors.New("invalid witness program length")
+		}
+		if variant != bech32 {
+			return errors.New("witness version 0 must use bech32")
+		}
+	} else if variant != bech32m {
+		return errors.New("witness version 1 and above must use bech32m")
+	}
+	return nil
+}
+
+// validateEVM accepts 0x prefixed addresses which are all lower or upper case, or match their EIP-55 checksum
+func validateEVM(address string, _ map[string]string) error {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return errors.New("must be 0x followed by 40 hex characters")
+	}
+	hexAddress := address[2:]
+	if _, err := hex.DecodeString(hexAddress); err != nil {
+		return errors.New("must be 0x followed by 40 hex characters")
+	}
+	if hexAddress == strings.ToLower(hexAddress) || hexAddress == strings.ToUpper(hexAddress) {
+		return nil
+	}
+	if toChecksumAddress(hexAddress) != address {
+		return errors.New("invalid EIP-55 checksum")
+	}
+	return nil
+}
+
+func toChecksumAddress(hexAddress string) string {
+	lower := strings.ToLower(hexAddress)
+	hash := hex.EncodeToString(crypto.Keccak256([]byte(lower)))
+	result := []byte(lower)
+	for i, c := range result {
+		if c >= 'a' && hash[i] >= '8' {
+			result[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(result)
+}
+
+// validateXRP accepts classic addresses; the destination tag must be a uint32
+func validateXRP(address string, extraInfo map[string]string) error {
+	payload, err := base58CheckDecode(address, rippleAlphabet)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 21 || payload[0] != 0x00 {
+		return errors.New("invalid classic address")
+	}
+	if tag, ok := extraInfo["tag"]; ok && tag != "" {
+		if _, err := strconv.ParseUint(tag, 10, 32); err != nil {
+			return fmt.Errorf("destination tag %q must be an unsigned 32 bit integer", tag)
+		}
+	}
+	return nil
+}
+
+// validateBNB accepts BNB Beacon Chain bech32 addresses
+func validateBNB(address string, _ map[string]string) error {
+	hrp, data, variant, err := bech32Decode(address)
+	if err != nil {
+		return err
+	}
+	if hrp != "bnb" || variant != bech32 {
+		return fmt.Errorf("unexpected prefix %q", hrp)
+	}
+	program, err := convertBits(data, 5, 8)
+	if err != nil {
+		return err
+	}
+	if len(program) != 20 {
+		return errors.New("invalid length")
+	}
+	return nil
+}
+
+// validateTRON accepts base58check addresses with version byte 0x41
+func validateTRON(address string, _ map[string]string) error {
+	payload, err := base58CheckDecode(address, bitcoinAlphabet)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 21 || payload[0] != 0x41 {
+		return errors.New("invalid TRON address")
+	}
+	return nil
+}
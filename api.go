@@ -1,11 +1,14 @@
 package bridgeutil
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"sync"
+	"time"
 
 	"github.com/iancoleman/orderedmap"
 	"github.com/imroc/req/v3"
@@ -14,13 +17,29 @@ import (
 const get = "GET"
 const post = "POST"
 
-// BridgeAPI is a convenient struct for using sygna API
+// ExchangeLogger is called after every API call with the request body and the parsed response or error.
+// Bodies contain private_info and personal data, redact them before logging, e.g. with redact.Policy.LogExchange.
+type ExchangeLogger func(method, path string, body, response interface{}, err error)
+
+/*
+BridgeAPI is a convenient struct for using sygna API.
+Create it with NewBridgeAPI to configure the HTTP client, or as a struct literal for the defaults.
+A BridgeAPI is safe for concurrent use; do not modify its fields once it is in use.
+*/
 type BridgeAPI struct {
-	APIDomain  string
-	APIKey     string
-	UserAgent  string
-	client     *req.Client
-	clientOnce sync.Once
+	APIDomain      string
+	APIKey         string
+	UserAgent      string
+	ExchangeLogger ExchangeLogger
+	// bridgePublicKey verifies the responses signed by Sygna Bridge, see WithBridgePublicKey
+	bridgePublicKey string
+	// timeout bounds each call to Sygna Bridge, see WithTimeout
+	timeout     time.Duration
+	headers     http.Header
+	middlewares []Middleware
+	ctx         context.Context
+	client      *req.Client
+	clientOnce  sync.Once
 }
 
 func (api *BridgeAPI) getClient() *req.Client {
@@ -30,6 +49,38 @@ func (api *BridgeAPI) getClient() *req.Client {
 	return api.client
 }
 
+/*
+WithContext returns a shallow copy of api whose calls use ctx for cancellation, deadlines and tracing.
+The copy shares the HTTP client, middlewares and limits of api.
+
+	response, err := api.WithContext(ctx).PostPermissionRequest(param)
+*/
+func (api *BridgeAPI) WithContext(ctx context.Context) *BridgeAPI {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := &BridgeAPI{
+		APIDomain:       api.APIDomain,
+		APIKey:          api.APIKey,
+		UserAgent:       api.UserAgent,
+		ExchangeLogger:  api.ExchangeLogger,
+		bridgePublicKey: api.bridgePublicKey,
+		timeout:         api.timeout,
+		headers:         api.headers,
+		middlewares:     api.middlewares,
+		ctx:             ctx,
+	}
+	c.setClient(api.getClient())
+	return c
+}
+
+// setClient presets the client, it must be called before the first request
+func (api *BridgeAPI) setClient(client *req.Client) {
+	api.clientOnce.Do(func() {
+		api.client = client
+	})
+}
+
 func isHTTPStatusOK(statusCode int) bool {
 	if statusCode >= 200 && statusCode < 300 {
 		return true
@@ -72,33 +123,77 @@ func parseResponse(resp *req.Response, err error) (interface{}, error) {
 	return maps, nil
 }
 
-func request(api *BridgeAPI, method, path string, queryParams map[string]interface{}, body interface{}) (interface{}, error) {
-	if api.UserAgent == "" {
-		api.UserAgent = "util-go"
+// callContext returns the context of the calls of api, context.Background() when none is set
+func (api *BridgeAPI) callContext() context.Context {
+	if api.ctx == nil {
+		return context.Background()
+	}
+	return api.ctx
+}
+
+func request(api *BridgeAPI, endpoint, method, path string, queryParams map[string]interface{}, body interface{}) (interface{}, error) {
+	ex := &Exchange{
+		Context:  api.callContext(),
+		Endpoint: endpoint,
+		Method:   method,
+		Path:     path,
+		Query:    queryParams,
+		Body:     body,
+		Header:   api.headers.Clone(),
+	}
+	if ex.Header == nil {
+		ex.Header = http.Header{}
+	}
+
+	handler := Handler(api.send)
+	for i := len(api.middlewares) - 1; i >= 0; i-- {
+		handler = api.middlewares[i](handler)
+	}
+	response, err := handler(ex)
+
+	if api.ExchangeLogger != nil {
+		api.ExchangeLogger(method, path, ex.Body, response, err)
+	}
+	return response, err
+}
+
+// send is the innermost Handler, it sends the exchange to Sygna Bridge
+func (api *BridgeAPI) send(ex *Exchange) (interface{}, error) {
+	userAgent := api.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
 	}
 
 	client := api.getClient()
-	url := api.APIDomain + path
+	url := api.APIDomain + ex.Path
 
-	reqBuilder := client.R().
+	ctx := ex.Context
+	if api.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, api.timeout)
+		defer cancel()
+	}
+	reqBuilder := client.R().SetContext(ctx)
+	reqBuilder.Headers = ex.Header.Clone()
+	reqBuilder.
 		SetHeader("Content-type", "application/json;").
 		SetHeader("X-Api-Key", api.APIKey).
-		SetHeader("User-Agent", api.UserAgent)
+		SetHeader("User-Agent", userAgent)
 
-	if len(queryParams) > 0 {
-		for k, v := range queryParams {
+	if len(ex.Query) > 0 {
+		for k, v := range ex.Query {
 			reqBuilder.SetQueryParam(k, fmt.Sprint(v))
 		}
 	}
 
-	if body != nil {
-		reqBuilder.SetBodyJsonMarshal(body)
+	if ex.Body != nil {
+		reqBuilder.SetBodyJsonMarshal(ex.Body)
 	}
 
 	var resp *req.Response
 	var err error
 
-	switch method {
+	switch ex.Method {
 	case get:
 		resp, err = reqBuilder.Get(url)
 	case post:
@@ -106,18 +201,23 @@ func request(api *BridgeAPI, method, path string, queryParams map[string]interfa
 	default:
 		panic(errors.New("unsupported method"))
 	}
+
+	if resp != nil && resp.Response != nil {
+		ex.StatusCode = resp.StatusCode
+		ex.ResponseHeader = resp.Header
+	}
 	return parseResponse(resp, err)
 }
 
 /*
 GetVASP Get list of registered VASP associated with publicKey.
 Set validate false to disable validating returned vasp list data.
-Set isProdEnv true to use SygnaBridgeCentralPubkey to Verify data.
+Set isProdEnv true to use SygnaBridgeCentralPubkey to Verify data, unless WithBridgePublicKey is set.
 
 see https://developers.sygna.io/reference#bridgevasp-3
 */
 func (api *BridgeAPI) GetVASP(validate bool, isProdEnv ...bool) ([]*orderedmap.OrderedMap, error) {
-	response, err := request(api, get, "v2/bridge/vasp", nil, nil)
+	response, err := request(api, "GetVASP", get, "v2/bridge/vasp", nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -128,17 +228,7 @@ func (api *BridgeAPI) GetVASP(validate bool, isProdEnv ...bool) ([]*orderedmap.O
 		return mapVASPData, nil
 	}
 
-	isProd := false
-	if len(isProdEnv) > 0 {
-		isProd = isProdEnv[0]
-	}
-
-	publicKey := SygnaBridgeTestPubkey
-	if isProd {
-		publicKey = SygnaBridgeCentralPubkey
-	}
-
-	valid, err := Verify(response.(*orderedmap.OrderedMap), publicKey)
+	valid, err := VerifyContext(api.callContext(), response.(*orderedmap.OrderedMap), api.bridgeKey(isProdEnv))
 
 	if err != nil {
 		return nil, err
@@ -151,6 +241,19 @@ func (api *BridgeAPI) GetVASP(validate bool, isProdEnv ...bool) ([]*orderedmap.O
 	return mapVASPData, nil
 }
 
+// bridgeKey returns the key of WithBridgePublicKey, or else SygnaBridgeCentralPubkey when
+// isProdEnv is true and SygnaBridgeTestPubkey otherwise
+func (api *BridgeAPI) bridgeKey(isProdEnv []bool) string {
+	switch {
+	case api.bridgePublicKey != "":
+		return api.bridgePublicKey
+	case len(isProdEnv) > 0 && isProdEnv[0]:
+		return SygnaBridgeCentralPubkey
+	default:
+		return SygnaBridgeTestPubkey
+	}
+}
+
 // GetVASPPublicKey A Wrapper function of GetVASP to return specific VASP's Public Key.
 func (api *BridgeAPI) GetVASPPublicKey(targetVASPCode string, validate bool, isProdEnv ...bool) (string, error) {
 	response, err := api.GetVASP(validate, isProdEnv...)
@@ -178,7 +281,7 @@ func (api *BridgeAPI) GetStatus(transferID string) (*orderedmap.OrderedMap, erro
 	param := map[string]interface{}{
 		"transfer_id": transferID,
 	}
-	response, err := request(api, get, "v2/bridge/transaction/status", param, nil)
+	response, err := request(api, "GetStatus", get, "v2/bridge/transaction/status", param, nil)
 
 	if err != nil {
 		return nil, err
@@ -200,7 +303,7 @@ func (api *BridgeAPI) GetCurrencies(queryParams *orderedmap.OrderedMap) ([]*orde
 			param[k] = v
 		}
 	}
-	response, err := request(api, get, "v2/bridge/transaction/currencies", param, nil)
+	response, err := request(api, "GetCurrencies", get, "v2/bridge/transaction/currencies", param, nil)
 
 	if err != nil {
 		return nil, err
@@ -218,7 +321,7 @@ PostBeneficiaryEndpointURL Revise beneficiary endpoint url
 see https://developers.sygna.io/reference#bridgebeneficiaryendpointurl
 */
 func (api *BridgeAPI) PostBeneficiaryEndpointURL(param *orderedmap.OrderedMap) (*orderedmap.OrderedMap, error) {
-	response, err := request(api, post, "v2/bridge/vasp/beneficiary-endpoint-url", nil, param)
+	response, err := request(api, "PostBeneficiaryEndpointURL", post, "v2/bridge/vasp/beneficiary-endpoint-url", nil, param)
 
 	if err != nil {
 		return nil, err
@@ -232,7 +335,7 @@ PostPermissionRequest Should be called by the originator VASP to inform Sygna Br
 see https://developers.sygna.io/reference#bridgepermissionrequest-3
 */
 func (api *BridgeAPI) PostPermissionRequest(param *orderedmap.OrderedMap) (*orderedmap.OrderedMap, error) {
-	response, err := request(api, post, "v2/bridge/transaction/permission-request", nil, param)
+	response, err := request(api, "PostPermissionRequest", post, "v2/bridge/transaction/permission-request", nil, param)
 
 	if err != nil {
 		return nil, err
@@ -248,7 +351,7 @@ PostPermission Notify Sygna Bridge that you have confirmed specific permission R
 see https://developers.sygna.io/reference#bridgepermission-3
 */
 func (api *BridgeAPI) PostPermission(param *orderedmap.OrderedMap) (*orderedmap.OrderedMap, error) {
-	response, err := request(api, post, "v2/bridge/transaction/permission", nil, param)
+	response, err := request(api, "PostPermission", post, "v2/bridge/transaction/permission", nil, param)
 
 	if err != nil {
 		return nil, err
@@ -262,7 +365,7 @@ PostTransactionID Send broadcasted transaction id to Sygna Bridge for purpose of
 see https://developers.sygna.io/reference#bridgetransactionid-3
 */
 func (api *BridgeAPI) PostTransactionID(param *orderedmap.OrderedMap) (*orderedmap.OrderedMap, error) {
-	response, err := request(api, post, "v2/bridge/transaction/txid", nil, param)
+	response, err := request(api, "PostTransactionID", post, "v2/bridge/transaction/txid", nil, param)
 
 	if err != nil {
 		return nil, err
@@ -276,7 +379,7 @@ PostRetry Retrieve the lost transfer requests
 see https://developers.sygna.io/reference#bridgeretry-3
 */
 func (api *BridgeAPI) PostRetry(param *orderedmap.OrderedMap) (*orderedmap.OrderedMap, error) {
-	response, err := request(api, post, "v2/bridge/transaction/retry", nil, param)
+	response, err := request(api, "PostRetry", post, "v2/bridge/transaction/retry", nil, param)
 
 	if err != nil {
 		return nil, err
@@ -285,7 +388,7 @@ func (api *BridgeAPI) PostRetry(param *orderedmap.OrderedMap) (*orderedmap.Order
 }
 
 func (api *BridgeAPI) PostTransactionCDDRequest(param *orderedmap.OrderedMap) (*orderedmap.OrderedMap, error) {
-	response, err := request(api, post, "v2/bridge/transaction/cdd-request", nil, param)
+	response, err := request(api, "PostTransactionCDDRequest", post, "v2/bridge/transaction/cdd-request", nil, param)
 	if err != nil {
 		return nil, err
 	}
@@ -294,7 +397,7 @@ func (api *BridgeAPI) PostTransactionCDDRequest(param *orderedmap.OrderedMap) (*
 }
 
 func (api *BridgeAPI) PostTransactionCDD(param *orderedmap.OrderedMap) (*orderedmap.OrderedMap, error) {
-	response, err := request(api, post, "v2/bridge/transaction/cdd", nil, param)
+	response, err := request(api, "PostTransactionCDD", post, "v2/bridge/transaction/cdd", nil, param)
 
 	if err != nil {
 		return nil, err
@@ -315,7 +418,7 @@ func (api *BridgeAPI) PostWalletAddressFilter(param *orderedmap.OrderedMap, igno
 	if len(ignoreKYT) > 0 {
 		q["ignore_kyt"] = ignoreKYT[0]
 	}
-	response, err := request(api, post, "v2/bridge/wallet-address-filter", q, param)
+	response, err := request(api, "PostWalletAddressFilter", post, "v2/bridge/wallet-address-filter", q, param)
 	if err != nil {
 		return nil, err
 	}
@@ -325,7 +428,7 @@ func (api *BridgeAPI) PostWalletAddressFilter(param *orderedmap.OrderedMap, igno
 
 // Get vasp details by vasp code
 func (api *BridgeAPI) GetVASPDetails(vaspCode string, validate bool, isProdEnv ...bool) (*orderedmap.OrderedMap, error) {
-	response, err := request(api, get, fmt.Sprintf("v2/bridge/vasp/detail/%s", url.PathEscape(vaspCode)), nil, nil)
+	response, err := request(api, "GetVASPDetails", get, fmt.Sprintf("v2/bridge/vasp/detail/%s", url.PathEscape(vaspCode)), nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -336,17 +439,7 @@ func (api *BridgeAPI) GetVASPDetails(vaspCode string, validate bool, isProdEnv .
 		return VASPDataObject, nil
 	}
 
-	isProd := false
-	if len(isProdEnv) > 0 {
-		isProd = isProdEnv[0]
-	}
-
-	publicKey := SygnaBridgeTestPubkey
-	if isProd {
-		publicKey = SygnaBridgeCentralPubkey
-	}
-
-	valid, err := Verify(response.(*orderedmap.OrderedMap), publicKey)
+	valid, err := VerifyContext(api.callContext(), response.(*orderedmap.OrderedMap), api.bridgeKey(isProdEnv))
 
 	if err != nil {
 		return nil, err
@@ -365,7 +458,7 @@ func (api *BridgeAPI) GetVASPUsages(startAt, endAt int64, validate bool, isProdE
 		"start_at": startAt,
 		"end_at":   endAt,
 	}
-	response, err := request(api, get, "v2/bridge/vasp/usage", param, nil)
+	response, err := request(api, "GetVASPUsages", get, "v2/bridge/vasp/usage", param, nil)
 
 	if err != nil {
 		return nil, err
@@ -378,17 +471,7 @@ func (api *BridgeAPI) GetVASPUsages(startAt, endAt int64, validate bool, isProdE
 		return usageDataObject, nil
 	}
 
-	isProd := false
-	if len(isProdEnv) > 0 {
-		isProd = isProdEnv[0]
-	}
-
-	publicKey := SygnaBridgeTestPubkey
-	if isProd {
-		publicKey = SygnaBridgeCentralPubkey
-	}
-
-	valid, err := Verify(response.(*orderedmap.OrderedMap), publicKey)
+	valid, err := VerifyContext(api.callContext(), response.(*orderedmap.OrderedMap), api.bridgeKey(isProdEnv))
 
 	if err != nil {
 		return nil, err
@@ -403,7 +486,7 @@ func (api *BridgeAPI) GetVASPUsages(startAt, endAt int64, validate bool, isProdE
 
 // PostServerStatus declares that the VASP’s server is currently in maintenance.
 func (api *BridgeAPI) PostServerStatus(param *orderedmap.OrderedMap) (*orderedmap.OrderedMap, error) {
-	response, err := request(api, post, "v2/bridge/vasp/server-status", nil, param)
+	response, err := request(api, "PostServerStatus", post, "v2/bridge/vasp/server-status", nil, param)
 
 	if err != nil {
 		return nil, err
@@ -411,8 +494,9 @@ func (api *BridgeAPI) PostServerStatus(param *orderedmap.OrderedMap) (*orderedma
 	return response.(*orderedmap.OrderedMap), nil
 }
 
+// PostVASPBeneficiaryCheckingRule Declare the IVMS101 fields your VASP requires; param can be built with BeneficiaryCheckingRule.ToOrderedMap.
 func (api *BridgeAPI) PostVASPBeneficiaryCheckingRule(param *orderedmap.OrderedMap) (*orderedmap.OrderedMap, error) {
-	response, err := request(api, post, "v2/bridge/vasp/beneficiary-checking-rule", nil, param)
+	response, err := request(api, "PostVASPBeneficiaryCheckingRule", post, "v2/bridge/vasp/beneficiary-checking-rule", nil, param)
 
 	if err != nil {
 		return nil, err
@@ -421,7 +505,7 @@ func (api *BridgeAPI) PostVASPBeneficiaryCheckingRule(param *orderedmap.OrderedM
 }
 
 func (api *BridgeAPI) PostTransactionCancel(param *orderedmap.OrderedMap) (*orderedmap.OrderedMap, error) {
-	response, err := request(api, post, "v2/bridge/transaction/cancel", nil, param)
+	response, err := request(api, "PostTransactionCancel", post, "v2/bridge/transaction/cancel", nil, param)
 
 	if err != nil {
 		return nil, err
@@ -430,7 +514,7 @@ func (api *BridgeAPI) PostTransactionCancel(param *orderedmap.OrderedMap) (*orde
 }
 
 func (api *BridgeAPI) PostAddressValidation(param *orderedmap.OrderedMap) (*orderedmap.OrderedMap, error) {
-	response, err := request(api, post, "v2/bridge/transaction/address-validation", nil, param)
+	response, err := request(api, "PostAddressValidation", post, "v2/bridge/transaction/address-validation", nil, param)
 
 	if err != nil {
 		return nil, err
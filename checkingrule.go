@@ -0,0 +1,327 @@
+package bridgeutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/ivms101"
+	"github.com/iancoleman/orderedmap"
+)
+
+/*
+BeneficiaryCheckingRule is the rule a beneficiary VASP declares with PostVASPBeneficiaryCheckingRule.
+Every flag set to true marks an IVMS101 field the VASP requires and a flag set to false a field it
+accepts without requiring it. A nil flag or rule marks a field the VASP does not expect at all.
+The rule applies to every natural and legal person of the originator and the beneficiary.
+
+	{"natural_person":{"country_of_residence":true,"date_and_place_of_birth":{"date_of_birth":true,"place_of_birth":true}},
+	 "legal_person":{"name_identifiers":{"legal_person_name_identifier_type":true,"legal_person_name":true}}}
+*/
+type BeneficiaryCheckingRule struct {
+	NaturalPerson *NaturalPersonRule `json:"natural_person,omitempty"`
+	LegalPerson   *LegalPersonRule   `json:"legal_person,omitempty"`
+}
+
+// NaturalPersonRule flags the fields required of a natural person
+type NaturalPersonRule struct {
+	CountryOfResidence      *bool                       `json:"country_of_residence,omitempty"`
+	CustomerIdentification  *bool                       `json:"customer_identification,omitempty"`
+	DateAndPlaceOfBirth     *DateAndPlaceOfBirthRule    `json:"date_and_place_of_birth,omitempty"`
+	GeographicAddress       *AddressRule                `json:"geographic_address,omitempty"`
+	LocalNameIdentifiers    *NaturalPersonNameRule      `json:"local_name_identifiers,omitempty"`
+	NameIdentifiers         *NaturalPersonNameRule      `json:"name_identifiers,omitempty"`
+	NationalIdentification  *NationalIdentificationRule `json:"national_identification,omitempty"`
+	PhoneticNameIdentifiers *NaturalPersonNameRule      `json:"phonetic_name_identifiers,omitempty"`
+}
+
+// LegalPersonRule flags the fields required of a legal person.
+// CustomerIdentification is the customer_number of the IVMS101 legal person.
+type LegalPersonRule struct {
+	CountryOfRegistration   *bool                       `json:"country_of_registration,omitempty"`
+	CustomerIdentification  *bool                       `json:"customer_identification,omitempty"`
+	GeographicAddress       *AddressRule                `json:"geographic_address,omitempty"`
+	LocalNameIdentifiers    *LegalPersonNameRule        `json:"local_name_identifiers,omitempty"`
+	NameIdentifiers         *LegalPersonNameRule        `json:"name_identifiers,omitempty"`
+	NationalIdentification  *NationalIdentificationRule `json:"national_identification,omitempty"`
+	PhoneticNameIdentifiers *LegalPersonNameRule        `json:"phonetic_name_identifiers,omitempty"`
+}
+
+// DateAndPlaceOfBirthRule flags the fields required of the date and place of birth
+type DateAndPlaceOfBirthRule struct {
+	DateOfBirth  *bool `json:"date_of_birth,omitempty"`
+	PlaceOfBirth *bool `json:"place_of_birth,omitempty"`
+}
+
+// NaturalPersonNameRule flags the fields required of every name identifier of a natural person
+type NaturalPersonNameRule struct {
+	PrimaryIdentifier   *bool `json:"primary_identifier,omitempty"`
+	SecondaryIdentifier *bool `json:"secondary_identifier,omitempty"`
+	NameIdentifierType  *bool `json:"name_identifier_type,omitempty"`
+}
+
+// LegalPersonNameRule flags the fields required of every name identifier of a legal person
+type LegalPersonNameRule struct {
+	LegalPersonNameIdentifierType *bool `json:"legal_person_name_identifier_type,omitempty"`
+	LegalPersonName               *bool `json:"legal_person_name,omitempty"`
+}
+
+// NationalIdentificationRule flags the fields required of the national identification
+type NationalIdentificationRule struct {
+	NationalIdentifier     *bool `json:"national_identifier,omitempty"`
+	NationalIdentifierType *bool `json:"national_identifier_type,omitempty"`
+	CountryOfIssue         *bool `json:"country_of_issue,omitempty"`
+	RegistrationAuthority  *bool `json:"registration_authority,omitempty"`
+}
+
+// AddressRule flags the fields required of every geographic address
+type AddressRule struct {
+	AddressType        *bool `json:"address_type,omitempty"`
+	Department         *bool `json:"department,omitempty"`
+	SubDepartment      *bool `json:"sub_department,omitempty"`
+	StreetName         *bool `json:"street_name,omitempty"`
+	BuildingNumber     *bool `json:"building_number,omitempty"`
+	BuildingName       *bool `json:"building_name,omitempty"`
+	Floor              *bool `json:"floor,omitempty"`
+	PostBox            *bool `json:"post_box,omitempty"`
+	Room               *bool `json:"room,omitempty"`
+	PostCode           *bool `json:"post_code,omitempty"`
+	TownName           *bool `json:"town_name,omitempty"`
+	TownLocationName   *bool `json:"town_location_name,omitempty"`
+	DistrictName       *bool `json:"district_name,omitempty"`
+	CountrySubDivision *bool `json:"country_sub_division,omitempty"`
+	AddressLine        *bool `json:"address_line,omitempty"`
+	Country            *bool `json:"country,omitempty"`
+}
+
+// RuleFlag returns a flag of a BeneficiaryCheckingRule
+func RuleFlag(required bool) *bool {
+	return &required
+}
+
+// ViolationKind tells why a field violates a BeneficiaryCheckingRule
+type ViolationKind int
+
+const (
+	// FieldMissing is a field the rule requires but the payload lacks
+	FieldMissing ViolationKind = iota
+	// FieldUnexpected is a field the payload has but the rule does not declare
+	FieldUnexpected
+)
+
+// RuleViolation is a field of the payload which does not follow a BeneficiaryCheckingRule
+type RuleViolation struct {
+	// Path of the field in the IVMS101 payload
+	Path string
+	Kind ViolationKind
+}
+
+func (v RuleViolation) String() string {
+	if v.Kind == FieldUnexpected {
+		return v.Path + " is not declared by the rule"
+	}
+	return v.Path + " is missing"
+}
+
+// BeneficiaryCheckingRuleFromOrderedMap decodes a rule from its API representation.
+// The signature is ignored and any other unknown field is an error.
+func BeneficiaryCheckingRuleFromOrderedMap(o *orderedmap.OrderedMap) (*BeneficiaryCheckingRule, error) {
+	unsigned := orderedmap.New()
+	for _, k := range o.Keys() {
+		if k != "signature" {
+			v, _ := o.Get(k)
+			unsigned.Set(k, v)
+		}
+	}
+	b, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	rule := &BeneficiaryCheckingRule{}
+	if err := decoder.Decode(rule); err != nil {
+		return nil, fmt.Errorf("invalid beneficiary checking rule: %w", err)
+	}
+	return rule, nil
+}
+
+// ToOrderedMap returns the rule in the shape PostVASPBeneficiaryCheckingRule expects, ready to Sign.
+// Fields keep the order of the structs so the signed json is deterministic.
+func (r *BeneficiaryCheckingRule) ToOrderedMap() *orderedmap.OrderedMap {
+	b, _ := json.Marshal(r)
+	return StringToOrderedMap(string(b))
+}
+
+// Evaluate checks payload against the rule and returns every required field which is missing,
+// then every present field which the rule does not declare, in payload order for each kind.
+// An empty result means the beneficiary VASP should not reject the payload with BVRC006.
+// A nil payload has no person and so no violation.
+func (r *BeneficiaryCheckingRule) Evaluate(payload *ivms101.Payload) []RuleViolation {
+	e := &ruleEvaluator{}
+	if payload == nil {
+		return nil
+	}
+	if payload.Originator != nil {
+		for i, person := range payload.Originator.OriginatorPersons {
+			r.evaluate(e, fmt.Sprintf("originator.originator_persons[%d]", i), person)
+		}
+	}
+	if payload.Beneficiary != nil {
+		for i, person := range payload.Beneficiary.BeneficiaryPersons {
+			r.evaluate(e, fmt.Sprintf("beneficiary.beneficiary_persons[%d]", i), person)
+		}
+	}
+	return append(e.missing, e.unexpected...)
+}
+
+func (r *BeneficiaryCheckingRule) evaluate(e *ruleEvaluator, path string, person ivms101.Person) {
+	switch {
+	case person.NaturalPerson != nil:
+		r.NaturalPerson.evaluate(e, path+".natural_person", person.NaturalPerson)
+	case person.LegalPerson != nil:
+		r.LegalPerson.evaluate(e, path+".legal_person", person.LegalPerson)
+	}
+}
+
+type ruleEvaluator struct {
+	missing, unexpected []RuleViolation
+}
+
+func (e *ruleEvaluator) check(path string, flag *bool, present bool) {
+	switch {
+	case flag != nil && *flag && !present:
+		e.missing = append(e.missing, RuleViolation{Path: path, Kind: FieldMissing})
+	case flag == nil && present:
+		e.unexpected = append(e.unexpected, RuleViolation{Path: path, Kind: FieldUnexpected})
+	}
+}
+
+// evaluate checks a natural person, a nil rule declares none of its fields
+func (r *NaturalPersonRule) evaluate(e *ruleEvaluator, path string, p *ivms101.NaturalPerson) {
+	if r == nil {
+		r = &NaturalPersonRule{}
+	}
+	name := &ivms101.NaturalPersonName{}
+	if p.Name != nil {
+		name = p.Name
+	}
+	r.NameIdentifiers.evaluate(e, path+".name.name_identifiers", name.NameIdentifiers)
+	r.LocalNameIdentifiers.evaluate(e, path+".name.local_name_identifiers", localNames(name.LocalNameIdentifiers))
+	r.PhoneticNameIdentifiers.evaluate(e, path+".name.phonetic_name_identifiers", localNames(name.PhoneticNameIdentifiers))
+	r.GeographicAddress.evaluate(e, path+".geographic_address", p.GeographicAddress)
+	r.NationalIdentification.evaluate(e, path+".national_identification", p.NationalIdentification)
+	e.check(path+".customer_identification", r.CustomerIdentification, p.CustomerIdentification != "")
+	r.DateAndPlaceOfBirth.evaluate(e, path+".date_and_place_of_birth", p.DateAndPlaceOfBirth)
+	e.check(path+".country_of_residence", r.CountryOfResidence, p.CountryOfResidence != "")
+}
+
+// evaluate checks a legal person, a nil rule declares none of its fields
+func (r *LegalPersonRule) evaluate(e *ruleEvaluator, path string, p *ivms101.LegalPerson) {
+	if r == nil {
+		r = &LegalPersonRule{}
+	}
+	name := &ivms101.LegalPersonName{}
+	if p.Name != nil {
+		name = p.Name
+	}
+	r.NameIdentifiers.evaluate(e, path+".name.name_identifiers", name.NameIdentifiers)
+	r.LocalNameIdentifiers.evaluate(e, path+".name.local_name_identifiers", name.LocalNameIdentifiers)
+	r.PhoneticNameIdentifiers.evaluate(e, path+".name.phonetic_name_identifiers", name.PhoneticNameIdentifiers)
+	r.GeographicAddress.evaluate(e, path+".geographic_address", p.GeographicAddress)
+	e.check(path+".customer_number", r.CustomerIdentification, p.CustomerNumber != "")
+	r.NationalIdentification.evaluate(e, path+".national_identification", p.NationalIdentification)
+	e.check(path+".country_of_registration", r.CountryOfRegistration, p.CountryOfRegistration != "")
+}
+
+func (r *DateAndPlaceOfBirthRule) evaluate(e *ruleEvaluator, path string, birth *ivms101.DateAndPlaceOfBirth) {
+	if r == nil {
+		r = &DateAndPlaceOfBirthRule{}
+	}
+	if birth == nil {
+		birth = &ivms101.DateAndPlaceOfBirth{}
+	}
+	e.check(path+".date_of_birth", r.DateOfBirth, birth.DateOfBirth != "")
+	e.check(path+".place_of_birth", r.PlaceOfBirth, birth.PlaceOfBirth != "")
+}
+
+// evaluate checks every name identifier, a rule requiring a field also requires at least one identifier
+func (r *NaturalPersonNameRule) evaluate(e *ruleEvaluator, path string, names []ivms101.NaturalPersonNameIdentifier) {
+	if r == nil {
+		r = &NaturalPersonNameRule{}
+	}
+	if len(names) == 0 {
+		names = []ivms101.NaturalPersonNameIdentifier{{}}
+	}
+	for i, name := range names {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		e.check(p+".primary_identifier", r.PrimaryIdentifier, name.PrimaryIdentifier != "")
+		e.check(p+".secondary_identifier", r.SecondaryIdentifier, name.SecondaryIdentifier != "")
+		e.check(p+".name_identifier_type", r.NameIdentifierType, name.NameIdentifierType != "")
+	}
+}
+
+func localNames(local []ivms101.LocalNaturalPersonNameIdentifier) []ivms101.NaturalPersonNameIdentifier {
+	names := make([]ivms101.NaturalPersonNameIdentifier, len(local))
+	for i, name := range local {
+		names[i] = ivms101.NaturalPersonNameIdentifier(name)
+	}
+	return names
+}
+
+// evaluate checks every name identifier, a rule requiring a field also requires at least one identifier
+func (r *LegalPersonNameRule) evaluate(e *ruleEvaluator, path string, names []ivms101.LegalPersonNameIdentifier) {
+	if r == nil {
+		r = &LegalPersonNameRule{}
+	}
+	if len(names) == 0 {
+		names = []ivms101.LegalPersonNameIdentifier{{}}
+	}
+	for i, name := range names {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		e.check(p+".legal_person_name", r.LegalPersonName, name.LegalPersonName != "")
+		e.check(p+".legal_person_name_identifier_type", r.LegalPersonNameIdentifierType, name.LegalPersonNameIdentifierType != "")
+	}
+}
+
+func (r *NationalIdentificationRule) evaluate(e *ruleEvaluator, path string, id *ivms101.NationalIdentification) {
+	if r == nil {
+		r = &NationalIdentificationRule{}
+	}
+	if id == nil {
+		id = &ivms101.NationalIdentification{}
+	}
+	e.check(path+".national_identifier", r.NationalIdentifier, id.NationalIdentifier != "")
+	e.check(path+".national_identifier_type", r.NationalIdentifierType, id.NationalIdentifierType != "")
+	e.check(path+".country_of_issue", r.CountryOfIssue, id.CountryOfIssue != "")
+	e.check(path+".registration_authority", r.RegistrationAuthority, id.RegistrationAuthority != "")
+}
+
+// evaluate checks every address, a rule requiring a field also requires at least one address
+func (r *AddressRule) evaluate(e *ruleEvaluator, path string, addresses []ivms101.Address) {
+	if r == nil {
+		r = &AddressRule{}
+	}
+	if len(addresses) == 0 {
+		addresses = []ivms101.Address{{}}
+	}
+	for i, a := range addresses {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		e.check(p+".address_type", r.AddressType, a.AddressType != "")
+		e.check(p+".department", r.Department, a.Department != "")
+		e.check(p+".sub_department", r.SubDepartment, a.SubDepartment != "")
+		e.check(p+".street_name", r.StreetName, a.StreetName != "")
+		e.check(p+".building_number", r.BuildingNumber, a.BuildingNumber != "")
+		e.check(p+".building_name", r.BuildingName, a.BuildingName != "")
+		e.check(p+".floor", r.Floor, a.Floor != "")
+		e.check(p+".post_box", r.PostBox, a.PostBox != "")
+		e.check(p+".room", r.Room, a.Room != "")
+		e.check(p+".post_code", r.PostCode, a.PostCode != "")
+		e.check(p+".town_name", r.TownName, a.TownName != "")
+		e.check(p+".town_location_name", r.TownLocationName, a.TownLocationName != "")
+		e.check(p+".district_name", r.DistrictName, a.DistrictName != "")
+		e.check(p+".country_sub_division", r.CountrySubDivision, a.CountrySubDivision != "")
+		e.check(p+".address_line", r.AddressLine, len(a.AddressLine) > 0)
+		e.check(p+".country", r.Country, a.Country != "")
+	}
+}
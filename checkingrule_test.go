@@ -0,0 +1,146 @@
+package bridgeutil
+
+import (
+	"testing"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/ivms101"
+	"github.com/stretchr/testify/assert"
+)
+
+// exampleCheckingRule is the checking rule posted by example/example.go
+const exampleCheckingRule = `{"natural_person":{"country_of_residence":true,"customer_identification":false,"date_and_place_of_birth":{"date_of_birth":true,"place_of_birth":true}},"legal_person":{"country_of_registration":false,"customer_identification":true,"name_identifiers":{"legal_person_name_identifier_type":true,"legal_person_name":true}}}`
+
+func TestBeneficiaryCheckingRuleEvaluate(t *testing.T) {
+	rule := &BeneficiaryCheckingRule{
+		NaturalPerson: &NaturalPersonRule{
+			CustomerIdentification: RuleFlag(false),
+			DateAndPlaceOfBirth:    &DateAndPlaceOfBirthRule{DateOfBirth: RuleFlag(true)},
+			NameIdentifiers:        &NaturalPersonNameRule{PrimaryIdentifier: RuleFlag(true), SecondaryIdentifier: RuleFlag(true), NameIdentifierType: RuleFlag(false)},
+		},
+		LegalPerson: &LegalPersonRule{
+			CustomerIdentification: RuleFlag(true),
+			NameIdentifiers:        &LegalPersonNameRule{LegalPersonName: RuleFlag(true), LegalPersonNameIdentifierType: RuleFlag(false)},
+		},
+	}
+
+	payload := &ivms101.Payload{
+		Originator: &ivms101.Originator{
+			OriginatorPersons: []ivms101.Person{{NaturalPerson: &ivms101.NaturalPerson{
+				Name: &ivms101.NaturalPersonName{NameIdentifiers: []ivms101.NaturalPersonNameIdentifier{
+					{PrimaryIdentifier: "Wu", SecondaryIdentifier: "Xinli", NameIdentifierType: ivms101.NaturalPersonNameTypeLegal},
+					{PrimaryIdentifier: "Wu", NameIdentifierType: ivms101.NaturalPersonNameTypeAlias},
+				}},
+			}}},
+		},
+		Beneficiary: &ivms101.Beneficiary{
+			BeneficiaryPersons: []ivms101.Person{{LegalPerson: &ivms101.LegalPerson{
+				Name: &ivms101.LegalPersonName{NameIdentifiers: []ivms101.LegalPersonNameIdentifier{
+					{LegalPersonName: "ABC Limited", LegalPersonNameIdentifierType: ivms101.LegalPersonNameTypeLegal},
+				}},
+				CustomerNumber: "1002390",
+			}}},
+		},
+	}
+	var messages []string
+	for _, v := range rule.Evaluate(payload) {
+		messages = append(messages, v.String())
+	}
+	assert.Equal(t, []string{
+		"originator.originator_persons[0].natural_person.name.name_identifiers[1].secondary_identifier is missing",
+		"originator.originator_persons[0].natural_person.date_and_place_of_birth.date_of_birth is missing",
+	}, messages)
+
+	payload.Beneficiary.BeneficiaryPersons[0].LegalPerson = &ivms101.LegalPerson{}
+	messages = nil
+	for _, v := range rule.Evaluate(payload) {
+		messages = append(messages, v.String())
+	}
+	assert.Equal(t, []string{
+		"originator.originator_persons[0].natural_person.name.name_identifiers[1].secondary_identifier is missing",
+		"originator.originator_persons[0].natural_person.date_and_place_of_birth.date_of_birth is missing",
+		"beneficiary.beneficiary_persons[0].legal_person.name.name_identifiers[0].legal_person_name is missing",
+		"beneficiary.beneficiary_persons[0].legal_person.customer_number is missing",
+	}, messages)
+}
+
+func TestBeneficiaryCheckingRuleEvaluateUnexpected(t *testing.T) {
+	rule := &BeneficiaryCheckingRule{NaturalPerson: &NaturalPersonRule{
+		CountryOfResidence: RuleFlag(false),
+		NameIdentifiers:    &NaturalPersonNameRule{PrimaryIdentifier: RuleFlag(true), NameIdentifierType: RuleFlag(true)},
+	}}
+	payload := &ivms101.Payload{
+		Originator: &ivms101.Originator{OriginatorPersons: []ivms101.Person{{NaturalPerson: &ivms101.NaturalPerson{
+			Name: &ivms101.NaturalPersonName{NameIdentifiers: []ivms101.NaturalPersonNameIdentifier{
+				{PrimaryIdentifier: "Wu", SecondaryIdentifier: "Xinli", NameIdentifierType: ivms101.NaturalPersonNameTypeLegal},
+			}},
+			CountryOfResidence:  "TW",
+			DateAndPlaceOfBirth: &ivms101.DateAndPlaceOfBirth{DateOfBirth: "1990-01-01"},
+		}}}},
+		Beneficiary: &ivms101.Beneficiary{BeneficiaryPersons: []ivms101.Person{{LegalPerson: &ivms101.LegalPerson{
+			CountryOfRegistration: "SG",
+		}}}},
+	}
+	assert.Equal(t, []RuleViolation{
+		{Path: "originator.originator_persons[0].natural_person.name.name_identifiers[0].secondary_identifier", Kind: FieldUnexpected},
+		{Path: "originator.originator_persons[0].natural_person.date_and_place_of_birth.date_of_birth", Kind: FieldUnexpected},
+		{Path: "beneficiary.beneficiary_persons[0].legal_person.country_of_registration", Kind: FieldUnexpected},
+	}, rule.Evaluate(payload))
+	assert.Equal(t, "beneficiary.beneficiary_persons[0].legal_person.country_of_registration is not declared by the rule", rule.Evaluate(payload)[2].String())
+
+	payload.Originator.OriginatorPersons[0].NaturalPerson.Name = nil
+	violations := rule.Evaluate(payload)
+	assert.Equal(t, RuleViolation{Path: "originator.originator_persons[0].natural_person.name.name_identifiers[0].primary_identifier"}, violations[0], "missing fields come first")
+	assert.Len(t, violations, 4)
+
+	assert.Nil(t, rule.Evaluate(nil))
+	assert.Nil(t, rule.Evaluate(&ivms101.Payload{}))
+}
+
+func TestBeneficiaryCheckingRuleRoundTrip(t *testing.T) {
+	message := StringToOrderedMap(exampleCheckingRule)
+	message.Set("signature", "3045022100")
+	rule, err := BeneficiaryCheckingRuleFromOrderedMap(message)
+	assert.Nil(t, err)
+	assert.Equal(t, &BeneficiaryCheckingRule{
+		NaturalPerson: &NaturalPersonRule{
+			CountryOfResidence:     RuleFlag(true),
+			CustomerIdentification: RuleFlag(false),
+			DateAndPlaceOfBirth:    &DateAndPlaceOfBirthRule{DateOfBirth: RuleFlag(true), PlaceOfBirth: RuleFlag(true)},
+		},
+		LegalPerson: &LegalPersonRule{
+			CountryOfRegistration:  RuleFlag(false),
+			CustomerIdentification: RuleFlag(true),
+			NameIdentifiers:        &LegalPersonNameRule{LegalPersonNameIdentifierType: RuleFlag(true), LegalPersonName: RuleFlag(true)},
+		},
+	}, rule)
+
+	encoded, err := OrderedMapToString(rule.ToOrderedMap())
+	assert.Nil(t, err)
+	assert.Equal(t, exampleCheckingRule, encoded)
+
+	violations := rule.Evaluate(&ivms101.Payload{Originator: &ivms101.Originator{
+		OriginatorPersons: []ivms101.Person{{NaturalPerson: &ivms101.NaturalPerson{CountryOfResidence: "TW"}}},
+	}})
+	assert.Equal(t, []RuleViolation{
+		{Path: "originator.originator_persons[0].natural_person.date_and_place_of_birth.date_of_birth"},
+		{Path: "originator.originator_persons[0].natural_person.date_and_place_of_birth.place_of_birth"},
+	}, violations)
+}
+
+func TestBeneficiaryCheckingRuleFromOrderedMapUnknownField(t *testing.T) {
+	var tests = []struct {
+		name    string
+		message string
+	}{
+		{"top level", `{"vasp_code":"VASPUSNY2","natural_person":{"country_of_residence":true}}`},
+		{"person", `{"natural_person":{"name":"REQUIRED"}}`},
+		{"nested", `{"legal_person":{"name_identifiers":{"name":true}}}`},
+		{"not a flag", `{"natural_person":{"country_of_residence":"REQUIRED"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BeneficiaryCheckingRuleFromOrderedMap(StringToOrderedMap(tt.message))
+			assert.NotNil(t, err)
+		})
+	}
+}
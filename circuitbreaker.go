@@ -0,0 +1,322 @@
+package bridgeutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrBridgeUnavailable is matched by the errors of calls rejected by an open CircuitBreaker,
+// check it with errors.Is to defer work until Sygna Bridge is back
+var ErrBridgeUnavailable = errors.New("sygna bridge is unavailable")
+
+// BridgeUnavailableError is returned instead of calling Sygna Bridge while the circuit breaker is open
+type BridgeUnavailableError struct {
+	// RetryAfter is how long until the breaker lets a probe call through, 0 while probes are in flight
+	RetryAfter time.Duration
+}
+
+func (e *BridgeUnavailableError) Error() string {
+	return fmt.Sprintf("%v: circuit breaker is open, retry after %v", ErrBridgeUnavailable, e.RetryAfter)
+}
+
+// Is matches ErrBridgeUnavailable
+func (e *BridgeUnavailableError) Is(target error) bool {
+	return target == ErrBridgeUnavailable
+}
+
+// BreakerState is the state of a CircuitBreaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects every call with ErrBridgeUnavailable
+	BreakerOpen
+	// BreakerHalfOpen lets a few probe calls through to decide whether to close or open again
+	BreakerHalfOpen
+)
+
+// String returns the name of the state
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+const breakerBuckets = 10
+
+// breakerTransition is a state change waiting for OnStateChange
+type breakerTransition struct {
+	from, to BreakerState
+}
+
+// breakerBucket counts the calls of a tenth of the window
+type breakerBucket struct {
+	calls, failures int
+}
+
+// CircuitBreakerConfig configures NewCircuitBreaker, zero fields take their default
+type CircuitBreakerConfig struct {
+	// Window is the period over which the error rate is measured, 1 minute by default
+	Window time.Duration
+	// MinRequests is the number of calls in the window before the breaker may open, 10 by default
+	MinRequests int
+	// ErrorRate is the fraction of failed calls in the window which opens the breaker, 0.5 by default
+	ErrorRate float64
+	// OpenTimeout is how long the breaker stays open before probing, 30 seconds by default
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of probe calls which must succeed to close the breaker, 1 by default
+	HalfOpenRequests int
+	// IsFailure reports whether a call counts as a failure, by default when no response was
+	// received or the status is 5xx. Calls whose context is done never count.
+	IsFailure func(statusCode int, err error) bool
+	// OnStateChange is called on every state change, e.g. to switch to a deferred queue, including
+	// open to half-open as soon as OpenTimeout elapses. Changes are delivered one at a time and in
+	// order, synchronously after the breaker is unlocked: by the goroutine whose call changed the
+	// state, or by the one already delivering an earlier change. A slow hook delays that call.
+	OnStateChange func(from, to BreakerState)
+}
+
+// CircuitBreaker stops calling Sygna Bridge when too many calls fail, see WithCircuitBreaker.
+// It is safe for concurrent use.
+type CircuitBreaker struct {
+	cfg CircuitBreakerConfig
+
+	mu       sync.Mutex
+	state    BreakerState
+	openedAt time.Time
+	// buckets count the calls made while closed, bucket i covering
+	// [bucketStart+i*Window/breakerBuckets, bucketStart+(i+1)*Window/breakerBuckets)
+	buckets     [breakerBuckets]breakerBucket
+	bucketStart time.Time
+	// probes and probeSuccesses count the calls let through while half-open
+	probes         int
+	probeSuccesses int
+	// transitions wait for notify, notifying is set while a goroutine delivers them
+	transitions []breakerTransition
+	notifying   bool
+}
+
+// NewCircuitBreaker returns a closed circuit breaker
+func NewCircuitBreaker(cfg CircuitBreakerConfig) (*CircuitBreaker, error) {
+	if cfg.Window < 0 || cfg.MinRequests < 0 || cfg.ErrorRate < 0 || cfg.ErrorRate > 1 || cfg.OpenTimeout < 0 || cfg.HalfOpenRequests < 0 {
+		return nil, fmt.Errorf("invalid circuit breaker config %+v", cfg)
+	}
+	if cfg.Window == 0 {
+		cfg.Window = time.Minute
+	}
+	if cfg.MinRequests == 0 {
+		cfg.MinRequests = 10
+	}
+	if cfg.ErrorRate == 0 {
+		cfg.ErrorRate = 0.5
+	}
+	if cfg.OpenTimeout == 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenRequests == 0 {
+		cfg.HalfOpenRequests = 1
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = isBridgeFailure
+	}
+	return &CircuitBreaker{cfg: cfg, bucketStart: time.Now()}, nil
+}
+
+func isBridgeFailure(statusCode int, err error) bool {
+	if statusCode == 0 {
+		return err != nil
+	}
+	return statusCode >= http.StatusInternalServerError
+}
+
+// State returns the current state; an open breaker whose OpenTimeout elapsed turns half-open
+func (b *CircuitBreaker) State() BreakerState {
+	defer b.notify()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cfg.OpenTimeout {
+		b.setState(BreakerHalfOpen, time.Now())
+	}
+	return b.state
+}
+
+// allow reports whether a call may be made, and whether it is a probe
+func (b *CircuitBreaker) allow(now time.Time) (probe bool, err error) {
+	defer b.notify()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerClosed:
+		return false, nil
+	case BreakerOpen:
+		if wait := b.cfg.OpenTimeout - now.Sub(b.openedAt); wait > 0 {
+			return false, &BridgeUnavailableError{RetryAfter: wait}
+		}
+		b.setState(BreakerHalfOpen, now)
+	}
+	if b.probes >= b.cfg.HalfOpenRequests {
+		return false, &BridgeUnavailableError{}
+	}
+	b.probes++
+	return true, nil
+}
+
+// done records the outcome of an allowed call; ignored calls neither succeeded nor failed
+func (b *CircuitBreaker) done(now time.Time, probe, failed, ignored bool) {
+	defer b.notify()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		if b.state != BreakerHalfOpen {
+			return
+		}
+		switch {
+		case ignored:
+			b.probes--
+		case failed:
+			b.setState(BreakerOpen, now)
+		default:
+			b.probeSuccesses++
+			if b.probeSuccesses >= b.cfg.HalfOpenRequests {
+				b.setState(BreakerClosed, now)
+			}
+		}
+		return
+	}
+	if b.state != BreakerClosed || ignored {
+		return
+	}
+
+	bucket := b.bucket(now)
+	bucket.calls++
+	if failed {
+		bucket.failures++
+	}
+	var calls, failures int
+	for _, bucket := range b.buckets {
+		calls += bucket.calls
+		failures += bucket.failures
+	}
+	if calls >= b.cfg.MinRequests && float64(failures) >= b.cfg.ErrorRate*float64(calls) {
+		b.setState(BreakerOpen, now)
+	}
+}
+
+// bucket returns the bucket of now, clearing the buckets which left the window
+func (b *CircuitBreaker) bucket(now time.Time) *breakerBucket {
+	width := max(b.cfg.Window/breakerBuckets, 1)
+	shift := int(now.Sub(b.bucketStart) / width)
+	if shift >= breakerBuckets {
+		shift -= breakerBuckets - 1
+		if shift >= breakerBuckets {
+			b.buckets = [breakerBuckets]breakerBucket{}
+		} else {
+			copy(b.buckets[:], b.buckets[shift:])
+			for i := breakerBuckets - shift; i < breakerBuckets; i++ {
+				b.buckets[i] = breakerBucket{}
+			}
+		}
+		b.bucketStart = b.bucketStart.Add(time.Duration(shift) * width)
+		return b.bucket(now)
+	}
+	return &b.buckets[shift]
+}
+
+// setState changes the state and resets the counters, b.mu must be held and notify called once it
+// is released. Opening the breaker starts a timer turning it half-open after OpenTimeout.
+func (b *CircuitBreaker) setState(state BreakerState, now time.Time) {
+	from := b.state
+	b.state = state
+	b.probes, b.probeSuccesses = 0, 0
+	b.buckets = [breakerBuckets]breakerBucket{}
+	b.bucketStart = now
+	if state == BreakerOpen {
+		b.openedAt = now
+		time.AfterFunc(b.cfg.OpenTimeout, func() {
+			defer b.notify()
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			// a later change already left this open period
+			if b.state == BreakerOpen && b.openedAt.Equal(now) {
+				b.setState(BreakerHalfOpen, time.Now())
+			}
+		})
+	}
+	if b.cfg.OnStateChange != nil && from != state {
+		b.transitions = append(b.transitions, breakerTransition{from, state})
+	}
+}
+
+// notify delivers the pending transitions to OnStateChange in order, b.mu must not be held.
+// It returns at once when another goroutine is delivering, which then delivers them too.
+func (b *CircuitBreaker) notify() {
+	if b.cfg.OnStateChange == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.notifying {
+		return
+	}
+	b.notifying = true
+	defer func() { b.notifying = false }()
+	for len(b.transitions) > 0 {
+		t := b.transitions[0]
+		b.transitions = b.transitions[1:]
+		func() {
+			b.mu.Unlock()
+			defer b.mu.Lock()
+			b.cfg.OnStateChange(t.from, t.to)
+		}()
+	}
+}
+
+// Middleware fails calls fast with ErrBridgeUnavailable while the breaker is open
+func (b *CircuitBreaker) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ex *Exchange) (interface{}, error) {
+			probe, err := b.allow(time.Now())
+			if err != nil {
+				return nil, err
+			}
+			response, err := next(ex)
+			ignored := ex.Context.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded))
+			b.done(time.Now(), probe, err != nil && b.cfg.IsFailure(ex.StatusCode, err), ignored)
+			return response, err
+		}
+	}
+}
+
+/*
+WithCircuitBreaker stops calling Sygna Bridge while it is failing. Keep the breaker to read its State.
+It sits inside the middlewares of WithMiddleware and outside the rate limits, so rejected calls
+never wait for a token.
+
+	breaker, err := bridgeutil.NewCircuitBreaker(bridgeutil.CircuitBreakerConfig{ErrorRate: 0.5, OpenTimeout: time.Minute})
+	api, err := bridgeutil.NewBridgeAPI(bridgeutil.WithCircuitBreaker(breaker))
+	...
+	if errors.Is(err, bridgeutil.ErrBridgeUnavailable) {
+		deferredQueue.Push(withdrawal)
+	}
+*/
+func WithCircuitBreaker(b *CircuitBreaker) Option {
+	return func(o *options) error {
+		if b == nil {
+			return errors.New("circuit breaker is nil")
+		}
+		o.circuitBreaker = b
+		return nil
+	}
+}
@@ -0,0 +1,182 @@
+package bridgeutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stateChanges records the calls of OnStateChange
+type stateChanges struct {
+	mu      sync.Mutex
+	changes [][2]BreakerState
+}
+
+func (c *stateChanges) record(from, to BreakerState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changes = append(c.changes, [2]BreakerState{from, to})
+}
+
+func (c *stateChanges) get() [][2]BreakerState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][2]BreakerState(nil), c.changes...)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls int32
+	var healthy atomic.Bool
+	changes := &stateChanges{}
+	breaker, err := NewCircuitBreaker(CircuitBreakerConfig{
+		MinRequests:   4,
+		ErrorRate:     0.5,
+		OpenTimeout:   50 * time.Millisecond,
+		OnStateChange: changes.record,
+	})
+	assert.Nil(t, err)
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"unavailable"}`))
+			return
+		}
+		okHandler(w, r)
+	}, WithCircuitBreaker(breaker))
+
+	for i := 0; i < 4; i++ {
+		_, err := api.GetCurrencies(nil)
+		assert.NotNil(t, err)
+		assert.False(t, errors.Is(err, ErrBridgeUnavailable))
+	}
+	assert.Equal(t, BreakerOpen, breaker.State())
+
+	_, err = api.GetCurrencies(nil)
+	assert.ErrorIs(t, err, ErrBridgeUnavailable)
+	var unavailable *BridgeUnavailableError
+	assert.True(t, errors.As(err, &unavailable))
+	assert.Greater(t, unavailable.RetryAfter, time.Duration(0))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls), "open breaker fails fast")
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, [][2]BreakerState{
+		{BreakerClosed, BreakerOpen},
+		{BreakerOpen, BreakerHalfOpen},
+	}, changes.get(), "half-open is notified without waiting for a call")
+	assert.Equal(t, BreakerHalfOpen, breaker.State())
+	_, err = api.GetCurrencies(nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, BreakerOpen, breaker.State(), "a failed probe opens the breaker again")
+
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+	_, err = api.GetCurrencies(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, BreakerClosed, breaker.State())
+
+	assert.Equal(t, [][2]BreakerState{
+		{BreakerClosed, BreakerOpen},
+		{BreakerOpen, BreakerHalfOpen},
+		{BreakerHalfOpen, BreakerOpen},
+		{BreakerOpen, BreakerHalfOpen},
+		{BreakerHalfOpen, BreakerClosed},
+	}, changes.get())
+}
+
+func TestCircuitBreakerStateChangesInOrder(t *testing.T) {
+	changes := &stateChanges{}
+	entered, release := make(chan struct{}), make(chan struct{})
+	breaker, err := NewCircuitBreaker(CircuitBreakerConfig{
+		MinRequests: 1,
+		OpenTimeout: 10 * time.Millisecond,
+		OnStateChange: func(from, to BreakerState) {
+			if to == BreakerOpen {
+				close(entered)
+				<-release
+			}
+			changes.record(from, to)
+		},
+	})
+	assert.Nil(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		breaker.done(time.Now(), false, true, false)
+	}()
+	<-entered
+
+	// the breaker turns half-open then closed while closed to open is still being delivered
+	time.Sleep(20 * time.Millisecond)
+	probe, err := breaker.allow(time.Now())
+	assert.Nil(t, err)
+	assert.True(t, probe)
+	breaker.done(time.Now(), probe, false, false)
+	assert.Equal(t, BreakerClosed, breaker.State())
+	assert.Empty(t, changes.get(), "later changes wait for the earlier one")
+
+	close(release)
+	<-done
+	assert.Equal(t, [][2]BreakerState{
+		{BreakerClosed, BreakerOpen},
+		{BreakerOpen, BreakerHalfOpen},
+		{BreakerHalfOpen, BreakerClosed},
+	}, changes.get())
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	breaker, err := NewCircuitBreaker(CircuitBreakerConfig{MinRequests: 2})
+	assert.Nil(t, err)
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid transfer id"}`))
+	}, WithCircuitBreaker(breaker))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 3; i++ {
+		_, err := api.GetStatus("b97903fd")
+		assert.NotNil(t, err)
+		_, err = api.WithContext(ctx).GetStatus("b97903fd")
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+	assert.Equal(t, BreakerClosed, breaker.State())
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	breaker, err := NewCircuitBreaker(CircuitBreakerConfig{Window: 10 * time.Second, MinRequests: 4})
+	assert.Nil(t, err)
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		breaker.done(start, false, true, false)
+	}
+	assert.Equal(t, BreakerClosed, breaker.State())
+
+	// the failures left the window, 1 failure out of 4 calls keeps the breaker closed
+	later := start.Add(11 * time.Second)
+	breaker.done(later, false, true, false)
+	for i := 0; i < 3; i++ {
+		breaker.done(later, false, false, false)
+	}
+	assert.Equal(t, BreakerClosed, breaker.State())
+
+	breaker.done(later.Add(time.Second), false, true, false)
+	breaker.done(later.Add(time.Second), false, true, false)
+	assert.Equal(t, BreakerOpen, breaker.State())
+}
+
+func TestInvalidCircuitBreaker(t *testing.T) {
+	_, err := NewCircuitBreaker(CircuitBreakerConfig{ErrorRate: 2})
+	assert.NotNil(t, err)
+	_, err = NewBridgeAPI(WithCircuitBreaker(nil))
+	assert.NotNil(t, err)
+	assert.Equal(t, "half-open", BreakerHalfOpen.String())
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	bridgeutil "github.com/CoolBitX-Technology/sygna-bridge-util-go"
+	"github.com/iancoleman/orderedmap"
+)
+
+const (
+	defaultDomainEnv = "SYGNA_API_DOMAIN"
+	defaultAPIKeyEnv = "SYGNA_API_KEY"
+	// defaultEnvironmentEnv selects the Sygna Bridge environment, as in LoadConfig
+	defaultEnvironmentEnv = "SYGNA_ENVIRONMENT"
+)
+
+// envUsage is the usage of the -env flags
+const envUsage = "Sygna Bridge environment: production, test, dev or sandbox, $" + defaultEnvironmentEnv + ", else production for the production domain and test otherwise"
+
+// apiFlags select the Sygna Bridge environment, domain and API key
+type apiFlags struct {
+	env       string
+	domain    string
+	apiKeyEnv string
+	validate  bool
+}
+
+func (f *apiFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.env, "env", "", envUsage)
+	fs.StringVar(&f.domain, "domain", "", "API domain, $"+defaultDomainEnv+" or the domain of the environment by default")
+	fs.StringVar(&f.apiKeyEnv, "api-key-env", defaultAPIKeyEnv, "environment variable holding the API key")
+}
+
+// registerValidate adds the flags of endpoints whose responses are signed by Sygna Bridge
+func (f *apiFlags) registerValidate(fs *flag.FlagSet) {
+	fs.BoolVar(&f.validate, "validate", true, "verify the signature of the response with the Sygna Bridge key of the environment")
+}
+
+// environment returns the configuration of the environment env, or else of $SYGNA_ENVIRONMENT.
+// Without either, the production domain selects production and any other domain test.
+func environment(a *app, env, domain string) (*bridgeutil.Config, error) {
+	cfg := &bridgeutil.Config{Environment: env, APIDomain: domain}
+	if cfg.Environment == "" {
+		cfg.Environment = a.getenv(defaultEnvironmentEnv)
+	}
+	if cfg.Environment == "" {
+		cfg.Environment = bridgeutil.EnvironmentTest
+		if strings.TrimSuffix(domain, "/") == strings.TrimSuffix(bridgeutil.SygnaBridgeAPIDomain, "/") {
+			cfg.Environment = bridgeutil.EnvironmentProduction
+		}
+	}
+	if cfg.BridgePublicKey() == "" {
+		return nil, fmt.Errorf("unknown environment %q", cfg.Environment)
+	}
+	return cfg, nil
+}
+
+func (f *apiFlags) api(a *app) (*bridgeutil.BridgeAPI, error) {
+	domain := f.domain
+	if domain == "" {
+		domain = a.getenv(defaultDomainEnv)
+	}
+	cfg, err := environment(a, f.env, domain)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.APIDomain == "" {
+		cfg.APIDomain = bridgeutil.SygnaBridgeAPITestDomain
+		if cfg.Environment == bridgeutil.EnvironmentProduction {
+			cfg.APIDomain = bridgeutil.SygnaBridgeAPIDomain
+		}
+	}
+	cfg.APIKey = a.getenv(f.apiKeyEnv)
+	cfg.UserAgent = "util-go-cli"
+	return cfg.NewBridgeAPI()
+}
+
+func apiCommands() []command {
+	return []command{
+		{"vasp list", "list the registered VASPs", runVASPList},
+		{"vasp detail", "show the details of a VASP", runVASPDetail},
+		{"vasp pubkey", "print the public key of a VASP", runVASPPubkey},
+		{"vasp usages", "list the usages of your VASP", runVASPUsages},
+		{"status", "show the status of a transfer", runStatus},
+		{"currencies", "list the supported currencies", runCurrencies},
+		postCommand("beneficiary-endpoint-url", "update your beneficiary endpoint url", nil, (*bridgeutil.BridgeAPI).PostBeneficiaryEndpointURL),
+		postCommand("permission-request", "send a permission request as the originator", []string{"data", "callback"}, (*bridgeutil.BridgeAPI).PostPermissionRequest),
+		postCommand("permission", "accept or reject a permission request as the beneficiary", nil, (*bridgeutil.BridgeAPI).PostPermission),
+		postCommand("txid", "send the txid of an accepted transfer", nil, (*bridgeutil.BridgeAPI).PostTransactionID),
+		postCommand("retry", "ask Sygna Bridge to resend requests to your VASP", nil, (*bridgeutil.BridgeAPI).PostRetry),
+		postCommand("cdd-request", "request customer due diligence from the originator", nil, (*bridgeutil.BridgeAPI).PostTransactionCDDRequest),
+		postCommand("cdd", "send customer due diligence as the originator", nil, (*bridgeutil.BridgeAPI).PostTransactionCDD),
+		postCommand("server-status", "declare your server in maintenance", nil, (*bridgeutil.BridgeAPI).PostServerStatus),
+		postCommand("checking-rule", "declare your beneficiary checking rule", nil, (*bridgeutil.BridgeAPI).PostVASPBeneficiaryCheckingRule),
+		postCommand("cancel", "cancel a transfer", nil, (*bridgeutil.BridgeAPI).PostTransactionCancel),
+		postCommand("address-validation", "validate addresses with a beneficiary VASP", nil, (*bridgeutil.BridgeAPI).PostAddressValidation),
+		{"wallet-address-filter", "look up the VASPs of addresses", runWalletAddressFilter},
+	}
+}
+
+// postCommand returns a command which sends a JSON body to a POST endpoint.
+// With -sign, signFields (or the body when nil) are signed before sending.
+func postCommand(name, usage string, signFields []string, post func(*bridgeutil.BridgeAPI, *orderedmap.OrderedMap) (*orderedmap.OrderedMap, error)) command {
+	return command{name, usage, func(a *app, args []string) error {
+		fs := a.flagSet(name)
+		var flags apiFlags
+		var keys keyFlags
+		flags.register(fs)
+		keys.register(fs)
+		sign := fs.Bool("sign", false, "sign the body with the private key before sending")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+		body, err := a.readJSONObject(fs.Args())
+		if err != nil {
+			return err
+		}
+		if *sign {
+			privateKey, err := keys.privateKey(a)
+			if err != nil {
+				return err
+			}
+			if err := signMessage(body, privateKey, signFields); err != nil {
+				return err
+			}
+		}
+		api, err := flags.api(a)
+		if err != nil {
+			return err
+		}
+		response, err := post(api, body)
+		if err != nil {
+			return err
+		}
+		return a.printJSON(response)
+	}}
+}
+
+func runVASPList(a *app, args []string) error {
+	fs := a.flagSet("vasp list")
+	var flags apiFlags
+	flags.register(fs)
+	flags.registerValidate(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	api, err := flags.api(a)
+	if err != nil {
+		return err
+	}
+	response, err := api.GetVASP(flags.validate)
+	if err != nil {
+		return err
+	}
+	return a.printJSON(response)
+}
+
+func runVASPDetail(a *app, args []string) error {
+	fs := a.flagSet("vasp detail")
+	var flags apiFlags
+	flags.register(fs)
+	flags.registerValidate(fs)
+	code := fs.String("code", "", "VASP code (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *code == "" {
+		return errors.New("-code is required")
+	}
+
+	api, err := flags.api(a)
+	if err != nil {
+		return err
+	}
+	response, err := api.GetVASPDetails(*code, flags.validate)
+	if err != nil {
+		return err
+	}
+	return a.printJSON(response)
+}
+
+func runVASPPubkey(a *app, args []string) error {
+	fs := a.flagSet("vasp pubkey")
+	var flags apiFlags
+	flags.register(fs)
+	flags.registerValidate(fs)
+	code := fs.String("code", "", "VASP code (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *code == "" {
+		return errors.New("-code is required")
+	}
+
+	api, err := flags.api(a)
+	if err != nil {
+		return err
+	}
+	publicKey, err := api.GetVASPPublicKey(*code, flags.validate)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(a.stdout, publicKey)
+	return err
+}
+
+func runVASPUsages(a *app, args []string) error {
+	fs := a.flagSet("vasp usages")
+	var flags apiFlags
+	flags.register(fs)
+	flags.registerValidate(fs)
+	start := fs.Int64("start", 0, "start of the period in unix seconds (required)")
+	end := fs.Int64("end", 0, "end of the period in unix seconds (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *start == 0 || *end == 0 {
+		return errors.New("-start and -end are required")
+	}
+
+	api, err := flags.api(a)
+	if err != nil {
+		return err
+	}
+	response, err := api.GetVASPUsages(*start, *end, flags.validate)
+	if err != nil {
+		return err
+	}
+	return a.printJSON(response)
+}
+
+func runStatus(a *app, args []string) error {
+	fs := a.flagSet("status")
+	var flags apiFlags
+	flags.register(fs)
+	transferID := fs.String("transfer-id", "", "transfer id (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *transferID == "" {
+		return errors.New("-transfer-id is required")
+	}
+
+	api, err := flags.api(a)
+	if err != nil {
+		return err
+	}
+	response, err := api.GetStatus(*transferID)
+	if err != nil {
+		return err
+	}
+	return a.printJSON(response)
+}
+
+func runCurrencies(a *app, args []string) error {
+	fs := a.flagSet("currencies")
+	var flags apiFlags
+	flags.register(fs)
+	currencyID := fs.String("currency-id", "", "filter by currency id")
+	currencyName := fs.String("currency-name", "", "filter by currency name")
+	currencySymbol := fs.String("currency-symbol", "", "filter by currency symbol")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	query := orderedmap.New()
+	for k, v := range map[string]string{"currency_id": *currencyID, "currency_name": *currencyName, "currency_symbol": *currencySymbol} {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+	api, err := flags.api(a)
+	if err != nil {
+		return err
+	}
+	response, err := api.GetCurrencies(query)
+	if err != nil {
+		return err
+	}
+	return a.printJSON(response)
+}
+
+func runWalletAddressFilter(a *app, args []string) error {
+	fs := a.flagSet("wallet-address-filter")
+	var flags apiFlags
+	flags.register(fs)
+	ignoreKYT := fs.Bool("ignore-kyt", false, "skip blockchain analytics for addresses which do not belong to a Sygna VASP")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	body, err := a.readJSONObject(fs.Args())
+	if err != nil {
+		return err
+	}
+	api, err := flags.api(a)
+	if err != nil {
+		return err
+	}
+	response, err := api.PostWalletAddressFilter(body, *ignoreKYT)
+	if err != nil {
+		return err
+	}
+	return a.printJSON(response)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	bridgeutil "github.com/CoolBitX-Technology/sygna-bridge-util-go"
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/crypto"
+	"github.com/iancoleman/orderedmap"
+)
+
+var versions = map[string]crypto.Version{
+	crypto.VersionLegacy.String(): crypto.VersionLegacy,
+	crypto.VersionAESGCM.String(): crypto.VersionAESGCM,
+	crypto.VersionStream.String(): crypto.VersionStream,
+}
+
+func cryptoCommands() []command {
+	return []command{
+		{"keygen", "generate a key pair, optionally into a keystore file", runKeygen},
+		{"pubkey", "print the public key of the private key", runPubkey},
+		{"encrypt", "encrypt private info for a public key", runEncrypt},
+		{"decrypt", "decrypt private info with the private key", runDecrypt},
+		{"sign", "sign a JSON message with the private key", runSign},
+		{"verify", "verify the signature of a JSON message", runVerify},
+	}
+}
+
+func runKeygen(a *app, args []string) error {
+	fs := a.flagSet("keygen")
+	path := fs.String("keystore", "", "write the private key to this new keystore file instead of stdout")
+	passwordEnv := fs.String("password-env", defaultPasswordEnv, "environment variable holding the keystore password")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	privateKey, publicKey, err := bridgeutil.GenerateKeyPair()
+	if err != nil {
+		return err
+	}
+	result := orderedmap.New()
+	if *path == "" {
+		result.Set("private_key", privateKey)
+	} else {
+		if err := a.writeKeystore(*path, *passwordEnv, privateKey); err != nil {
+			return err
+		}
+		result.Set("keystore", *path)
+	}
+	result.Set("public_key", publicKey)
+	return a.printJSON(result)
+}
+
+func runPubkey(a *app, args []string) error {
+	fs := a.flagSet("pubkey")
+	var keys keyFlags
+	keys.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	privateKey, err := keys.privateKey(a)
+	if err != nil {
+		return err
+	}
+	publicKey, err := bridgeutil.PublicKeyFromPrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(a.stdout, publicKey)
+	return err
+}
+
+func runEncrypt(a *app, args []string) error {
+	fs := a.flagSet("encrypt")
+	publicKey := fs.String("public-key", "", "hex public key of the recipient (required)")
+	version := fs.String("version", crypto.VersionLegacy.String(), "envelope version: legacy, aes-256-gcm or stream")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *publicKey == "" {
+		return errors.New("-public-key is required")
+	}
+	v, ok := versions[*version]
+	if !ok {
+		return fmt.Errorf("unknown version %q", *version)
+	}
+
+	b, err := a.readInput(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	if v == crypto.VersionStream {
+		encoder := hex.NewEncoder(a.stdout)
+		w, err := crypto.NewEncryptWriter(encoder, *publicKey)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(a.stdout)
+		return err
+	}
+
+	// JSON objects are re-encoded compactly like Encrypt does; anything else is encrypted as is
+	var ciphertext string
+	o := orderedmap.New()
+	if err := o.UnmarshalJSON(b); err == nil {
+		ciphertext, err = bridgeutil.EncryptWithVersion(o, *publicKey, v)
+		if err != nil {
+			return err
+		}
+	} else {
+		ciphertext, err = bridgeutil.EncryptStringWithVersion(strings.TrimRight(string(b), "\r\n"), *publicKey, v)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(a.stdout, ciphertext)
+	return err
+}
+
+func runDecrypt(a *app, args []string) error {
+	fs := a.flagSet("decrypt")
+	var keys keyFlags
+	keys.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	privateKey, err := keys.privateKey(a)
+	if err != nil {
+		return err
+	}
+	b, err := a.readInput(fs.Args())
+	if err != nil {
+		return err
+	}
+	ciphertext := strings.TrimSpace(string(b))
+
+	version, err := crypto.DetectVersion(ciphertext)
+	if err != nil {
+		return err
+	}
+	if version == crypto.VersionStream {
+		r, err := crypto.NewDecryptReader(hex.NewDecoder(strings.NewReader(ciphertext)), privateKey)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(a.stdout, r)
+		return err
+	}
+
+	plaintext, err := bridgeutil.Decrypt(ciphertext, privateKey)
+	if err != nil {
+		return err
+	}
+	if s, ok := plaintext.(string); ok {
+		_, err = fmt.Fprintln(a.stdout, s)
+		return err
+	}
+	return a.printJSON(plaintext)
+}
+
+func runSign(a *app, args []string) error {
+	fs := a.flagSet("sign")
+	var keys keyFlags
+	keys.register(fs)
+	paths := fs.String("fields", "", "comma separated top level objects to sign instead of the message, e.g. data,callback")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	privateKey, err := keys.privateKey(a)
+	if err != nil {
+		return err
+	}
+	message, err := a.readJSONObject(fs.Args())
+	if err != nil {
+		return err
+	}
+	var fields []string
+	if *paths != "" {
+		fields = strings.Split(*paths, ",")
+	}
+	if err := signMessage(message, privateKey, fields); err != nil {
+		return err
+	}
+	return a.printJSON(message)
+}
+
+// signMessage signs message, or each of its top level objects in fields
+func signMessage(message *orderedmap.OrderedMap, privateKey string, fields []string) error {
+	if len(fields) == 0 {
+		return bridgeutil.Sign(message, privateKey)
+	}
+	for _, field := range fields {
+		v, _ := message.Get(field)
+		o, ok := toOrderedMap(v)
+		if !ok {
+			return fmt.Errorf("%s must be a JSON object", field)
+		}
+		if err := bridgeutil.Sign(o, privateKey); err != nil {
+			return err
+		}
+		message.Set(field, o)
+	}
+	return nil
+}
+
+func runVerify(a *app, args []string) error {
+	fs := a.flagSet("verify")
+	publicKey := fs.String("public-key", "", "hex public key of the signer, Sygna Bridge of the environment by default")
+	env := fs.String("env", "", envUsage+" ($"+defaultDomainEnv+")")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *publicKey == "" {
+		cfg, err := environment(a, *env, a.getenv(defaultDomainEnv))
+		if err != nil {
+			return err
+		}
+		*publicKey = cfg.BridgePublicKey()
+	}
+
+	message, err := a.readJSONObject(fs.Args())
+	if err != nil {
+		return err
+	}
+	valid, err := bridgeutil.Verify(message, *publicKey)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errInvalidSignature
+	}
+	_, err = fmt.Fprintln(a.stdout, "valid")
+	return err
+}
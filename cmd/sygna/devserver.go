@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	bridgeutil "github.com/CoolBitX-Technology/sygna-bridge-util-go"
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/devserver"
+)
+
+const defaultCentralKeyEnv = "SYGNA_CENTRAL_PRIVATE_KEY"
+
+func runDevserver(a *app, args []string) error {
+	fs := a.flagSet("devserver")
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	seedPath := fs.String("seed", "", "YAML file of the VASPs and currencies to serve")
+	centralKeyEnv := fs.String("central-key-env", defaultCentralKeyEnv, "environment variable holding the central private key, generated when unset")
+	skipVerification := fs.Bool("skip-signature-verification", false, "accept requests whatever their signature")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := devserver.Config{
+		CentralPrivateKey:         a.getenv(*centralKeyEnv),
+		SkipSignatureVerification: *skipVerification,
+	}
+	generated := cfg.CentralPrivateKey == ""
+	if generated {
+		privateKey, _, err := bridgeutil.GenerateKeyPair()
+		if err != nil {
+			return err
+		}
+		cfg.CentralPrivateKey = privateKey
+	}
+	if *seedPath != "" {
+		seed, err := devserver.LoadSeed(*seedPath)
+		if err != nil {
+			return err
+		}
+		cfg.Seed = *seed
+	}
+	server, err := devserver.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	if generated {
+		// printed so the server can be restarted with the same key, which VASPs pin
+		fmt.Fprintf(a.stdout, "generated central private key, reuse it with: export %s=%s\n", *centralKeyEnv, cfg.CentralPrivateKey)
+	}
+	fmt.Fprintf(a.stdout, "central public key: %s\n", server.CentralPublicKey())
+	fmt.Fprintf(a.stdout, "serving fake Sygna Bridge on http://%s/ (admin at /admin/transfers)\n", *addr)
+	return http.ListenAndServe(*addr, server)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/iancoleman/orderedmap"
+)
+
+// readInput reads the file of the first positional argument, or stdin if there is none or it is "-"
+func (a *app) readInput(args []string) ([]byte, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("unexpected arguments %v", args[1:])
+	}
+	if len(args) == 0 || args[0] == "-" {
+		return io.ReadAll(a.stdin)
+	}
+	return os.ReadFile(args[0])
+}
+
+// readJSONObject reads a JSON object, keeping the order of its keys for signing
+func (a *app) readJSONObject(args []string) (*orderedmap.OrderedMap, error) {
+	b, err := a.readInput(args)
+	if err != nil {
+		return nil, err
+	}
+	o := orderedmap.New()
+	if err := o.UnmarshalJSON(b); err != nil {
+		return nil, fmt.Errorf("input must be a JSON object: %w", err)
+	}
+	return o, nil
+}
+
+// printJSON writes v as indented JSON
+func (a *app) printJSON(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, b, "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	_, err = out.WriteTo(a.stdout)
+	return err
+}
+
+// toOrderedMap returns nested objects, which orderedmap decodes as values, as pointers
+func toOrderedMap(v interface{}) (*orderedmap.OrderedMap, bool) {
+	switch o := v.(type) {
+	case *orderedmap.OrderedMap:
+		return o, true
+	case orderedmap.OrderedMap:
+		return &o, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPrivateKeyEnv = "SYGNA_PRIVATE_KEY"
+	defaultPasswordEnv   = "SYGNA_KEYSTORE_PASSWORD"
+)
+
+// keyFlags select where the private key is read from
+type keyFlags struct {
+	env         string
+	keystore    string
+	passwordEnv string
+}
+
+func (k *keyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&k.env, "private-key-env", defaultPrivateKeyEnv, "environment variable holding the hex private key")
+	fs.StringVar(&k.keystore, "keystore", "", "go-ethereum keystore file holding the private key, instead of the environment")
+	fs.StringVar(&k.passwordEnv, "password-env", defaultPasswordEnv, "environment variable holding the keystore password")
+}
+
+func (k *keyFlags) privateKey(a *app) (string, error) {
+	if k.keystore == "" {
+		privateKey := a.getenv(k.env)
+		if privateKey == "" {
+			return "", fmt.Errorf("%s is not set, set it or use -keystore", k.env)
+		}
+		return privateKey, nil
+	}
+
+	b, err := os.ReadFile(k.keystore)
+	if err != nil {
+		return "", err
+	}
+	key, err := keystore.DecryptKey(b, a.getenv(k.passwordEnv))
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt %s: %w", k.keystore, err)
+	}
+	return hex.EncodeToString(ethcrypto.FromECDSA(key.PrivateKey)), nil
+}
+
+// writeKeystore encrypts the hex private key with the password of passwordEnv into a new keystore file
+func (a *app) writeKeystore(path, passwordEnv, privateKey string) error {
+	password := a.getenv(passwordEnv)
+	if password == "" {
+		return fmt.Errorf("%s must be set to encrypt the keystore", passwordEnv)
+	}
+	ecdsaKey, err := ethcrypto.HexToECDSA(privateKey)
+	if err != nil {
+		return err
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return err
+	}
+	key := &keystore.Key{
+		Id:         id,
+		Address:    ethcrypto.PubkeyToAddress(ecdsaKey.PublicKey),
+		PrivateKey: ecdsaKey,
+	}
+	b, err := keystore.EncryptKey(key, password, a.scryptN, a.scryptP)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists", path)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
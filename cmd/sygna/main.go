@@ -0,0 +1,122 @@
+/*
+Command sygna manages keys, encrypts, decrypts, signs and verifies Sygna Bridge
+messages and calls the Sygna Bridge API.
+
+	sygna keygen [-keystore file]
+	sygna encrypt -public-key 04... [file]
+	sygna permission-request -sign request.json
+
+JSON input is read from the file argument or stdin. Private keys are read from the
+SYGNA_PRIVATE_KEY environment variable or a go-ethereum keystore file, and the API key
+from SYGNA_API_KEY, so secrets never appear in the process arguments.
+Run sygna help for every command.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+)
+
+// command is a sygna subcommand such as "encrypt" or "vasp list"
+type command struct {
+	name  string
+	usage string
+	run   func(a *app, args []string) error
+}
+
+// app holds the environment of a run so commands can be tested without a process
+type app struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+	// scryptN and scryptP are the keystore encryption parameters
+	scryptN int
+	scryptP int
+}
+
+var errInvalidSignature = errors.New("invalid signature")
+
+func commands() []command {
+	cmds := append(cryptoCommands(), apiCommands()...)
+	return append(cmds,
+		command{"devserver", "run a local fake Sygna Bridge", runDevserver},
+		command{"simulate", "walk two local VASPs through transfers on a fake bridge", runSimulate},
+	)
+}
+
+func main() {
+	a := &app{
+		stdin:   os.Stdin,
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+		getenv:  os.Getenv,
+		scryptN: keystore.StandardScryptN,
+		scryptP: keystore.StandardScryptP,
+	}
+	os.Exit(a.run(os.Args[1:]))
+}
+
+// run executes the command of args and returns the exit code
+func (a *app) run(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" {
+		a.usage()
+		return 2
+	}
+
+	cmd, rest, ok := findCommand(args)
+	if !ok {
+		fmt.Fprintf(a.stderr, "sygna: unknown command %q\n", strings.Join(args, " "))
+		a.usage()
+		return 2
+	}
+	if err := cmd.run(a, rest); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		fmt.Fprintf(a.stderr, "sygna %s: %v\n", cmd.name, err)
+		return 1
+	}
+	return 0
+}
+
+// findCommand matches two word commands such as "vasp list" before one word commands
+func findCommand(args []string) (command, []string, bool) {
+	cmds := commands()
+	if len(args) > 1 {
+		for _, cmd := range cmds {
+			if cmd.name == args[0]+" "+args[1] {
+				return cmd, args[2:], true
+			}
+		}
+	}
+	for _, cmd := range cmds {
+		if cmd.name == args[0] {
+			return cmd, args[1:], true
+		}
+	}
+	return command{}, nil, false
+}
+
+func (a *app) usage() {
+	fmt.Fprintln(a.stderr, "usage: sygna <command> [flags] [file]")
+	fmt.Fprintln(a.stderr)
+	for _, cmd := range commands() {
+		fmt.Fprintf(a.stderr, "  %-26s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(a.stderr)
+	fmt.Fprintln(a.stderr, "Run sygna <command> -h for the flags of a command.")
+}
+
+func (a *app) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("sygna "+name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	return fs
+}
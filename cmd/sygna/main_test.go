@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	bridgeutil "github.com/CoolBitX-Technology/sygna-bridge-util-go"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/iancoleman/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	fakePrivateKey = "ba4523e5091939113423a709b5924708af30fc5a958ac71f48eb030b84494702"
+	fakePublicKey  = "04c1a0d4269ce2b0e1dab89e8defbfc9c0c780e6b769f1dba7cbc3531c8167ae7f0b49b1a36d574fd0cbb353f5d31152110daa541213cf0919c1be708a112163e3"
+)
+
+func newTestApp(stdin string, env map[string]string) (*app, *bytes.Buffer, *bytes.Buffer) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	return &app{
+		stdin:   strings.NewReader(stdin),
+		stdout:  stdout,
+		stderr:  stderr,
+		getenv:  func(k string) string { return env[k] },
+		scryptN: keystore.LightScryptN,
+		scryptP: keystore.LightScryptP,
+	}, stdout, stderr
+}
+
+func TestKeygenAndPubkey(t *testing.T) {
+	a, stdout, _ := newTestApp("", nil)
+	assert.Equal(t, 0, a.run([]string{"keygen"}))
+	var keys map[string]string
+	assert.Nil(t, json.Unmarshal(stdout.Bytes(), &keys))
+
+	a, stdout, _ = newTestApp("", map[string]string{"SYGNA_PRIVATE_KEY": keys["private_key"]})
+	assert.Equal(t, 0, a.run([]string{"pubkey"}))
+	assert.Equal(t, keys["public_key"]+"\n", stdout.String())
+
+	path := filepath.Join(t.TempDir(), "key.json")
+	env := map[string]string{"SYGNA_KEYSTORE_PASSWORD": "secret"}
+	a, stdout, _ = newTestApp("", env)
+	assert.Equal(t, 0, a.run([]string{"keygen", "-keystore", path}))
+	keys = nil
+	assert.Nil(t, json.Unmarshal(stdout.Bytes(), &keys))
+	assert.Empty(t, keys["private_key"])
+
+	a, stdout, _ = newTestApp("", env)
+	assert.Equal(t, 0, a.run([]string{"pubkey", "-keystore", path}))
+	assert.Equal(t, keys["public_key"]+"\n", stdout.String())
+
+	a, _, stderr := newTestApp("", map[string]string{"SYGNA_KEYSTORE_PASSWORD": "wrong"})
+	assert.Equal(t, 1, a.run([]string{"pubkey", "-keystore", path}))
+	assert.Contains(t, stderr.String(), "cannot decrypt")
+
+	a, _, stderr = newTestApp("", nil)
+	assert.Equal(t, 1, a.run([]string{"pubkey"}))
+	assert.Contains(t, stderr.String(), "SYGNA_PRIVATE_KEY is not set")
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	env := map[string]string{"SYGNA_PRIVATE_KEY": fakePrivateKey}
+	var tests = []struct {
+		input    string
+		expected string
+	}{
+		{`{"originator":{"name":"Antoine Griezmann"}, "beneficiary":{"name":"利昂內爾 梅西"}}`, "{\n  \"originator\": {\n    \"name\": \"Antoine Griezmann\"\n  },\n  \"beneficiary\": {\n    \"name\": \"利昂內爾 梅西\"\n  }\n}\n"},
+		{"plain text\n", "plain text\n"},
+	}
+	for _, version := range []string{"legacy", "aes-256-gcm"} {
+		for _, test := range tests {
+			a, stdout, _ := newTestApp(test.input, nil)
+			assert.Equal(t, 0, a.run([]string{"encrypt", "-public-key", fakePublicKey, "-version", version}))
+
+			a, plaintext, _ := newTestApp(stdout.String(), env)
+			assert.Equal(t, 0, a.run([]string{"decrypt"}))
+			assert.Equal(t, test.expected, plaintext.String())
+		}
+	}
+
+	a, stdout, _ := newTestApp("streamed data", nil)
+	assert.Equal(t, 0, a.run([]string{"encrypt", "-public-key", fakePublicKey, "-version", "stream"}))
+	a, plaintext, _ := newTestApp(stdout.String(), env)
+	assert.Equal(t, 0, a.run([]string{"decrypt"}))
+	assert.Equal(t, "streamed data", plaintext.String())
+
+	a, _, stderr := newTestApp("{}", nil)
+	assert.Equal(t, 1, a.run([]string{"encrypt"}))
+	assert.Contains(t, stderr.String(), "-public-key is required")
+}
+
+func TestSignVerify(t *testing.T) {
+	message := `{"transfer_id":"b97903fd68fcff05cfe035482bc3cf7fd934505b4e0644e612087dca4bae37e4","txid":"6f721fba0d405df21fb27dd76cfe2b548907f3881c5625b9cfe624c15c3178ae"}`
+	a, stdout, _ := newTestApp(message, map[string]string{"SYGNA_PRIVATE_KEY": fakePrivateKey})
+	assert.Equal(t, 0, a.run([]string{"sign"}))
+	assert.Contains(t, stdout.String(), `"signature": "a599a99d018f544701e3ae1217f783581a23228d23a5fe18ff96e9fb6471d75127943bd791e3d69495a787cc0a689b4777c875f5302bf116ee88ac27f5562b2a"`)
+	signed := stdout.String()
+
+	a, stdout, _ = newTestApp(signed, nil)
+	assert.Equal(t, 0, a.run([]string{"verify", "-public-key", fakePublicKey}))
+	assert.Equal(t, "valid\n", stdout.String())
+
+	a, _, stderr := newTestApp(strings.Replace(signed, "6f721fba", "00000000", 1), nil)
+	assert.Equal(t, 1, a.run([]string{"verify", "-public-key", fakePublicKey}))
+	assert.Contains(t, stderr.String(), "invalid signature")
+
+	a, _, stderr = newTestApp(signed, map[string]string{"SYGNA_ENVIRONMENT": "staging"})
+	assert.Equal(t, 1, a.run([]string{"verify"}))
+	assert.Contains(t, stderr.String(), `unknown environment "staging"`)
+
+	a, _, stderr = newTestApp(signed, map[string]string{"SYGNA_ENVIRONMENT": "staging"})
+	assert.Equal(t, 1, a.run([]string{"verify", "-env", "sandbox"}))
+	assert.Contains(t, stderr.String(), "invalid signature")
+}
+
+func TestEnvironment(t *testing.T) {
+	var tests = []struct {
+		env, envVar, domain string
+		expected            string
+	}{
+		{"", "", "", bridgeutil.EnvironmentTest},
+		{"", "", "https://api.sygna.io", bridgeutil.EnvironmentProduction},
+		{"", "", "http://localhost:8080/", bridgeutil.EnvironmentTest},
+		{"", "sandbox", "https://api.sygna.io/", bridgeutil.EnvironmentSandbox},
+		{"production", "sandbox", "", bridgeutil.EnvironmentProduction},
+	}
+	for _, tt := range tests {
+		a, _, _ := newTestApp("", map[string]string{"SYGNA_ENVIRONMENT": tt.envVar})
+		cfg, err := environment(a, tt.env, tt.domain)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.expected, cfg.Environment, tt)
+	}
+}
+
+func TestVASPListEnvironment(t *testing.T) {
+	response := bridgeutil.StringToOrderedMap(`{"vasp_data":[{"vasp_code":"VASPUSNY1"}]}`)
+	assert.Nil(t, bridgeutil.Sign(response, fakePrivateKey))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	a, stdout, _ := newTestApp("", map[string]string{"SYGNA_API_DOMAIN": server.URL})
+	assert.Equal(t, 0, a.run([]string{"vasp", "list", "-validate=false"}))
+	assert.Contains(t, stdout.String(), "VASPUSNY1")
+
+	a, _, stderr := newTestApp("", map[string]string{"SYGNA_API_DOMAIN": server.URL, "SYGNA_ENVIRONMENT": "production"})
+	assert.Equal(t, 1, a.run([]string{"vasp", "list"}))
+	assert.Contains(t, stderr.String(), "invalid signature")
+
+	a, _, stderr = newTestApp("", map[string]string{"SYGNA_API_DOMAIN": server.URL})
+	assert.Equal(t, 1, a.run([]string{"vasp", "pubkey", "-code", "VASPUSNY1", "-env", "staging"}))
+	assert.Contains(t, stderr.String(), `unknown environment "staging"`)
+}
+
+func TestPermissionRequest(t *testing.T) {
+	var received *orderedmap.OrderedMap
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/bridge/transaction/permission-request", r.URL.Path)
+		assert.Equal(t, "api-key", r.Header.Get("X-Api-Key"))
+		received = orderedmap.New()
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(received))
+		w.Write([]byte(`{"transfer_id":"b97903fd"}`))
+	}))
+	defer server.Close()
+
+	body := `{"data":{"private_info":"04","transaction":{"currency_id":"sygna:0x80000090"},"data_dt":"2020-07-13T05:56:53.088Z"},"callback":{"callback_url":"https://example.com"}}`
+	a, stdout, stderr := newTestApp(body, map[string]string{
+		"SYGNA_API_DOMAIN":  server.URL,
+		"SYGNA_API_KEY":     "api-key",
+		"SYGNA_PRIVATE_KEY": fakePrivateKey,
+	})
+	assert.Equal(t, 0, a.run([]string{"permission-request", "-sign"}), stderr.String())
+	assert.Equal(t, "{\n  \"transfer_id\": \"b97903fd\"\n}\n", stdout.String())
+
+	for _, field := range []string{"data", "callback"} {
+		v, _ := received.Get(field)
+		o, _ := toOrderedMap(v)
+		valid, err := bridgeutil.Verify(o, fakePublicKey)
+		assert.Nil(t, err)
+		assert.True(t, valid, field)
+	}
+}
+
+func TestStatusAndUnknownCommand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/bridge/transaction/status", r.URL.Path)
+		assert.Equal(t, "b97903fd", r.URL.Query().Get("transfer_id"))
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"transfer not found"}`))
+	}))
+	defer server.Close()
+
+	a, _, stderr := newTestApp("", nil)
+	assert.Equal(t, 1, a.run([]string{"status", "-domain", server.URL, "-transfer-id", "b97903fd"}))
+	assert.Contains(t, stderr.String(), "transfer not found")
+
+	a, _, stderr = newTestApp("", nil)
+	assert.Equal(t, 2, a.run([]string{"vasp", "remove"}))
+	assert.Contains(t, stderr.String(), "unknown command")
+	assert.Contains(t, stderr.String(), "vasp list")
+
+	a, _, _ = newTestApp("", nil)
+	assert.Equal(t, 2, a.run([]string{"status", "-h"}))
+}
+
+func TestSimulate(t *testing.T) {
+	a, stdout, stderr := newTestApp("\n\n\n\n\n\n\n\n\n", nil)
+	assert.Equal(t, 0, a.run([]string{"simulate", "-scenario", "reject", "-interactive"}), stderr.String())
+	assert.Contains(t, stdout.String(), "beneficiary VASPJPJT4 answers REJECTED")
+	assert.Contains(t, stderr.String(), "press enter")
+
+	a, _, stderr = newTestApp("", nil)
+	assert.Equal(t, 1, a.run([]string{"simulate", "-scenario", "refund"}))
+	assert.Contains(t, stderr.String(), "unknown scenario")
+}
+
+func TestDevserverCentralKey(t *testing.T) {
+	a, stdout, _ := newTestApp("", nil)
+	assert.Equal(t, 1, a.run([]string{"devserver", "-addr", "invalid:address:0"}))
+	assert.Contains(t, stdout.String(), "export SYGNA_CENTRAL_PRIVATE_KEY=")
+
+	a, stdout, _ = newTestApp("", map[string]string{"SYGNA_CENTRAL_PRIVATE_KEY": fakePrivateKey})
+	assert.Equal(t, 1, a.run([]string{"devserver", "-addr", "invalid:address:0"}))
+	assert.Equal(t, "central public key: "+fakePublicKey+"\n", strings.SplitAfter(stdout.String(), "\n")[0])
+	assert.NotContains(t, stdout.String(), fakePrivateKey)
+}
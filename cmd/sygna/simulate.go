@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/simulator"
+)
+
+func runSimulate(a *app, args []string) error {
+	fs := a.flagSet("simulate")
+	scenario := fs.String("scenario", "all", "scenario to play: all, accept, reject, cancel or cdd")
+	interactive := fs.Bool("interactive", false, "wait for enter before each step")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var scenarios []simulator.Scenario
+	if *scenario != "all" {
+		found := false
+		for _, s := range simulator.Scenarios {
+			if string(s) == *scenario {
+				scenarios, found = []simulator.Scenario{s}, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown scenario %q", *scenario)
+		}
+	}
+
+	cfg := simulator.Config{Out: a.stdout}
+	if *interactive {
+		input := bufio.NewReader(a.stdin)
+		cfg.Step = func(description string) {
+			fmt.Fprintf(a.stderr, "\npress enter: %s ", strings.TrimSpace(description))
+			input.ReadString('\n')
+		}
+	}
+	return simulator.Run(context.Background(), cfg, scenarios...)
+}
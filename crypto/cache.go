@@ -0,0 +1,69 @@
+package crypto
+
+import (
+	"container/list"
+	"sync"
+)
+
+const defaultPublicKeyCacheSize = 256
+
+// defaultPublicKeyCache is used by Encrypt and Verify so that repeated calls
+// with the same hex public key only parse it once.
+var defaultPublicKeyCache = NewPublicKeyCache(defaultPublicKeyCacheSize)
+
+// PublicKeyCache is a goroutine-safe LRU cache of parsed public keys keyed by their hex form.
+type PublicKeyCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// NewPublicKeyCache returns a cache holding at most size parsed keys
+func NewPublicKeyCache(size int) *PublicKeyCache {
+	if size < 1 {
+		size = 1
+	}
+	return &PublicKeyCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// Get returns the parsed key for s, parsing and caching it on a miss
+func (c *PublicKeyCache) Get(s string) (*PublicKey, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[s]; ok {
+		c.order.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*PublicKey), nil
+	}
+	c.mu.Unlock()
+
+	key, err := ParsePublicKey(s)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[s]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*PublicKey), nil
+	}
+	c.entries[s] = c.order.PushFront(key)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*PublicKey).hex)
+	}
+	return key, nil
+}
+
+// Len returns the number of cached keys
+func (c *PublicKeyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
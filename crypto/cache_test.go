@@ -0,0 +1,30 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const fakePublicKey2 = "04a6936f2bc43773cb4874980518b3f681c004464d167aebdc9e305e10d6fb6cdacb27a22812453e6c51ceabff5b1e2d2196d81a8d3e8e71e907948b01a7ea9ac8"
+
+func TestPublicKeyCache(t *testing.T) {
+	cache := NewPublicKeyCache(1)
+
+	k1, err := cache.Get(fakePublicKey)
+	assert.Nil(t, err)
+	k2, err := cache.Get(fakePublicKey)
+	assert.Nil(t, err)
+	assert.Same(t, k1, k2, "should reuse parsed key")
+
+	_, err = cache.Get(fakePublicKey2)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, cache.Len(), "should evict least recently used key")
+
+	k3, _ := cache.Get(fakePublicKey)
+	assert.NotSame(t, k1, k3, "should parse evicted key again")
+
+	_, err = cache.Get("zz")
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, cache.Len(), "should not cache invalid key")
+}
@@ -10,34 +10,43 @@ import (
 
 // Encrypt Encrypt private info to hex string.
 func Encrypt(sensitiveData []byte, publicKey string) (string, error) {
-	eciesPublicKey, err := newPublicKeyFromHex(publicKey)
-	if err != nil {
-		return "", err
-	}
+	return EncryptWithVersion(sensitiveData, publicKey, VersionLegacy)
+}
 
-	// Generate ephemeral key
-	ek, err := generateKey()
+// EncryptWithVersion Encrypt private info to hex string using the given envelope version.
+func EncryptWithVersion(sensitiveData []byte, publicKey string, version Version) (string, error) {
+	key, err := defaultPublicKeyCache.Get(publicKey)
 	if err != nil {
 		return "", err
 	}
+	return key.EncryptWithVersion(sensitiveData, version)
+}
 
-	encryptionKey, macKey, err := ek.Encapsulate(eciesPublicKey)
+// Encrypt Encrypt private info to hex string with the parsed Public Key.
+func (k *PublicKey) Encrypt(sensitiveData []byte) (string, error) {
+	return k.EncryptWithVersion(sensitiveData, VersionLegacy)
+}
 
+// EncryptWithVersion Encrypt private info to hex string with the parsed Public Key using the given envelope version.
+func (k *PublicKey) EncryptWithVersion(sensitiveData []byte, version Version) (string, error) {
+	encryptedData, err := sealEnvelope(sensitiveData, k.publicKey, version)
 	if err != nil {
 		return "", err
 	}
-	iv := make([]byte, 16)
-
-	ciphertext := aesEncrypt(sensitiveData, encryptionKey, iv)
-	dataToMac := appendBytes(iv, ek.publicKey.Bytes(false), ciphertext)
-
-	encryptedData := appendBytes(ek.publicKey.Bytes(false), sha1Sum(dataToMac, macKey), ciphertext)
-	encryptedHex := hex.EncodeToString(encryptedData)
-	return encryptedHex, nil
+	return hex.EncodeToString(encryptedData), nil
 }
 
 //Decrypt Decrypt private info from recipient server.
 func Decrypt(encryptedData, privateKey string) (interface{}, error) {
+	decrypted, err := DecryptBytes(encryptedData, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return toPrivateInfo(decrypted), nil
+}
+
+// DecryptBytes Decrypt private info from recipient server to raw bytes.
+func DecryptBytes(encryptedData, privateKey string) ([]byte, error) {
 	bEncrypted, err := hex.DecodeString(encryptedData)
 	if err != nil {
 		return nil, err
@@ -46,6 +55,46 @@ func Decrypt(encryptedData, privateKey string) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	return openEnvelope(bEncrypted, eciesPrivateKey)
+}
+
+// toPrivateInfo returns decrypted as *orderedmap.OrderedMap if it is a json object, otherwise as string
+func toPrivateInfo(decrypted []byte) interface{} {
+	o := orderedmap.New()
+	o.UnmarshalJSON(decrypted)
+
+	if isOrderedMapEmpty(o) {
+		return string(decrypted)
+	}
+	return o
+}
+
+// encryptLegacy encrypts with AES-256-CBC under an all-zero IV and appends
+// HMAC-SHA1 over iv || ephemeralPub || ciphertext, as Sygna Bridge expects.
+func encryptLegacy(sensitiveData []byte, pub *publicKey) ([]byte, error) {
+	// Generate ephemeral key
+	ek, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	encryptionKey, macKey, err := ek.Encapsulate(pub)
+
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, 16)
+
+	ciphertext := aesEncrypt(sensitiveData, encryptionKey, iv)
+	dataToMac := appendBytes(iv, ek.publicKey.Bytes(false), ciphertext)
+
+	return appendBytes(ek.publicKey.Bytes(false), sha1Sum(dataToMac, macKey), ciphertext), nil
+}
+
+func decryptLegacy(bEncrypted []byte, priv *privateKey) ([]byte, error) {
+	if len(bEncrypted) < 85 {
+		return nil, errors.New("encrypted data is too short")
+	}
 	ephemeralPubKey := bEncrypted[:65]
 	mac := bEncrypted[65:85]
 	ciphertext := bEncrypted[85:]
@@ -55,7 +104,7 @@ func Decrypt(encryptedData, privateKey string) (interface{}, error) {
 		return nil, err
 	}
 
-	encryptionKey, macKey, err := eciesPrivateKey.Encapsulate(eciesPublicKey)
+	encryptionKey, macKey, err := priv.Encapsulate(eciesPublicKey)
 	if err != nil {
 		return nil, err
 	}
@@ -69,16 +118,5 @@ func Decrypt(encryptedData, privateKey string) (interface{}, error) {
 		return nil, errors.New("mac is not same")
 	}
 
-	decrypted, err := aesDecrypt(ciphertext, encryptionKey, iv)
-	if err != nil {
-		return nil, err
-	}
-
-	o := orderedmap.New()
-	o.UnmarshalJSON(decrypted)
-
-	if isOrderedMapEmpty(o) {
-		return string(decrypted), nil
-	}
-	return o, nil
+	return aesDecrypt(ciphertext, encryptionKey, iv)
 }
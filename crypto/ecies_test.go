@@ -126,3 +126,27 @@ func TestDecrypt(t *testing.T) {
 		assert.Equal(t, string(bDecrypted), string(bExpected), "should be equal")
 	}
 }
+
+func BenchmarkEncrypt(b *testing.B) {
+	plaintext := []byte(`{"originator":{"name":"Antoine Griezmann","date_of_birth":"1991-03-21"},"beneficiary":{"name":"利昂內爾 梅西"}}`)
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Encrypt(plaintext, fakePublicKey); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	ciphertext, err := Encrypt([]byte(`{"originator":{"name":"Antoine Griezmann","date_of_birth":"1991-03-21"},"beneficiary":{"name":"利昂內爾 梅西"}}`), fakePublicKey)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decrypt(ciphertext, fakePrivateKey); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
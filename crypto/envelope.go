@@ -0,0 +1,161 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Version identifies the layout of an encrypted private info envelope.
+type Version byte
+
+const (
+	// VersionLegacy is the format every Sygna Bridge VASP understands:
+	// uncompressed ephemeral key || HMAC-SHA1 || AES-256-CBC ciphertext.
+	// It carries no version byte; the 0x04 key prefix identifies it.
+	VersionLegacy Version = 0x04
+	// VersionAESGCM is 0x01 || compressed ephemeral key || nonce || AES-256-GCM ciphertext,
+	// with the key derived by HKDF-SHA256 from the ECDH shared secret.
+	VersionAESGCM Version = 0x01
+)
+
+const (
+	compressedKeySize = 33
+	gcmNonceSize      = 12
+)
+
+var hkdfInfoAESGCM = []byte("sygna-bridge-ecies-aes-256-gcm")
+
+// String returns the name of the version
+func (v Version) String() string {
+	switch v {
+	case VersionLegacy:
+		return "legacy"
+	case VersionAESGCM:
+		return "aes-256-gcm"
+	case VersionStream:
+		return "stream"
+	default:
+		return fmt.Sprintf("unknown(0x%02x)", byte(v))
+	}
+}
+
+// DetectVersion returns the envelope version of hex encrypted private info
+func DetectVersion(encryptedData string) (Version, error) {
+	b, err := hex.DecodeString(encryptedData)
+	if err != nil {
+		return 0, err
+	}
+	return detectVersion(b)
+}
+
+func detectVersion(bEncrypted []byte) (Version, error) {
+	if len(bEncrypted) == 0 {
+		return 0, errors.New("encrypted data is empty")
+	}
+	switch v := Version(bEncrypted[0]); v {
+	case VersionLegacy, VersionAESGCM, VersionStream:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("unsupported encryption version: %v", v)
+	}
+}
+
+func sealEnvelope(sensitiveData []byte, pub *publicKey, version Version) ([]byte, error) {
+	switch version {
+	case VersionLegacy:
+		return encryptLegacy(sensitiveData, pub)
+	case VersionAESGCM:
+		return encryptAESGCM(sensitiveData, pub)
+	default:
+		return nil, fmt.Errorf("unsupported encryption version: %v", version)
+	}
+}
+
+func openEnvelope(bEncrypted []byte, priv *privateKey) ([]byte, error) {
+	version, err := detectVersion(bEncrypted)
+	if err != nil {
+		return nil, err
+	}
+	switch version {
+	case VersionAESGCM:
+		return decryptAESGCM(bEncrypted, priv)
+	case VersionStream:
+		return nil, errors.New("encrypted stream must be decrypted with NewDecryptReader")
+	default:
+		return decryptLegacy(bEncrypted, priv)
+	}
+}
+
+// deriveKey derives a 32 byte symmetric key bound to both ephemeral and recipient keys
+func deriveKey(secret, ephemeralPub, recipientPub, info []byte) ([]byte, error) {
+	return hkdf.Key(sha256.New, secret, appendBytes(ephemeralPub, recipientPub), string(info), 32)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encryptAESGCM(sensitiveData []byte, pub *publicKey) ([]byte, error) {
+	ek, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+	ephemeralPub := ek.publicKey.Bytes(true)
+
+	key, err := deriveKey(ek.sharedSecret(pub), ephemeralPub, pub.Bytes(true), hkdfInfoAESGCM)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcmNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	header := appendBytes([]byte{byte(VersionAESGCM)}, ephemeralPub)
+	return aead.Seal(appendBytes(header, nonce), nonce, sensitiveData, header), nil
+}
+
+func decryptAESGCM(bEncrypted []byte, priv *privateKey) ([]byte, error) {
+	headerSize := 1 + compressedKeySize
+	if len(bEncrypted) < headerSize+gcmNonceSize {
+		return nil, errors.New("encrypted data is too short")
+	}
+	header := bEncrypted[:headerSize]
+	nonce := bEncrypted[headerSize : headerSize+gcmNonceSize]
+	ciphertext := bEncrypted[headerSize+gcmNonceSize:]
+
+	ephemeralPub, err := newPublicKeyFromBytes(header[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(priv.sharedSecret(ephemeralPub), header[1:], priv.publicKey.Bytes(true), hkdfInfoAESGCM)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, err := aead.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, errors.New("cannot authenticate encrypted data")
+	}
+	return decrypted, nil
+}
@@ -0,0 +1,58 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/iancoleman/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptWithVersion(t *testing.T) {
+	plaintext := `{"originator":{"name":"Antoine Griezmann"},"beneficiary":{"name":"利昂內爾 梅西"}}`
+
+	var tests = []struct {
+		version  Version
+		expected Version
+	}{
+		{VersionLegacy, VersionLegacy},
+		{VersionAESGCM, VersionAESGCM},
+	}
+
+	for _, test := range tests {
+		ciphertext, err := EncryptWithVersion([]byte(plaintext), fakePublicKey, test.version)
+		assert.Nil(t, err)
+
+		version, err := DetectVersion(ciphertext)
+		assert.Nil(t, err)
+		assert.Equal(t, test.expected, version, "should be equal")
+
+		decrypted, err := Decrypt(ciphertext, fakePrivateKey)
+		assert.Nil(t, err)
+		b, _ := decrypted.(*orderedmap.OrderedMap).MarshalJSON()
+		assert.Equal(t, plaintext, string(b), "should be equal")
+	}
+}
+
+func TestDecryptAESGCMTampered(t *testing.T) {
+	ciphertext, err := EncryptWithVersion([]byte("abcdefghijk"), fakePublicKey, VersionAESGCM)
+	assert.Nil(t, err)
+
+	b, _ := hex.DecodeString(ciphertext)
+	b[len(b)-1] ^= 0x01
+	_, err = Decrypt(hex.EncodeToString(b), fakePrivateKey)
+	assert.NotNil(t, err)
+
+	b[0] = 0x7f
+	_, err = Decrypt(hex.EncodeToString(b), fakePrivateKey)
+	assert.NotNil(t, err)
+}
+
+func TestDecryptAESGCMCompressedEphemeralKey(t *testing.T) {
+	ciphertext, _ := EncryptWithVersion([]byte("abcdefghijk"), fakePublicKey, VersionAESGCM)
+	b, _ := hex.DecodeString(ciphertext)
+
+	assert.Equal(t, byte(VersionAESGCM), b[0])
+	assert.Contains(t, []byte{0x02, 0x03}, b[1], "ephemeral key should be compressed")
+	assert.Equal(t, 1+33+12+len("abcdefghijk")+16, len(b))
+}
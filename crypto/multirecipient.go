@@ -0,0 +1,127 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+)
+
+// MultiRecipientCiphertext is private info encrypted for the beneficiary VASP
+// in the Sygna Bridge format, together with copies any additional recipient
+// (e.g. an archive key of the compliance team) can open.
+//
+// The additional copies share a single AES-256-GCM payload whose random
+// content key is wrapped for each recipient with VersionAESGCM.
+type MultiRecipientCiphertext struct {
+	// PrivateInfo is the legacy ciphertext to send as private_info to Sygna Bridge
+	PrivateInfo string `json:"private_info"`
+	// Payload is hex of nonce || AES-256-GCM ciphertext under the content key
+	Payload string `json:"payload,omitempty"`
+	// Recipients holds the content key wrapped for each additional public key
+	Recipients []WrappedKey `json:"recipients,omitempty"`
+}
+
+// WrappedKey is the content key of a MultiRecipientCiphertext encrypted for one recipient
+type WrappedKey struct {
+	// KeyID is hex of the recipient's compressed public key
+	KeyID string `json:"key_id"`
+	// WrappedKey is the hex VersionAESGCM envelope of the content key
+	WrappedKey string `json:"wrapped_key"`
+}
+
+var multiRecipientPayloadAD = []byte("sygna-bridge-multi-recipient")
+
+// EncryptMultiRecipient Encrypt private info for the beneficiary and every additional public key.
+func EncryptMultiRecipient(sensitiveData []byte, beneficiaryPublicKey string, additionalPublicKeys ...string) (*MultiRecipientCiphertext, error) {
+	privateInfo, err := Encrypt(sensitiveData, beneficiaryPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	result := &MultiRecipientCiphertext{PrivateInfo: privateInfo}
+	if len(additionalPublicKeys) == 0 {
+		return result, nil
+	}
+
+	contentKey := make([]byte, 32)
+	if _, err := rand.Read(contentKey); err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcmNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	result.Payload = hex.EncodeToString(aead.Seal(nonce, nonce, sensitiveData, multiRecipientPayloadAD))
+
+	for _, publicKey := range additionalPublicKeys {
+		key, err := defaultPublicKeyCache.Get(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		wrapped, err := key.EncryptWithVersion(contentKey, VersionAESGCM)
+		if err != nil {
+			return nil, err
+		}
+		result.Recipients = append(result.Recipients, WrappedKey{
+			KeyID:      hex.EncodeToString(key.Bytes(true)),
+			WrappedKey: wrapped,
+		})
+	}
+	return result, nil
+}
+
+// DecryptMultiRecipient Decrypt whichever copy of private info the private key can open.
+func DecryptMultiRecipient(ciphertext *MultiRecipientCiphertext, privateKey string) (interface{}, error) {
+	if ciphertext == nil {
+		return nil, errors.New("ciphertext is empty")
+	}
+	eciesPrivateKey, err := newPrivateKeyFromHex(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID := hex.EncodeToString(eciesPrivateKey.publicKey.Bytes(true))
+	for _, recipient := range ciphertext.Recipients {
+		if recipient.KeyID != keyID {
+			continue
+		}
+		decrypted, err := openMultiRecipientPayload(ciphertext.Payload, recipient.WrappedKey, eciesPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		return toPrivateInfo(decrypted), nil
+	}
+
+	return Decrypt(ciphertext.PrivateInfo, privateKey)
+}
+
+func openMultiRecipientPayload(payload, wrappedKey string, priv *privateKey) ([]byte, error) {
+	bWrapped, err := hex.DecodeString(wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	contentKey, err := openEnvelope(bWrapped, priv)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(contentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	bPayload, err := hex.DecodeString(payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(bPayload) < gcmNonceSize {
+		return nil, errors.New("encrypted data is too short")
+	}
+	decrypted, err := aead.Open(nil, bPayload[:gcmNonceSize], bPayload[gcmNonceSize:], multiRecipientPayloadAD)
+	if err != nil {
+		return nil, errors.New("cannot authenticate encrypted data")
+	}
+	return decrypted, nil
+}
@@ -0,0 +1,39 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const fakeArchivePrivateKey = "5ee9b1d4b4b8fd0b2e0f8c2c6a3a6f8d51d1fb8fd79a8d2f3f2a0d9b0d7c3e21"
+
+func TestEncryptMultiRecipient(t *testing.T) {
+	archive, _ := newPrivateKeyFromHex(fakeArchivePrivateKey)
+	archivePublicKey := hex.EncodeToString(archive.publicKey.Bytes(false))
+
+	plaintext := "zxcvvbjgiyi5/喬丹"
+	ciphertext, err := EncryptMultiRecipient([]byte(plaintext), fakePublicKey, archivePublicKey)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ciphertext.Recipients))
+
+	// the beneficiary copy stays a plain Sygna Bridge ciphertext
+	decrypted, err := Decrypt(ciphertext.PrivateInfo, fakePrivateKey)
+	assert.Nil(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	b, _ := json.Marshal(ciphertext)
+	var stored MultiRecipientCiphertext
+	assert.Nil(t, json.Unmarshal(b, &stored))
+
+	for _, privateKey := range []string{fakePrivateKey, fakeArchivePrivateKey} {
+		decrypted, err := DecryptMultiRecipient(&stored, privateKey)
+		assert.Nil(t, err)
+		assert.Equal(t, plaintext, decrypted, "should be equal")
+	}
+
+	_, err = DecryptMultiRecipient(&stored, "aa4523e5091939113423a709b5924708af30fc5a958ac71f48eb030b84494702")
+	assert.NotNil(t, err, "should not decrypt with an unrelated key")
+}
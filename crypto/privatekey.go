@@ -1,40 +1,50 @@
 package crypto
 
 import (
-	"crypto/elliptic"
-	"crypto/rand"
+	"bytes"
 	"encoding/hex"
 	"fmt"
-	"math/big"
 
-	"github.com/fomichev/secp256k1"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 )
 
 // PrivateKey is an instance of secp256k1 private key with nested public key
 type privateKey struct {
 	*publicKey
-	D *big.Int
+	key *secp256k1.PrivateKey
 }
 
 // generateKey generates secp256k1 key pair
 func generateKey() (*privateKey, error) {
-	curve := secp256k1.SECP256K1()
-
-	p, x, y, err := elliptic.GenerateKey(curve, rand.Reader)
+	key, err := secp256k1.GeneratePrivateKey()
 	if err != nil {
 		return nil, fmt.Errorf("cannot generate key pair: %w", err)
 	}
 
 	return &privateKey{
-		publicKey: &publicKey{
-			Curve: curve,
-			X:     x,
-			Y:     y,
-		},
-		D: new(big.Int).SetBytes(p),
+		publicKey: &publicKey{key: key.PubKey()},
+		key:       key,
 	}, nil
 }
 
+// GenerateKeyPair generates a secp256k1 key pair and returns the hex private key and uncompressed public key
+func GenerateKeyPair() (string, string, error) {
+	k, err := generateKey()
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(k.key.Serialize()), hex.EncodeToString(k.Bytes(false)), nil
+}
+
+// PublicKeyFromPrivateKey returns the hex uncompressed public key of a hex private key
+func PublicKeyFromPrivateKey(privateKey string) (string, error) {
+	k, err := newPrivateKeyFromHex(privateKey)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(k.Bytes(false)), nil
+}
+
 // NewPrivateKeyFromHex decodes hex form of private key raw bytes, computes public key and returns PrivateKey instance
 func newPrivateKeyFromHex(s string) (*privateKey, error) {
 	b, err := hex.DecodeString(s)
@@ -47,16 +57,11 @@ func newPrivateKeyFromHex(s string) (*privateKey, error) {
 
 // NewPrivateKeyFromBytes decodes private key raw bytes, computes public key and returns PrivateKey instance
 func newPrivateKeyFromBytes(priv []byte) *privateKey {
-	curve := secp256k1.SECP256K1()
-	x, y := curve.ScalarBaseMult(priv)
+	key := secp256k1.PrivKeyFromBytes(priv)
 
 	return &privateKey{
-		publicKey: &publicKey{
-			Curve: curve,
-			X:     x,
-			Y:     y,
-		},
-		D: new(big.Int).SetBytes(priv),
+		publicKey: &publicKey{key: key.PubKey()},
+		key:       key,
 	}
 }
 
@@ -66,8 +71,16 @@ func (k *privateKey) Encapsulate(pub *publicKey) ([]byte, []byte, error) {
 	if pub == nil {
 		return nil, nil, fmt.Errorf("public key is empty")
 	}
-	sx, _ := pub.Curve.ScalarMult(pub.X, pub.Y, k.D.Bytes())
+	// The shared secret is computed in constant time; leading zero bytes are
+	// trimmed to stay compatible with the big.Int based encoding used by
+	// ciphertexts produced before the backend switch.
+	sx := bytes.TrimLeft(k.sharedSecret(pub), "\x00")
 
-	hash := sha512Sum(sx.Bytes())
+	hash := sha512Sum(sx)
 	return hash[:32], hash[32:], nil
 }
+
+// sharedSecret returns the 32 byte x coordinate of the ECDH shared point
+func (k *privateKey) sharedSecret(pub *publicKey) []byte {
+	return secp256k1.GenerateSharedSecret(k.key, pub.key)
+}
@@ -1,19 +1,15 @@
 package crypto
 
 import (
-	"bytes"
-	"crypto/elliptic"
 	"encoding/hex"
 	"fmt"
-	"math/big"
 
-	"github.com/fomichev/secp256k1"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 )
 
-// PublicKey instance with nested elliptic.Curve interface (secp256k1 instance in our case)
+// PublicKey instance backed by a constant-time secp256k1 implementation
 type publicKey struct {
-	elliptic.Curve
-	X, Y *big.Int
+	key *secp256k1.PublicKey
 }
 
 // NewPublicKeyFromHex decodes hex form of public key raw bytes and returns PublicKey instance
@@ -29,103 +25,40 @@ func newPublicKeyFromHex(s string) (*publicKey, error) {
 // NewPublicKeyFromBytes decodes public key raw bytes and returns PublicKey instance;
 // Supports both compressed and uncompressed public keys
 func newPublicKeyFromBytes(b []byte) (*publicKey, error) {
-	curve := secp256k1.SECP256K1()
-
-	switch b[0] {
-	case 0x02, 0x03:
-		if len(b) != 33 {
-			return nil, fmt.Errorf("cannot parse public key")
-		}
-
-		x := new(big.Int).SetBytes(b[1:])
-		var ybit uint
-		switch b[0] {
-		case 0x02:
-			ybit = 0
-		case 0x03:
-			ybit = 1
-		}
-
-		if x.Cmp(curve.Params().P) >= 0 {
-			return nil, fmt.Errorf("cannot parse public key")
-		}
-
-		// y^2 = x^3 + b
-		// y   = sqrt(x^3 + b)
-		var y, x3b big.Int
-		x3b.Mul(x, x)
-		x3b.Mul(&x3b, x)
-		x3b.Add(&x3b, curve.Params().B)
-		x3b.Mod(&x3b, curve.Params().P)
-		if z := y.ModSqrt(&x3b, curve.Params().P); z == nil {
-			return nil, fmt.Errorf("cannot parse public key")
-		}
-
-		if y.Bit(0) != ybit {
-			y.Sub(curve.Params().P, &y)
-		}
-		if y.Bit(0) != ybit {
-			return nil, fmt.Errorf("incorrectly encoded X and Y bit")
-		}
-
-		return &publicKey{
-			Curve: curve,
-			X:     x,
-			Y:     &y,
-		}, nil
-	case 0x04:
-		if len(b) != 65 {
-			return nil, fmt.Errorf("cannot parse public key")
-		}
-
-		x := new(big.Int).SetBytes(b[1:33])
-		y := new(big.Int).SetBytes(b[33:])
-
-		if x.Cmp(curve.Params().P) >= 0 || y.Cmp(curve.Params().P) >= 0 {
-			return nil, fmt.Errorf("cannot parse public key")
-		}
-
-		x3 := new(big.Int).Sqrt(x).Mul(x, x)
-		if t := new(big.Int).Sqrt(y).Sub(y, x3.Add(x3, curve.Params().B)); t.IsInt64() && t.Int64() == 0 {
-			return nil, fmt.Errorf("cannot parse public key")
-		}
-
-		return &publicKey{
-			Curve: curve,
-			X:     x,
-			Y:     y,
-		}, nil
-	default:
-		return nil, fmt.Errorf("cannot parse public key")
+	key, err := secp256k1.ParsePubKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse public key: %w", err)
 	}
+
+	return &publicKey{key: key}, nil
 }
 
 // Bytes returns public key raw bytes;
 // Could be optionally compressed by dropping Y part
 func (k *publicKey) Bytes(compressed bool) []byte {
-	x := k.X.Bytes()
-	if len(x) < 32 {
-		for i := 0; i < 32-len(x); i++ {
-			x = append([]byte{0}, x...)
-		}
-	}
-
 	if compressed {
-		// If odd
-		if k.Y.Bit(0) != 0 {
-			return bytes.Join([][]byte{{0x03}, x}, nil)
-		}
-
-		// If even
-		return bytes.Join([][]byte{{0x02}, x}, nil)
+		return k.key.SerializeCompressed()
 	}
+	return k.key.SerializeUncompressed()
+}
+
+// PublicKey is a parsed secp256k1 public key which can be reused to verify
+// signatures and encrypt private info without decoding the hex form again.
+type PublicKey struct {
+	*publicKey
+	hex string
+}
 
-	y := k.Y.Bytes()
-	if len(y) < 32 {
-		for i := 0; i < 32-len(y); i++ {
-			y = append([]byte{0}, y...)
-		}
+// ParsePublicKey decodes hex form of compressed or uncompressed public key
+func ParsePublicKey(s string) (*PublicKey, error) {
+	key, err := newPublicKeyFromHex(s)
+	if err != nil {
+		return nil, err
 	}
+	return &PublicKey{publicKey: key, hex: s}, nil
+}
 
-	return bytes.Join([][]byte{{0x04}, x, y}, nil)
+// String returns the hex form the key was parsed from
+func (k *PublicKey) String() string {
+	return k.hex
 }
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/iancoleman/orderedmap"
 )
@@ -37,30 +39,68 @@ func Sign(message *orderedmap.OrderedMap, privateKey string) error {
 
 // Verify Verify data with provided Public Key
 func Verify(message *orderedmap.OrderedMap, publicKey string) (bool, error) {
-	bPublicKey, err := hex.DecodeString(publicKey)
+	key, err := defaultPublicKeyCache.Get(publicKey)
 	if err != nil {
 		return false, err
 	}
+	return key.Verify(message)
+}
 
-	clone, err := cloneOrderedMap(message)
-	if err != nil {
-		return false, err
-	}
-	signature, exist := clone.Get("signature")
+// Verify Verify data with the parsed Public Key
+func (k *PublicKey) Verify(message *orderedmap.OrderedMap) (bool, error) {
+	signature, exist := message.Get("signature")
 	if !exist {
 		return false, errors.New("message must contain signature")
 	}
+	strSignature, ok := signature.(string)
+	if !ok {
+		return false, errors.New("signature must be a string")
+	}
 
-	bSignature, err := hex.DecodeString(signature.(string))
+	bSignature, err := hex.DecodeString(strSignature)
 	if err != nil {
 		return false, err
 	}
 
-	clone.Set("signature", "")
-	bClone, err := json.Marshal(clone)
+	bMessage, err := marshalWithEmptySignature(message)
 	if err != nil {
 		return false, err
 	}
 
-	return crypto.VerifySignature(bPublicKey, sha256Sum(bClone), bSignature), nil
+	return verifySignature(k.key, sha256Sum(bMessage), bSignature), nil
+}
+
+// NormalizeSignature returns a 64 byte [R || S] signature with S in the lower half of the curve
+// order, the only form Verify accepts. HSMs often return the other, equally valid, S.
+func NormalizeSignature(signature []byte) ([]byte, error) {
+	if len(signature) != 64 {
+		return nil, errors.New("signature must be 64 bytes")
+	}
+	var r, s secp256k1.ModNScalar
+	if r.SetByteSlice(signature[:32]) || s.SetByteSlice(signature[32:]) || r.IsZero() || s.IsZero() {
+		return nil, errors.New("invalid signature")
+	}
+	if s.IsOverHalfOrder() {
+		s.Negate()
+	}
+	normalized := make([]byte, 64)
+	r.PutBytesUnchecked(normalized[:32])
+	s.PutBytesUnchecked(normalized[32:])
+	return normalized, nil
+}
+
+// verifySignature checks a 64 byte [R || S] signature the same way as
+// go-ethereum's VerifySignature, rejecting malleable signatures.
+func verifySignature(pub *secp256k1.PublicKey, hash, signature []byte) bool {
+	if len(signature) != 64 {
+		return false
+	}
+	var r, s secp256k1.ModNScalar
+	if r.SetByteSlice(signature[:32]) || s.SetByteSlice(signature[32:]) {
+		return false
+	}
+	if s.IsOverHalfOrder() {
+		return false
+	}
+	return ecdsa.NewSignature(&r, &s).Verify(hash, pub)
 }
@@ -1,6 +1,7 @@
 package crypto
 
 import (
+	"encoding/hex"
 	"testing"
 
 	"github.com/iancoleman/orderedmap"
@@ -91,3 +92,86 @@ func TestVerify(t *testing.T) {
 		assert.Equal(t, valid, test.expected, "should be equal")
 	}
 }
+
+func TestGenerateKeyPair(t *testing.T) {
+	publicKey, err := PublicKeyFromPrivateKey(fakePrivateKey)
+	assert.Nil(t, err)
+	assert.Equal(t, fakePublicKey, publicKey)
+
+	privateKey, publicKey, err := GenerateKeyPair()
+	assert.Nil(t, err)
+	derived, _ := PublicKeyFromPrivateKey(privateKey)
+	assert.Equal(t, publicKey, derived)
+
+	o := orderedmap.New()
+	o.Set("transfer_id", "b97903fd68fcff05cfe035482bc3cf7fd934505b4e0644e612087dca4bae37e4")
+	assert.Nil(t, Sign(o, privateKey))
+	valid, err := Verify(o, publicKey)
+	assert.Nil(t, err)
+	assert.True(t, valid, "should be valid")
+
+	_, err = PublicKeyFromPrivateKey("zz")
+	assert.NotNil(t, err)
+}
+
+func BenchmarkSign(b *testing.B) {
+	o := orderedmap.New()
+	o.Set("transfer_id", "b97903fd68fcff05cfe035482bc3cf7fd934505b4e0644e612087dca4bae37e4")
+	o.Set("txid", "6f721fba0d405df21fb27dd76cfe2b548907f3881c5625b9cfe624c15c3178ae")
+
+	for i := 0; i < b.N; i++ {
+		if err := Sign(o, fakePrivateKey); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	o := orderedmap.New()
+	o.Set("transfer_id", "b97903fd68fcff05cfe035482bc3cf7fd934505b4e0644e612087dca4bae37e4")
+	o.Set("txid", "6f721fba0d405df21fb27dd76cfe2b548907f3881c5625b9cfe624c15c3178ae")
+	o.Set("signature", "a599a99d018f544701e3ae1217f783581a23228d23a5fe18ff96e9fb6471d75127943bd791e3d69495a787cc0a689b4777c875f5302bf116ee88ac27f5562b2a")
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Verify(o, fakePublicKey); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestPublicKeyVerify(t *testing.T) {
+	key, err := ParsePublicKey(fakePublicKey)
+	assert.Nil(t, err)
+
+	o := orderedmap.New()
+	o.Set("transfer_id", "b97903fd68fcff05cfe035482bc3cf7fd934505b4e0644e612087dca4bae37e4")
+	o.Set("txid", "6f721fba0d405df21fb27dd76cfe2b548907f3881c5625b9cfe624c15c3178ae")
+	o.Set("signature", "a599a99d018f544701e3ae1217f783581a23228d23a5fe18ff96e9fb6471d75127943bd791e3d69495a787cc0a689b4777c875f5302bf116ee88ac27f5562b2a")
+
+	for i := 0; i < 2; i++ {
+		valid, err := key.Verify(o)
+		assert.Nil(t, err)
+		assert.True(t, valid, "should be valid")
+	}
+
+	o.Set("txid", "tampered")
+	valid, _ := key.Verify(o)
+	assert.False(t, valid, "should be invalid")
+}
+
+func TestNormalizeSignature(t *testing.T) {
+	lowS, _ := hex.DecodeString("a599a99d018f544701e3ae1217f783581a23228d23a5fe18ff96e9fb6471d75127943bd791e3d69495a787cc0a689b4777c875f5302bf116ee88ac27f5562b2a")
+	highS, _ := hex.DecodeString("a599a99d018f544701e3ae1217f783581a23228d23a5fe18ff96e9fb6471d751d86bc4286e1c296b6a587833f59764b742e666f17f1caf24d149b264dae01617")
+
+	normalized, err := NormalizeSignature(highS)
+	assert.Nil(t, err)
+	assert.Equal(t, lowS, normalized)
+	normalized, err = NormalizeSignature(lowS)
+	assert.Nil(t, err)
+	assert.Equal(t, lowS, normalized)
+
+	_, err = NormalizeSignature(lowS[:63])
+	assert.NotNil(t, err)
+	_, err = NormalizeSignature(make([]byte, 64))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,326 @@
+package crypto
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// VersionStream is 0x02 || compressed ephemeral key || nonce prefix, followed by
+// chunks of uint32 length || AES-256-GCM ciphertext. Every chunk is authenticated
+// on its own and the last one is flagged, so truncated streams are rejected.
+const VersionStream Version = 0x02
+
+const (
+	streamChunkSize       = 64 * 1024
+	streamNoncePrefixSize = 7
+	streamHeaderSize      = 1 + compressedKeySize + streamNoncePrefixSize
+)
+
+var hkdfInfoStream = []byte("sygna-bridge-ecies-stream")
+
+// Encoding is the text encoding of an encrypted stream
+type Encoding int
+
+const (
+	// EncodingRaw leaves the encrypted stream as raw bytes
+	EncodingRaw Encoding = iota
+	// EncodingHex encodes the encrypted stream as lowercase hex
+	EncodingHex
+	// EncodingBase64 encodes the encrypted stream as standard base64
+	EncodingBase64
+)
+
+type encryptWriter struct {
+	w           io.Writer
+	aead        cipher.AEAD
+	header      []byte
+	noncePrefix []byte
+	buf         []byte
+	counter     uint32
+	closed      bool
+}
+
+// NewEncryptWriter returns a writer which encrypts everything written to it for publicKey
+// and writes the encrypted stream to w. Close must be called to write the final chunk.
+func NewEncryptWriter(w io.Writer, publicKey string) (io.WriteCloser, error) {
+	key, err := defaultPublicKeyCache.Get(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return key.NewEncryptWriter(w)
+}
+
+// NewEncryptWriter returns a writer which encrypts everything written to it with the parsed Public Key.
+func (k *PublicKey) NewEncryptWriter(w io.Writer) (io.WriteCloser, error) {
+	ek, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+	ephemeralPub := ek.publicKey.Bytes(true)
+
+	key, err := deriveKey(ek.sharedSecret(k.publicKey), ephemeralPub, k.Bytes(true), hkdfInfoStream)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	noncePrefix := make([]byte, streamNoncePrefixSize)
+	if _, err := rand.Read(noncePrefix); err != nil {
+		return nil, err
+	}
+	header := appendBytes([]byte{byte(VersionStream)}, ephemeralPub, noncePrefix)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{
+		w:           w,
+		aead:        aead,
+		header:      header,
+		noncePrefix: noncePrefix,
+		buf:         make([]byte, 0, streamChunkSize),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write to closed encrypt writer")
+	}
+	written := 0
+	for len(p) > 0 {
+		// only flush a full chunk once more data arrives, the last chunk is written by Close
+		if len(e.buf) == streamChunkSize {
+			if err := e.flush(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):streamChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close writes the final chunk; it does not close the underlying writer
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.flush(true)
+}
+
+func (e *encryptWriter) flush(last bool) error {
+	nonce, err := streamNonce(e.noncePrefix, e.counter, last)
+	if err != nil {
+		return err
+	}
+	e.counter++
+
+	sealed := e.aead.Seal(nil, nonce, e.buf, e.header)
+	record := make([]byte, 4, 4+len(sealed))
+	binary.BigEndian.PutUint32(record, uint32(len(sealed)))
+	if _, err := e.w.Write(append(record, sealed...)); err != nil {
+		return err
+	}
+	e.buf = e.buf[:0]
+	return nil
+}
+
+type decryptReader struct {
+	r           *bufio.Reader
+	aead        cipher.AEAD
+	header      []byte
+	noncePrefix []byte
+	buf         []byte
+	counter     uint32
+	done        bool
+	err         error
+}
+
+// NewDecryptReader returns a reader which decrypts the encrypted stream read from r.
+// Read returns an error if any chunk fails authentication or the stream is truncated.
+func NewDecryptReader(r io.Reader, privateKey string) (io.Reader, error) {
+	eciesPrivateKey, err := newPrivateKeyFromHex(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("cannot read stream header: %w", err)
+	}
+	if Version(header[0]) != VersionStream {
+		return nil, fmt.Errorf("unsupported encryption version: %v", Version(header[0]))
+	}
+
+	ephemeralPubBytes := header[1 : 1+compressedKeySize]
+	ephemeralPub, err := newPublicKeyFromBytes(ephemeralPubBytes)
+	if err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(eciesPrivateKey.sharedSecret(ephemeralPub), ephemeralPubBytes, eciesPrivateKey.publicKey.Bytes(true), hkdfInfoStream)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		r:           bufio.NewReader(r),
+		aead:        aead,
+		header:      header,
+		noncePrefix: header[1+compressedKeySize:],
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.readChunk()
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *decryptReader) readChunk() error {
+	var length [4]byte
+	if _, err := io.ReadFull(d.r, length[:]); err != nil {
+		return fmt.Errorf("encrypted stream is truncated: %w", io.ErrUnexpectedEOF)
+	}
+	size := binary.BigEndian.Uint32(length[:])
+	if size > uint32(streamChunkSize+d.aead.Overhead()) {
+		return errors.New("encrypted chunk is too large")
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return fmt.Errorf("encrypted stream is truncated: %w", io.ErrUnexpectedEOF)
+	}
+
+	_, err := d.r.Peek(1)
+	last := err == io.EOF
+
+	nonce, err := streamNonce(d.noncePrefix, d.counter, last)
+	if err != nil {
+		return err
+	}
+	d.counter++
+
+	d.buf, err = d.aead.Open(sealed[:0], nonce, sealed, d.header)
+	if err != nil {
+		return errors.New("cannot authenticate encrypted chunk")
+	}
+	d.done = last
+	return nil
+}
+
+// streamNonce returns nonce prefix || chunk counter || last chunk flag
+func streamNonce(prefix []byte, counter uint32, last bool) ([]byte, error) {
+	if counter == ^uint32(0) {
+		return nil, errors.New("encrypted stream is too long")
+	}
+	nonce := make([]byte, gcmNonceSize)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[streamNoncePrefixSize:], counter)
+	if last {
+		nonce[gcmNonceSize-1] = 1
+	}
+	return nonce, nil
+}
+
+// EncryptFile Encrypt the file at path for publicKey and write the encrypted stream to w in the given encoding.
+func EncryptFile(w io.Writer, path, publicKey string, encoding Encoding) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encoder, err := newEncoder(w, encoding)
+	if err != nil {
+		return err
+	}
+	encryptor, err := NewEncryptWriter(encoder, publicKey)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(encryptor, f); err != nil {
+		return err
+	}
+	if err := encryptor.Close(); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// DecryptFile Decrypt the encrypted stream stored at path in the given encoding and write the plaintext to w.
+func DecryptFile(w io.Writer, path, privateKey string, encoding Encoding) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	decoder, err := newDecoder(f, encoding)
+	if err != nil {
+		return err
+	}
+	decryptor, err := NewDecryptReader(decoder, privateKey)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, decryptor)
+	return err
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func newEncoder(w io.Writer, encoding Encoding) (io.WriteCloser, error) {
+	switch encoding {
+	case EncodingRaw:
+		return nopWriteCloser{w}, nil
+	case EncodingHex:
+		return nopWriteCloser{hex.NewEncoder(w)}, nil
+	case EncodingBase64:
+		return base64.NewEncoder(base64.StdEncoding, w), nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %d", encoding)
+	}
+}
+
+func newDecoder(r io.Reader, encoding Encoding) (io.Reader, error) {
+	switch encoding {
+	case EncodingRaw:
+		return r, nil
+	case EncodingHex:
+		return hex.NewDecoder(r), nil
+	case EncodingBase64:
+		return base64.NewDecoder(base64.StdEncoding, r), nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %d", encoding)
+	}
+}
@@ -0,0 +1,74 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptStream(t *testing.T) {
+	for _, size := range []int{0, 1, streamChunkSize - 1, streamChunkSize, 2*streamChunkSize + 5} {
+		plaintext := make([]byte, size)
+		rand.Read(plaintext)
+
+		var encrypted bytes.Buffer
+		w, err := NewEncryptWriter(&encrypted, fakePublicKey)
+		assert.Nil(t, err)
+		_, err = w.Write(plaintext)
+		assert.Nil(t, err)
+		assert.Nil(t, w.Close())
+
+		r, err := NewDecryptReader(&encrypted, fakePrivateKey)
+		assert.Nil(t, err)
+		decrypted, err := io.ReadAll(r)
+		assert.Nil(t, err)
+		assert.True(t, bytes.Equal(plaintext, decrypted), "should be equal for size %d", size)
+	}
+}
+
+func TestDecryptStreamTruncated(t *testing.T) {
+	plaintext := make([]byte, 2*streamChunkSize+5)
+
+	var encrypted bytes.Buffer
+	w, _ := NewEncryptWriter(&encrypted, fakePublicKey)
+	w.Write(plaintext)
+	w.Close()
+
+	// drop the last chunk, the previous one is then read as last and fails authentication
+	b := encrypted.Bytes()
+	truncated := b[:len(b)-(4+5+16)]
+	r, _ := NewDecryptReader(bytes.NewReader(truncated), fakePrivateKey)
+	_, err := io.ReadAll(r)
+	assert.NotNil(t, err)
+
+	tampered := append([]byte{}, b...)
+	tampered[streamHeaderSize+10] ^= 0x01
+	r, _ = NewDecryptReader(bytes.NewReader(tampered), fakePrivateKey)
+	_, err = io.ReadAll(r)
+	assert.NotNil(t, err)
+}
+
+func TestEncryptFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "passport.jpg")
+	plaintext := make([]byte, streamChunkSize+100)
+	rand.Read(plaintext)
+	assert.Nil(t, os.WriteFile(path, plaintext, 0600))
+
+	for _, encoding := range []Encoding{EncodingRaw, EncodingHex, EncodingBase64} {
+		var encrypted bytes.Buffer
+		assert.Nil(t, EncryptFile(&encrypted, path, fakePublicKey, encoding))
+
+		encryptedPath := filepath.Join(dir, "passport.enc")
+		assert.Nil(t, os.WriteFile(encryptedPath, encrypted.Bytes(), 0600))
+
+		var decrypted bytes.Buffer
+		assert.Nil(t, DecryptFile(&decrypted, encryptedPath, fakePrivateKey, encoding))
+		assert.True(t, bytes.Equal(plaintext, decrypted.Bytes()), "should be equal")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/json"
+	"strings"
 
 	"github.com/iancoleman/orderedmap"
 )
@@ -54,15 +56,30 @@ func isOrderedMapEmpty(o *orderedmap.OrderedMap) bool {
 	return len(o.Keys()) == 0
 }
 
-func cloneOrderedMap(o *orderedmap.OrderedMap) (*orderedmap.OrderedMap, error) {
-	b, err := o.MarshalJSON()
-	if err != nil {
-		return nil, err
+// marshalWithEmptySignature returns the JSON that was signed for message, which is
+// message itself with its signature replaced by an empty string, without copying it.
+func marshalWithEmptySignature(message *orderedmap.OrderedMap) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range message.Keys() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`"` + strings.Replace(k, `"`, `\"`, -1) + `":`)
+		if k == "signature" {
+			buf.WriteString(`""`)
+			continue
+		}
+		v, _ := message.Get(k)
+		bValue, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(bValue)
 	}
-	clone := orderedmap.New()
-	err = clone.UnmarshalJSON(b)
-	if err != nil {
-		return nil, err
-	}
-	return clone, nil
+	buf.WriteByte('}')
+
+	// json.Marshal compacts and escapes the output of MarshalJSON; go through it
+	// as well so the bytes are identical to the ones produced by Sign.
+	return json.Marshal(json.RawMessage(buf.Bytes()))
 }
@@ -2,6 +2,7 @@ package crypto
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"testing"
 
 	"github.com/iancoleman/orderedmap"
@@ -73,15 +74,20 @@ func TestIsOrderedMapEmpty(t *testing.T) {
 	assert.Equal(t, isOrderedMapEmpty(o), false, "should be equal")
 }
 
-func TestCloneOrderedMap(t *testing.T) {
-	o := orderedmap.New()
-	o.Set("key", "value")
+func TestMarshalWithEmptySignature(t *testing.T) {
+	child := orderedmap.New()
+	child.Set("name", "<Wu & Xinli>")
 
-	clone, _ := cloneOrderedMap(o)
-
-	assert.NotSame(t, o, clone, "should not be equal")
+	o := orderedmap.New()
+	o.Set("transfer_id", "b97903fd")
+	o.Set("signature", "abcdef")
+	o.Set("child", child)
+	o.Set("amount", 1.234)
 
-	o.Set("key1", "value1")
+	b, err := marshalWithEmptySignature(o)
+	assert.Nil(t, err)
 
-	assert.Equal(t, len(clone.Keys()), 1, "should be equal")
+	o.Set("signature", "")
+	expected, _ := json.Marshal(o)
+	assert.Equal(t, string(expected), string(b), "should be equal")
 }
@@ -0,0 +1,168 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Amount is a non-negative decimal amount such as "4.51120135938784", kept exactly.
+// The zero value is 0.
+type Amount struct {
+	// unscaled is the amount multiplied by 10^scale
+	unscaled *big.Int
+	scale    int
+}
+
+// ParseAmount parses a plain decimal string as used in the amount field of a transaction.
+// Signs, exponents and separators are rejected. Trailing zeros are kept by String.
+func ParseAmount(s string) (Amount, error) {
+	integer, fraction, hasPoint := strings.Cut(s, ".")
+	if integer == "" || (hasPoint && fraction == "") || !isDigits(integer) || !isDigits(fraction) {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+	unscaled, _ := new(big.Int).SetString(integer+fraction, 10)
+	return Amount{unscaled: unscaled, scale: len(fraction)}, nil
+}
+
+// MustParseAmount is like ParseAmount but panics if s is invalid
+func MustParseAmount(s string) Amount {
+	a, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// NewAmountFromBaseUnits returns the amount of units of the smallest denomination,
+// e.g. satoshi with decimals 8 or wei with decimals 18
+func NewAmountFromBaseUnits(units *big.Int, decimals int) (Amount, error) {
+	if units.Sign() < 0 {
+		return Amount{}, errors.New("amount must not be negative")
+	}
+	if decimals < 0 {
+		return Amount{}, fmt.Errorf("invalid decimals %d", decimals)
+	}
+	return Amount{unscaled: new(big.Int).Set(units), scale: decimals}.normalize(), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (a Amount) value() *big.Int {
+	if a.unscaled == nil {
+		return new(big.Int)
+	}
+	return a.unscaled
+}
+
+// normalize drops trailing fractional zeros
+func (a Amount) normalize() Amount {
+	unscaled, scale := new(big.Int).Set(a.value()), a.scale
+	ten, mod := big.NewInt(10), new(big.Int)
+	for scale > 0 {
+		quo, _ := new(big.Int).QuoRem(unscaled, ten, mod)
+		if mod.Sign() != 0 {
+			break
+		}
+		unscaled, scale = quo, scale-1
+	}
+	return Amount{unscaled: unscaled, scale: scale}
+}
+
+// String formats the amount as a plain decimal, never in scientific notation
+func (a Amount) String() string {
+	digits := a.value().String()
+	if a.scale == 0 {
+		return digits
+	}
+	if len(digits) <= a.scale {
+		digits = strings.Repeat("0", a.scale-len(digits)+1) + digits
+	}
+	return digits[:len(digits)-a.scale] + "." + digits[len(digits)-a.scale:]
+}
+
+// Decimals returns the number of significant decimal places, ignoring trailing zeros
+func (a Amount) Decimals() int {
+	return a.normalize().scale
+}
+
+// IsZero reports whether the amount is 0
+func (a Amount) IsZero() bool {
+	return a.value().Sign() == 0
+}
+
+// Cmp compares a and b and returns -1, 0 or +1
+func (a Amount) Cmp(b Amount) int {
+	return a.Rat().Cmp(b.Rat())
+}
+
+// Rat returns the amount as a rational number, e.g. to apply an exchange rate
+func (a Amount) Rat() *big.Rat {
+	denominator := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(a.scale)), nil)
+	return new(big.Rat).SetFrac(a.value(), denominator)
+}
+
+// BaseUnits returns the amount in units of the smallest denomination, e.g. satoshi with decimals 8.
+// It returns an error if the amount has more decimal places than decimals.
+func (a Amount) BaseUnits(decimals int) (*big.Int, error) {
+	if decimals < 0 {
+		return nil, fmt.Errorf("invalid decimals %d", decimals)
+	}
+	normalized := a.normalize()
+	if normalized.scale > decimals {
+		return nil, fmt.Errorf("amount %s exceeds %d decimal places", a, decimals)
+	}
+	multiplier := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals-normalized.scale)), nil)
+	return multiplier.Mul(multiplier, normalized.value()), nil
+}
+
+// MarshalText implements encoding.TextMarshaler so amounts are encoded as JSON strings
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (a *Amount) UnmarshalText(text []byte) error {
+	parsed, err := ParseAmount(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// CheckAmount checks that amount does not exceed the decimals of the currency.
+// Amounts of currencies with UnknownDecimals are not checked.
+func (c Currency) CheckAmount(amount Amount) error {
+	if c.Decimals == UnknownDecimals {
+		return nil
+	}
+	if amount.Decimals() > c.Decimals {
+		return fmt.Errorf("amount %s of %s exceeds %d decimal places", amount, c.ID, c.Decimals)
+	}
+	return nil
+}
+
+// ValidateAmount parses amount and checks it against the decimals of the currency id in the registry
+func (r *Registry) ValidateAmount(id, amount string) (Amount, error) {
+	if err := r.Validate(id); err != nil {
+		return Amount{}, err
+	}
+	parsed, err := ParseAmount(amount)
+	if err != nil {
+		return Amount{}, err
+	}
+	c, _ := r.LookupString(id)
+	if err := c.CheckAmount(parsed); err != nil {
+		return Amount{}, err
+	}
+	return parsed, nil
+}
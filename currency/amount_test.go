@@ -0,0 +1,102 @@
+package currency
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAmount(t *testing.T) {
+	var tests = []struct {
+		input    string
+		decimals int
+	}{
+		{"4.51120135938784", 14},
+		{"4.51120", 4},
+		{"0", 0},
+		{"0.00000001", 8},
+		{"100", 0},
+		{"123456789012345678901234567890.000000000000000001", 18},
+	}
+	for _, test := range tests {
+		a, err := ParseAmount(test.input)
+		assert.Nil(t, err)
+		assert.Equal(t, test.input, a.String())
+		assert.Equal(t, test.decimals, a.Decimals())
+	}
+
+	for _, invalid := range []string{"", "-1", "+1", "1e-8", ".5", "5.", "1,000", "0x10", "1.2.3", " 1"} {
+		_, err := ParseAmount(invalid)
+		assert.NotNil(t, err, "%q should be invalid", invalid)
+	}
+}
+
+func TestAmountBaseUnits(t *testing.T) {
+	a := MustParseAmount("4.51120")
+	units, err := a.BaseUnits(8)
+	assert.Nil(t, err)
+	assert.Equal(t, "451120000", units.String())
+
+	units, err = MustParseAmount("1.000000000000000001").BaseUnits(18)
+	assert.Nil(t, err)
+	assert.Equal(t, "1000000000000000001", units.String())
+
+	_, err = MustParseAmount("0.000000001").BaseUnits(8)
+	assert.EqualError(t, err, "amount 0.000000001 exceeds 8 decimal places")
+
+	wei, _ := new(big.Int).SetString("1000000000000000001", 10)
+	b, err := NewAmountFromBaseUnits(wei, 18)
+	assert.Nil(t, err)
+	assert.Equal(t, "1.000000000000000001", b.String())
+
+	b, err = NewAmountFromBaseUnits(big.NewInt(1), 8)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.00000001", b.String())
+
+	b, err = NewAmountFromBaseUnits(big.NewInt(150000000), 8)
+	assert.Nil(t, err)
+	assert.Equal(t, "1.5", b.String())
+
+	_, err = NewAmountFromBaseUnits(big.NewInt(-1), 8)
+	assert.NotNil(t, err)
+}
+
+func TestAmountCompareAndJSON(t *testing.T) {
+	assert.Equal(t, 0, MustParseAmount("4.5").Cmp(MustParseAmount("4.50000")))
+	assert.Equal(t, -1, MustParseAmount("4.5").Cmp(MustParseAmount("4.51")))
+	assert.True(t, Amount{}.IsZero())
+	assert.Equal(t, "0", Amount{}.String())
+
+	var transaction struct {
+		Amount Amount `json:"amount"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(`{"amount":"4.51120135938784"}`), &transaction))
+	b, err := json.Marshal(transaction)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"amount":"4.51120135938784"}`, string(b))
+}
+
+func TestRegistryValidateAmount(t *testing.T) {
+	r := NewRegistry(nil)
+	r.Set(
+		Currency{ID: ID{CoinType: CoinTypeBTC}, Symbol: "BTC", IsActive: true, Decimals: 8},
+		Currency{ID: ID{CoinType: CoinTypeETH, Contract: "dac17f958d2ee523a2201e9c1faad1b2e4f2ae28"}, Symbol: "USDT", IsActive: true, Decimals: UnknownDecimals},
+	)
+
+	a, err := r.ValidateAmount("sygna:0x80000000", "0.12345678")
+	assert.Nil(t, err)
+	assert.Equal(t, "0.12345678", a.String())
+
+	_, err = r.ValidateAmount("sygna:0x80000000", "0.123456789")
+	assert.EqualError(t, err, "amount 0.123456789 of sygna:0x80000000 exceeds 8 decimal places")
+
+	_, err = r.ValidateAmount("sygna:0x8000003c.dac17f958d2ee523a2201e9c1faad1b2e4f2ae28", "0.123456789")
+	assert.Nil(t, err)
+
+	_, err = r.ValidateAmount("sygna:0x80000090", "1")
+	assert.NotNil(t, err)
+	_, err = r.ValidateAmount("sygna:0x80000000", "1e-8")
+	assert.NotNil(t, err)
+}
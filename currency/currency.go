@@ -0,0 +1,127 @@
+/*
+Package currency parses Sygna Bridge currency ids such as sygna:0x80000090 and
+keeps a registry of supported currencies populated from GetCurrencies.
+
+A currency id is "sygna:" followed by the hardened SLIP-44 coin type in hex,
+optionally followed by "." and a token contract, e.g.
+sygna:0x8000003c.dac17f958d2ee523a2201e9c1faad1b2e4f2ae28 for USDT on Ethereum.
+*/
+package currency
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	scheme       = "sygna:"
+	hardenedFlag = 0x80000000
+)
+
+// SLIP-44 coin types of well known chains
+const (
+	CoinTypeBTC  uint32 = 0
+	CoinTypeLTC  uint32 = 2
+	CoinTypeDOGE uint32 = 3
+	CoinTypeDASH uint32 = 5
+	CoinTypeETH  uint32 = 60
+	CoinTypeETC  uint32 = 61
+	CoinTypeXRP  uint32 = 144
+	CoinTypeBCH  uint32 = 145
+	CoinTypeXLM  uint32 = 148
+	CoinTypeEOS  uint32 = 194
+	CoinTypeTRX  uint32 = 195
+	CoinTypeBSV  uint32 = 236
+	CoinTypeSOL  uint32 = 501
+	CoinTypeBNB  uint32 = 714
+	CoinTypeADA  uint32 = 1815
+)
+
+// nativeDecimals are the decimals of the native coin of well known chains
+var nativeDecimals = map[uint32]int{
+	CoinTypeBTC:  8,
+	CoinTypeLTC:  8,
+	CoinTypeDOGE: 8,
+	CoinTypeDASH: 8,
+	CoinTypeETH:  18,
+	CoinTypeETC:  18,
+	CoinTypeXRP:  6,
+	CoinTypeBCH:  8,
+	CoinTypeXLM:  7,
+	CoinTypeEOS:  4,
+	CoinTypeTRX:  6,
+	CoinTypeBSV:  8,
+	CoinTypeSOL:  9,
+	CoinTypeBNB:  8,
+	CoinTypeADA:  6,
+}
+
+// ID is a parsed Sygna Bridge currency id
+type ID struct {
+	// CoinType is the SLIP-44 coin type of the chain, without the hardened flag
+	CoinType uint32
+	// Contract is the token contract on the chain, empty for the native coin
+	Contract string
+}
+
+// NewID returns the currency id of a coin type and optional token contract
+func NewID(coinType uint32, contract string) ID {
+	return ID{CoinType: coinType, Contract: contract}
+}
+
+// ParseID parses a currency id such as sygna:0x80000090
+func ParseID(s string) (ID, error) {
+	if !strings.HasPrefix(s, scheme) {
+		return ID{}, fmt.Errorf("invalid currency id %q: must start with %q", s, scheme)
+	}
+	coin, contract, isToken := strings.Cut(strings.TrimPrefix(s, scheme), ".")
+	if isToken && contract == "" {
+		return ID{}, fmt.Errorf("invalid currency id %q: empty token contract", s)
+	}
+	if !strings.HasPrefix(coin, "0x") {
+		return ID{}, fmt.Errorf("invalid currency id %q: coin type must be hex", s)
+	}
+	value, err := strconv.ParseUint(coin[2:], 16, 32)
+	if err != nil {
+		return ID{}, fmt.Errorf("invalid currency id %q: %w", s, err)
+	}
+	if value&hardenedFlag == 0 {
+		return ID{}, fmt.Errorf("invalid currency id %q: coin type must be hardened", s)
+	}
+	return ID{CoinType: uint32(value) &^ hardenedFlag, Contract: contract}, nil
+}
+
+// String returns the currency id in the form Sygna Bridge expects
+func (id ID) String() string {
+	s := fmt.Sprintf("%s0x%08x", scheme, id.CoinType|hardenedFlag)
+	if id.Contract != "" {
+		s += "." + id.Contract
+	}
+	return s
+}
+
+// IsToken reports whether the id refers to a token rather than the native coin
+func (id ID) IsToken() bool {
+	return id.Contract != ""
+}
+
+// Chain returns the id of the native coin of the chain the currency lives on
+func (id ID) Chain() ID {
+	return ID{CoinType: id.CoinType}
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (id ID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (id *ID) UnmarshalText(b []byte) error {
+	parsed, err := ParseID(string(b))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
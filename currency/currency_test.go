@@ -0,0 +1,69 @@
+package currency
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/iancoleman/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseID(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected ID
+	}{
+		{"sygna:0x80000090", ID{CoinType: CoinTypeXRP}},
+		{"sygna:0x80000000", ID{CoinType: CoinTypeBTC}},
+		{"sygna:0x8000003c.dac17f958d2ee523a2201e9c1faad1b2e4f2ae28", ID{CoinType: CoinTypeETH, Contract: "dac17f958d2ee523a2201e9c1faad1b2e4f2ae28"}},
+	}
+	for _, test := range tests {
+		id, err := ParseID(test.input)
+		assert.Nil(t, err)
+		assert.Equal(t, test.expected, id)
+		assert.Equal(t, test.input, id.String())
+	}
+
+	for _, invalid := range []string{"0x80000090", "sygna:80000090", "sygna:0x00000090", "sygna:0x8000003c.", "sygna:0xzz"} {
+		_, err := ParseID(invalid)
+		assert.NotNil(t, err, "%s should be invalid", invalid)
+	}
+}
+
+type fakeFetcher struct {
+	coins string
+	err   error
+}
+
+func (f *fakeFetcher) GetCurrencies(queryParams *orderedmap.OrderedMap) ([]*orderedmap.OrderedMap, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var coins []*orderedmap.OrderedMap
+	for _, coin := range []string{f.coins} {
+		o := orderedmap.New()
+		o.UnmarshalJSON([]byte(coin))
+		coins = append(coins, o)
+	}
+	return coins, nil
+}
+
+func TestRegistry(t *testing.T) {
+	fetcher := &fakeFetcher{coins: `{"currency_id":"sygna:0x80000090","currency_name":"XRP","currency_symbol":"XRP","is_active":true,"addr_extra_info":["tag"]}`}
+	registry := NewRegistry(fetcher)
+	assert.NotNil(t, registry.Validate("sygna:0x80000090"), "empty registry should not know any currency")
+
+	assert.Nil(t, registry.Refresh())
+	c, ok := registry.LookupString("sygna:0x80000090")
+	assert.True(t, ok)
+	assert.Equal(t, 6, c.Decimals)
+	assert.Equal(t, []string{"tag"}, c.AddrExtraInfo)
+	assert.Equal(t, []Currency{c}, registry.LookupSymbol("xrp"))
+	assert.Nil(t, registry.Validate("sygna:0x80000090"))
+	assert.NotNil(t, registry.Validate("sygna:0x80000000"))
+
+	fetcher.err = errors.New("unavailable")
+	assert.NotNil(t, registry.Refresh())
+	_, ok = registry.LookupString("sygna:0x80000090")
+	assert.True(t, ok, "failed refresh should keep the previous currencies")
+}
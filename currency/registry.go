@@ -0,0 +1,193 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iancoleman/orderedmap"
+)
+
+// UnknownDecimals is the Decimals of a currency whose precision is not known
+const UnknownDecimals = -1
+
+// Currency is a currency supported by Sygna Bridge
+type Currency struct {
+	ID       ID
+	Name     string
+	Symbol   string
+	IsActive bool
+	// AddrExtraInfo lists the extra info keys addresses of the currency take, e.g. "tag"
+	AddrExtraInfo []string
+	// Decimals is the number of decimal places of the currency or UnknownDecimals
+	Decimals int
+}
+
+// Fetcher fetches the supported currencies; *bridgeutil.BridgeAPI implements it
+type Fetcher interface {
+	GetCurrencies(queryParams *orderedmap.OrderedMap) ([]*orderedmap.OrderedMap, error)
+}
+
+// Registry is a goroutine-safe set of currencies looked up by id or symbol
+type Registry struct {
+	fetcher  Fetcher
+	mu       sync.RWMutex
+	byID     map[string]*Currency
+	bySymbol map[string][]*Currency
+}
+
+// NewRegistry returns an empty registry populated by Refresh from fetcher, which may be nil
+func NewRegistry(fetcher Fetcher) *Registry {
+	return &Registry{
+		fetcher:  fetcher,
+		byID:     map[string]*Currency{},
+		bySymbol: map[string][]*Currency{},
+	}
+}
+
+// Refresh replaces the currencies of the registry with the ones returned by GetCurrencies
+func (r *Registry) Refresh() error {
+	if r.fetcher == nil {
+		return fmt.Errorf("currency registry has no fetcher")
+	}
+	coins, err := r.fetcher.GetCurrencies(nil)
+	if err != nil {
+		return err
+	}
+
+	currencies := make([]Currency, 0, len(coins))
+	for _, coin := range coins {
+		c, err := fromOrderedMap(coin)
+		if err != nil {
+			return err
+		}
+		currencies = append(currencies, c)
+	}
+	r.Set(currencies...)
+	return nil
+}
+
+// RefreshEvery calls Refresh every interval until ctx is done; errors are passed to onError, which may be nil
+func (r *Registry) RefreshEvery(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Refresh(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Set replaces the currencies of the registry
+func (r *Registry) Set(currencies ...Currency) {
+	byID := make(map[string]*Currency, len(currencies))
+	bySymbol := make(map[string][]*Currency, len(currencies))
+	for i := range currencies {
+		c := &currencies[i]
+		byID[c.ID.String()] = c
+		symbol := strings.ToUpper(c.Symbol)
+		bySymbol[symbol] = append(bySymbol[symbol], c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byID, r.bySymbol = byID, bySymbol
+}
+
+// Lookup returns the currency of a currency id
+func (r *Registry) Lookup(id ID) (Currency, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.byID[id.String()]
+	if !ok {
+		return Currency{}, false
+	}
+	return *c, true
+}
+
+// LookupString parses a currency id and returns its currency
+func (r *Registry) LookupString(id string) (Currency, bool) {
+	parsed, err := ParseID(id)
+	if err != nil {
+		return Currency{}, false
+	}
+	return r.Lookup(parsed)
+}
+
+// LookupSymbol returns every currency with the symbol, case insensitively;
+// a symbol such as USDT may exist on several chains
+func (r *Registry) LookupSymbol(symbol string) []Currency {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	matches := r.bySymbol[strings.ToUpper(symbol)]
+	result := make([]Currency, len(matches))
+	for i, c := range matches {
+		result[i] = *c
+	}
+	return result
+}
+
+// Validate checks that id is a well formed currency id of an active currency in the registry,
+// e.g. before calling PostPermissionRequest
+func (r *Registry) Validate(id string) error {
+	parsed, err := ParseID(id)
+	if err != nil {
+		return err
+	}
+	c, ok := r.Lookup(parsed)
+	if !ok {
+		return fmt.Errorf("currency %s is not supported", id)
+	}
+	if !c.IsActive {
+		return fmt.Errorf("currency %s is not active", id)
+	}
+	return nil
+}
+
+type supportedCoin struct {
+	CurrencyID     string   `json:"currency_id"`
+	CurrencyName   string   `json:"currency_name"`
+	CurrencySymbol string   `json:"currency_symbol"`
+	IsActive       bool     `json:"is_active"`
+	AddrExtraInfo  []string `json:"addr_extra_info"`
+	Decimals       *int     `json:"decimals"`
+}
+
+func fromOrderedMap(o *orderedmap.OrderedMap) (Currency, error) {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return Currency{}, err
+	}
+	var coin supportedCoin
+	if err := json.Unmarshal(b, &coin); err != nil {
+		return Currency{}, err
+	}
+	id, err := ParseID(coin.CurrencyID)
+	if err != nil {
+		return Currency{}, err
+	}
+
+	decimals := UnknownDecimals
+	if coin.Decimals != nil {
+		decimals = *coin.Decimals
+	} else if d, ok := nativeDecimals[id.CoinType]; ok && !id.IsToken() {
+		decimals = d
+	}
+
+	return Currency{
+		ID:            id,
+		Name:          coin.CurrencyName,
+		Symbol:        coin.CurrencySymbol,
+		IsActive:      coin.IsActive,
+		AddrExtraInfo: coin.AddrExtraInfo,
+		Decimals:      decimals,
+	}, nil
+}
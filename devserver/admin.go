@@ -0,0 +1,110 @@
+package devserver
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// adminRoutes serves the unauthenticated admin endpoints:
+//
+//	GET    /admin/central-key                    the central public key
+//	GET    /admin/vasps                          the registered VASPs
+//	GET    /admin/transfers                      every transfer
+//	DELETE /admin/transfers                      forget every transfer
+//	GET    /admin/transfers/{id}                 a transfer
+//	PATCH  /admin/transfers/{id}                 change status, reject_code, reject_message or txid
+//	POST   /admin/transfers/{id}/redeliver       resend the permission request to the beneficiary
+//
+// Setting the status of a pending transfer to ACCEPTED or REJECTED notifies the originator
+// as if the beneficiary had answered.
+func (s *Server) adminRoutes() {
+	s.mux.HandleFunc("GET /admin/central-key", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"public_key": s.centralPublicKey})
+	})
+	s.mux.HandleFunc("GET /admin/vasps", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		vasps := make([]VASP, len(s.vaspOrder))
+		for i, code := range s.vaspOrder {
+			vasps[i] = *s.vasps[code]
+		}
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, vasps)
+	})
+	s.mux.HandleFunc("GET /admin/transfers", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.Transfers())
+	})
+	s.mux.HandleFunc("DELETE /admin/transfers", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.transfers, s.order = map[string]*Transfer{}, nil
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, okBody())
+	})
+	s.mux.HandleFunc("GET /admin/transfers/{id}", func(w http.ResponseWriter, r *http.Request) {
+		t, ok := s.Transfer(r.PathValue("id"))
+		if !ok {
+			writeJSON(w, http.StatusNotFound, errorBody("transfer not found"))
+			return
+		}
+		writeJSON(w, http.StatusOK, t)
+	})
+	s.mux.HandleFunc("PATCH /admin/transfers/{id}", s.handleAdminUpdate)
+	s.mux.HandleFunc("POST /admin/transfers/{id}/redeliver", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := s.Transfer(r.PathValue("id")); !ok {
+			writeJSON(w, http.StatusNotFound, errorBody("transfer not found"))
+			return
+		}
+		s.forwardPermissionRequest(r.PathValue("id"))
+		writeJSON(w, http.StatusOK, okBody())
+	})
+}
+
+// TransferUpdate is the body of PATCH /admin/transfers/{id}; nil fields are unchanged
+type TransferUpdate struct {
+	Status        *string `json:"status"`
+	RejectCode    *string `json:"reject_code"`
+	RejectMessage *string `json:"reject_message"`
+	TxID          *string `json:"txid"`
+}
+
+func (s *Server) handleAdminUpdate(w http.ResponseWriter, r *http.Request) {
+	var update TransferUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorBody("body must be a JSON object"))
+		return
+	}
+	if update.Status != nil {
+		switch *update.Status {
+		case StatusPending, StatusAccepted, StatusRejected, StatusCanceled:
+		default:
+			writeJSON(w, http.StatusBadRequest, errorBody("unknown status "+*update.Status))
+			return
+		}
+	}
+
+	s.mu.Lock()
+	t, ok := s.transfers[r.PathValue("id")]
+	if !ok {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusNotFound, errorBody("transfer not found"))
+		return
+	}
+	answered := update.Status != nil && t.Status == StatusPending &&
+		(*update.Status == StatusAccepted || *update.Status == StatusRejected)
+	for field, value := range map[*string]*string{
+		&t.Status:        update.Status,
+		&t.RejectCode:    update.RejectCode,
+		&t.RejectMessage: update.RejectMessage,
+		&t.TxID:          update.TxID,
+	} {
+		if value != nil {
+			*field = *value
+		}
+	}
+	transfer := t.copy()
+	s.mu.Unlock()
+
+	if answered {
+		s.forwardPermission(transfer.TransferID)
+	}
+	writeJSON(w, http.StatusOK, transfer)
+}
@@ -0,0 +1,413 @@
+/*
+Package devserver is an in-memory stand-in for Sygna Bridge, serving the v2
+endpoints BridgeAPI calls, for local development and QA.
+
+The server signs its responses and callbacks with its own central key; pass
+CentralPublicKey to Verify in place of SygnaBridgeTestPubkey. Requests are
+authenticated by X-Api-Key and their signatures verified with the public key of
+the seeded VASP.
+
+Callbacks are posted in the background:
+
+  - permission requests go to the callback_permission_request_url of the beneficiary as
+    {"transfer_id": ..., "data": <signed data of the originator>, "signature": ...}
+  - permissions go to the callback_url of the originator as
+    {"transfer_id": ..., "permission_status": ..., "reject_code": ..., "reject_message": ..., "signature": ...}
+  - txids go to the callback_txid_url of the beneficiary as
+    {"transfer_id": ..., "txid": ..., "signature": ...}
+
+where signature is made with the central key. Transfers can be inspected and
+changed under /admin/transfers.
+*/
+package devserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	bridgeutil "github.com/CoolBitX-Technology/sygna-bridge-util-go"
+	"github.com/iancoleman/orderedmap"
+)
+
+// Config configures a Server
+type Config struct {
+	// CentralPrivateKey signs responses and callbacks; a key is generated when empty
+	CentralPrivateKey string
+	Seed              Seed
+	// Client posts callbacks, http.DefaultClient when nil
+	Client *http.Client
+	// SkipSignatureVerification accepts requests whatever their signature
+	SkipSignatureVerification bool
+}
+
+// Server is a fake Sygna Bridge; it implements http.Handler
+type Server struct {
+	centralPrivateKey string
+	centralPublicKey  string
+	client            *http.Client
+	skipVerification  bool
+	mux               *http.ServeMux
+	wg                sync.WaitGroup
+
+	mu         sync.Mutex
+	vasps      map[string]*VASP
+	vaspOrder  []string
+	currencies []Currency
+	transfers  map[string]*Transfer
+	order      []string
+}
+
+// New returns a server with the VASPs and currencies of the seed
+func New(cfg Config) (*Server, error) {
+	s := &Server{
+		centralPrivateKey: cfg.CentralPrivateKey,
+		client:            cfg.Client,
+		skipVerification:  cfg.SkipSignatureVerification,
+		vasps:             map[string]*VASP{},
+		currencies:        cfg.Seed.Currencies,
+		transfers:         map[string]*Transfer{},
+	}
+	var err error
+	if s.centralPrivateKey == "" {
+		s.centralPrivateKey, s.centralPublicKey, err = bridgeutil.GenerateKeyPair()
+	} else {
+		s.centralPublicKey, err = bridgeutil.PublicKeyFromPrivateKey(s.centralPrivateKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid central key: %w", err)
+	}
+	if s.client == nil {
+		s.client = http.DefaultClient
+	}
+	if len(s.currencies) == 0 {
+		s.currencies = DefaultCurrencies
+	}
+	for i := range cfg.Seed.VASPs {
+		v := cfg.Seed.VASPs[i]
+		if _, ok := s.vasps[v.VASPCode]; ok {
+			return nil, fmt.Errorf("duplicate vasp %s", v.VASPCode)
+		}
+		s.vasps[v.VASPCode] = &v
+		s.vaspOrder = append(s.vaspOrder, v.VASPCode)
+	}
+	s.routes()
+	return s, nil
+}
+
+// CentralPublicKey returns the public key responses and callbacks are signed with
+func (s *Server) CentralPublicKey() string {
+	return s.centralPublicKey
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Wait blocks until the pending callbacks are delivered
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Server) routes() {
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /v2/bridge/vasp", s.auth(s.handleVASPList))
+	s.mux.HandleFunc("GET /v2/bridge/vasp/detail/{code}", s.auth(s.handleVASPDetail))
+	s.mux.HandleFunc("GET /v2/bridge/vasp/usage", s.auth(s.handleVASPUsage))
+	s.mux.HandleFunc("POST /v2/bridge/vasp/beneficiary-endpoint-url", s.auth(s.handleBeneficiaryEndpointURL))
+	s.mux.HandleFunc("POST /v2/bridge/vasp/server-status", s.auth(s.handleServerStatus))
+	s.mux.HandleFunc("POST /v2/bridge/vasp/beneficiary-checking-rule", s.auth(s.handleCheckingRule))
+	s.mux.HandleFunc("GET /v2/bridge/transaction/currencies", s.auth(s.handleCurrencies))
+	s.mux.HandleFunc("GET /v2/bridge/transaction/status", s.auth(s.handleStatus))
+	s.mux.HandleFunc("POST /v2/bridge/transaction/permission-request", s.auth(s.handlePermissionRequest))
+	s.mux.HandleFunc("POST /v2/bridge/transaction/permission", s.auth(s.handlePermission))
+	s.mux.HandleFunc("POST /v2/bridge/transaction/txid", s.auth(s.handleTxID))
+	s.mux.HandleFunc("POST /v2/bridge/transaction/retry", s.auth(s.handleRetry))
+	s.mux.HandleFunc("POST /v2/bridge/transaction/cancel", s.auth(s.handleCancel))
+	s.mux.HandleFunc("POST /v2/bridge/transaction/cdd-request", s.auth(s.handleCDD("cdd-request")))
+	s.mux.HandleFunc("POST /v2/bridge/transaction/cdd", s.auth(s.handleCDD("cdd")))
+	s.mux.HandleFunc("POST /v2/bridge/transaction/address-validation", s.auth(s.handleAddressValidation))
+	s.mux.HandleFunc("POST /v2/bridge/wallet-address-filter", s.auth(s.handleWalletAddressFilter))
+	s.adminRoutes()
+}
+
+// httpError is returned by handlers to respond with a status code
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func errorf(status int, format string, a ...interface{}) error {
+	return &httpError{status: status, message: fmt.Sprintf(format, a...)}
+}
+
+// handler handles a request authenticated as vasp and returns the response body
+type handler func(vasp *VASP, r *http.Request) (interface{}, error)
+
+func (s *Server) auth(h handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vasp := s.vaspByAPIKey(r.Header.Get("X-Api-Key"))
+		if vasp == nil {
+			writeJSON(w, http.StatusUnauthorized, errorBody("invalid api key"))
+			return
+		}
+		response, err := h(vasp, r)
+		if err != nil {
+			status := http.StatusInternalServerError
+			var httpErr *httpError
+			if errors.As(err, &httpErr) {
+				status = httpErr.status
+			}
+			writeJSON(w, status, errorBody(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// vaspByAPIKey returns a copy of the VASP of the api key
+func (s *Server) vaspByAPIKey(apiKey string) *VASP {
+	if apiKey == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, code := range s.vaspOrder {
+		v := s.vasps[code]
+		if v.APIKey == apiKey || (v.APIKey == "" && v.VASPCode == apiKey) {
+			copied := *v
+			return &copied
+		}
+	}
+	return nil
+}
+
+func (s *Server) vasp(code string) (VASP, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.vasps[code]
+	if !ok {
+		return VASP{}, false
+	}
+	return *v, true
+}
+
+func errorBody(message string) *orderedmap.OrderedMap {
+	o := orderedmap.New()
+	o.Set("error", message)
+	return o
+}
+
+func okBody() *orderedmap.OrderedMap {
+	o := orderedmap.New()
+	o.Set("status", "OK")
+	return o
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// readBody decodes a JSON object request body
+func readBody(r *http.Request) (*orderedmap.OrderedMap, error) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	o := orderedmap.New()
+	if err := o.UnmarshalJSON(b); err != nil {
+		return nil, errorf(http.StatusBadRequest, "body must be a JSON object")
+	}
+	return o, nil
+}
+
+// verify checks the signature of message by the VASP
+func (s *Server) verify(vasp *VASP, message *orderedmap.OrderedMap) error {
+	if s.skipVerification {
+		return nil
+	}
+	valid, err := bridgeutil.Verify(message, vasp.VASPPubkey)
+	if err != nil || !valid {
+		return errorf(http.StatusBadRequest, "invalid signature of %s", vasp.VASPCode)
+	}
+	return nil
+}
+
+// sign signs message with the central key
+func (s *Server) sign(message *orderedmap.OrderedMap) *orderedmap.OrderedMap {
+	if err := bridgeutil.Sign(message, s.centralPrivateKey); err != nil {
+		panic(err)
+	}
+	return message
+}
+
+func getString(o *orderedmap.OrderedMap, key string) string {
+	v, _ := o.Get(key)
+	s, _ := v.(string)
+	return s
+}
+
+func getObject(o *orderedmap.OrderedMap, key string) *orderedmap.OrderedMap {
+	v, _ := o.Get(key)
+	switch m := v.(type) {
+	case *orderedmap.OrderedMap:
+		return m
+	case orderedmap.OrderedMap:
+		return &m
+	default:
+		return nil
+	}
+}
+
+func (s *Server) handleVASPList(_ *VASP, r *http.Request) (interface{}, error) {
+	s.mu.Lock()
+	vasps := make([]*orderedmap.OrderedMap, 0, len(s.vaspOrder))
+	for _, code := range s.vaspOrder {
+		vasps = append(vasps, vaspData(s.vasps[code]))
+	}
+	s.mu.Unlock()
+
+	response := orderedmap.New()
+	response.Set("vasp_data", vasps)
+	return s.sign(response), nil
+}
+
+func vaspData(v *VASP) *orderedmap.OrderedMap {
+	o := orderedmap.New()
+	o.Set("vasp_code", v.VASPCode)
+	o.Set("vasp_name", v.VASPName)
+	o.Set("vasp_pubkey", v.VASPPubkey)
+	return o
+}
+
+func (s *Server) handleVASPDetail(_ *VASP, r *http.Request) (interface{}, error) {
+	v, ok := s.vasp(r.PathValue("code"))
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "vasp %s not found", r.PathValue("code"))
+	}
+	response := orderedmap.New()
+	response.Set("vasp_data", vaspData(&v))
+	return s.sign(response), nil
+}
+
+func (s *Server) handleVASPUsage(vasp *VASP, r *http.Request) (interface{}, error) {
+	var startAt, endAt int64
+	if _, err := fmt.Sscan(r.URL.Query().Get("start_at"), &startAt); err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid start_at")
+	}
+	if _, err := fmt.Sscan(r.URL.Query().Get("end_at"), &endAt); err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid end_at")
+	}
+
+	count := 0
+	for _, t := range s.Transfers() {
+		at := t.CreatedAt.Unix()
+		if at >= startAt && at <= endAt && (t.OriginatorVASPCode == vasp.VASPCode || t.BeneficiaryVASPCode == vasp.VASPCode) {
+			count++
+		}
+	}
+	usage := orderedmap.New()
+	usage.Set("vasp_code", vasp.VASPCode)
+	usage.Set("transfer_count", count)
+	response := orderedmap.New()
+	response.Set("data", []*orderedmap.OrderedMap{usage})
+	return s.sign(response), nil
+}
+
+func (s *Server) handleBeneficiaryEndpointURL(vasp *VASP, r *http.Request) (interface{}, error) {
+	body, err := s.readSignedBody(vasp, r)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := s.vasps[vasp.VASPCode]
+	for key, field := range map[string]*string{
+		"callback_permission_request_url": &v.CallbackPermissionRequestURL,
+		"callback_txid_url":               &v.CallbackTxIDURL,
+		"callback_validate_addr_url":      &v.CallbackValidateAddrURL,
+	} {
+		if url := getString(body, key); url != "" {
+			*field = url
+		}
+	}
+	return okBody(), nil
+}
+
+func (s *Server) handleServerStatus(vasp *VASP, r *http.Request) (interface{}, error) {
+	body, err := s.readSignedBody(vasp, r)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vasps[vasp.VASPCode].ServerStatus = body
+	return okBody(), nil
+}
+
+func (s *Server) handleCheckingRule(vasp *VASP, r *http.Request) (interface{}, error) {
+	body, err := s.readSignedBody(vasp, r)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vasps[vasp.VASPCode].CheckingRule = body
+	return okBody(), nil
+}
+
+// readOwnBody decodes a request body of the VASP, which must be the vasp_code of the body if set
+func readOwnBody(vasp *VASP, r *http.Request) (*orderedmap.OrderedMap, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if code := getString(body, "vasp_code"); code != "" && code != vasp.VASPCode {
+		return nil, errorf(http.StatusForbidden, "api key of %s cannot act for %s", vasp.VASPCode, code)
+	}
+	return body, nil
+}
+
+// readSignedBody decodes a request body signed by the VASP, see readOwnBody
+func (s *Server) readSignedBody(vasp *VASP, r *http.Request) (*orderedmap.OrderedMap, error) {
+	body, err := readOwnBody(vasp, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verify(vasp, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (s *Server) handleCurrencies(_ *VASP, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	coins := []Currency{}
+	s.mu.Lock()
+	for _, c := range s.currencies {
+		if (q.Get("currency_id") == "" || q.Get("currency_id") == c.CurrencyID) &&
+			(q.Get("currency_name") == "" || q.Get("currency_name") == c.CurrencyName) &&
+			(q.Get("currency_symbol") == "" || q.Get("currency_symbol") == c.CurrencySymbol) {
+			coins = append(coins, c)
+		}
+	}
+	s.mu.Unlock()
+
+	response := orderedmap.New()
+	response.Set("supported_coins", coins)
+	return response, nil
+}
+
+// now is replaced in tests
+var now = time.Now
@@ -0,0 +1,311 @@
+package devserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	bridgeutil "github.com/CoolBitX-Technology/sygna-bridge-util-go"
+	"github.com/iancoleman/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+type testVASP struct {
+	privateKey string
+	publicKey  string
+	callbacks  chan *orderedmap.OrderedMap
+	server     *httptest.Server
+}
+
+func newTestVASP(t *testing.T) *testVASP {
+	v := &testVASP{callbacks: make(chan *orderedmap.OrderedMap, 10)}
+	var err error
+	v.privateKey, v.publicKey, err = bridgeutil.GenerateKeyPair()
+	assert.Nil(t, err)
+	v.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o := orderedmap.New()
+		b, _ := io.ReadAll(r.Body)
+		assert.Nil(t, o.UnmarshalJSON(b))
+		o.Set("path", r.URL.Path)
+		v.callbacks <- o
+	}))
+	t.Cleanup(v.server.Close)
+	return v
+}
+
+func newTestServer(t *testing.T, originator, beneficiary *testVASP) (*Server, *httptest.Server) {
+	seed, err := ParseSeed([]byte(`
+vasps:
+  - vasp_code: VASPUSNY1
+    vasp_name: Originator
+    vasp_pubkey: ` + originator.publicKey + `
+    api_key: originator-key
+  - vasp_code: VASPJPJT4
+    vasp_name: Beneficiary
+    vasp_pubkey: ` + beneficiary.publicKey + `
+    callback_permission_request_url: ` + beneficiary.server.URL + `/permission-request
+    callback_txid_url: ` + beneficiary.server.URL + `/txid
+    addresses: [rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh]
+`))
+	assert.Nil(t, err)
+	s, err := New(Config{Seed: *seed})
+	assert.Nil(t, err)
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func signed(t *testing.T, privateKey, s string) *orderedmap.OrderedMap {
+	o := orderedmap.New()
+	assert.Nil(t, o.UnmarshalJSON([]byte(s)))
+	assert.Nil(t, bridgeutil.Sign(o, privateKey))
+	return o
+}
+
+func permissionRequest(t *testing.T, privateKey, callbackURL, amount string) *orderedmap.OrderedMap {
+	data := signed(t, privateKey, `{"private_info":"04ab","transaction":{"originator_vasp":{"vasp_code":"VASPUSNY1","addrs":[{"address":"r3kmLJN5D28dHuH8vZNUZpMC43pEHpaocV","addr_extra_info":[]}]},"beneficiary_vasp":{"vasp_code":"VASPJPJT4","addrs":[{"address":"rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh","addr_extra_info":[{"tag":"123"}]}]},"currency_id":"sygna:0x80000090","amount":"`+amount+`"},"data_dt":"2020-07-13T05:56:53.088Z"}`)
+	callback := signed(t, privateKey, `{"callback_url":"`+callbackURL+`"}`)
+	body := orderedmap.New()
+	body.Set("data", data)
+	body.Set("callback", callback)
+	return body
+}
+
+func TestTransferFlow(t *testing.T) {
+	originator, beneficiary := newTestVASP(t), newTestVASP(t)
+	s, server := newTestServer(t, originator, beneficiary)
+	originatorAPI := &bridgeutil.BridgeAPI{APIDomain: server.URL + "/", APIKey: "originator-key"}
+	beneficiaryAPI := &bridgeutil.BridgeAPI{APIDomain: server.URL + "/", APIKey: "VASPJPJT4"}
+
+	vasps, err := originatorAPI.GetVASP(false)
+	assert.Nil(t, err)
+	assert.Len(t, vasps, 2)
+
+	response, err := originatorAPI.PostPermissionRequest(permissionRequest(t, originator.privateKey, originator.server.URL+"/permission", "4.5"))
+	assert.Nil(t, err)
+	transferID, _ := response.Get("transfer_id")
+
+	forwarded := <-beneficiary.callbacks
+	assert.Equal(t, "/permission-request", getString(forwarded, "path"))
+	forwarded.Delete("path")
+	valid, err := bridgeutil.Verify(forwarded, s.CentralPublicKey())
+	assert.Nil(t, err)
+	assert.True(t, valid)
+	valid, err = bridgeutil.Verify(getObject(forwarded, "data"), originator.publicKey)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	_, err = beneficiaryAPI.PostPermission(signed(t, originator.privateKey, `{"transfer_id":"`+transferID.(string)+`","permission_status":"ACCEPTED"}`))
+	assert.Contains(t, err.Error(), "invalid signature of VASPJPJT4")
+	_, err = originatorAPI.PostPermission(signed(t, originator.privateKey, `{"transfer_id":"`+transferID.(string)+`","permission_status":"ACCEPTED"}`))
+	assert.Contains(t, err.Error(), "only the beneficiary")
+	_, err = beneficiaryAPI.PostPermission(signed(t, beneficiary.privateKey, `{"transfer_id":"`+transferID.(string)+`","permission_status":"ACCEPTED"}`))
+	assert.Nil(t, err)
+
+	permission := <-originator.callbacks
+	assert.Equal(t, "ACCEPTED", getString(permission, "permission_status"))
+
+	_, err = originatorAPI.PostTransactionID(signed(t, originator.privateKey, `{"transfer_id":"`+transferID.(string)+`","txid":"9b0d2e58"}`))
+	assert.Nil(t, err)
+	txid := <-beneficiary.callbacks
+	assert.Equal(t, "/txid", getString(txid, "path"))
+	assert.Equal(t, "9b0d2e58", getString(txid, "txid"))
+
+	status, err := beneficiaryAPI.GetStatus(transferID.(string))
+	assert.Nil(t, err)
+	valid, _ = bridgeutil.Verify(status, s.CentralPublicKey())
+	assert.True(t, valid)
+	transferData := getObject(status, "transferData")
+	assert.Equal(t, "9b0d2e58", getString(transferData, "txid"))
+	assert.Equal(t, "ACCEPTED", getString(getObject(transferData, "permission"), "status"))
+
+	_, err = originatorAPI.PostPermissionRequest(permissionRequest(t, originator.privateKey, originator.server.URL+"/permission", "4.5"))
+	assert.Contains(t, err.Error(), "duplicate permission request")
+
+	s.Wait()
+	transfer, ok := s.Transfer(transferID.(string))
+	assert.True(t, ok)
+	assert.Len(t, transfer.Deliveries, 3)
+	for _, d := range transfer.Deliveries {
+		assert.Empty(t, d.Error)
+	}
+
+	filtered, err := originatorAPI.PostWalletAddressFilter(bridgeutil.StringToOrderedMap(`{"currency_id":"sygna:0x80000090","addrs":["rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh","r3kmLJN5D28dHuH8vZNUZpMC43pEHpaocV"]}`))
+	assert.Nil(t, err)
+	code, _ := filtered[0].Get("vasp_code")
+	assert.Equal(t, "VASPJPJT4", code)
+
+	coins, err := originatorAPI.GetCurrencies(bridgeutil.StringToOrderedMap(`{"currency_symbol":"XRP"}`))
+	assert.Nil(t, err)
+	assert.Len(t, coins, 1)
+
+	_, err = (&bridgeutil.BridgeAPI{APIDomain: server.URL + "/", APIKey: "unknown"}).GetCurrencies(nil)
+	assert.Contains(t, err.Error(), "invalid api key")
+}
+
+func TestAdmin(t *testing.T) {
+	originator, beneficiary := newTestVASP(t), newTestVASP(t)
+	s, server := newTestServer(t, originator, beneficiary)
+	originatorAPI := &bridgeutil.BridgeAPI{APIDomain: server.URL + "/", APIKey: "originator-key"}
+
+	response, err := originatorAPI.PostPermissionRequest(permissionRequest(t, originator.privateKey, originator.server.URL+"/permission", "1"))
+	assert.Nil(t, err)
+	transferID, _ := response.Get("transfer_id")
+	<-beneficiary.callbacks
+
+	req, _ := http.NewRequest(http.MethodPatch, server.URL+"/admin/transfers/"+transferID.(string), strings.NewReader(`{"status":"REJECTED","reject_code":"BVRC004","reject_message":"sanctioned"}`))
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	permission := <-originator.callbacks
+	assert.Equal(t, "REJECTED", getString(permission, "permission_status"))
+	assert.Equal(t, "BVRC004", getString(permission, "reject_code"))
+
+	resp, err = http.Get(server.URL + "/admin/transfers")
+	assert.Nil(t, err)
+	var transfers []Transfer
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&transfers))
+	resp.Body.Close()
+	assert.Len(t, transfers, 1)
+	assert.Equal(t, StatusRejected, transfers[0].Status)
+
+	resp, err = http.Post(server.URL+"/admin/transfers/"+transferID.(string)+"/redeliver", "application/json", nil)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	<-beneficiary.callbacks
+
+	resp, err = http.Get(server.URL + "/admin/central-key")
+	assert.Nil(t, err)
+	var key map[string]string
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&key))
+	resp.Body.Close()
+	assert.Equal(t, s.CentralPublicKey(), key["public_key"])
+	s.Wait()
+}
+
+func TestParseSeed(t *testing.T) {
+	_, err := ParseSeed([]byte("vasps:\n  - vasp_name: no code\n"))
+	assert.NotNil(t, err)
+	_, err = ParseSeed([]byte("vasps:\n  - vasp_code: A\n  - vasp_code: A\n"))
+	assert.NotNil(t, err)
+
+	seed, err := ParseSeed([]byte("currencies:\n  - currency_id: sygna:0x80000000\n    currency_symbol: BTC\n    is_active: true\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, "BTC", seed.Currencies[0].CurrencySymbol)
+
+	_, err = New(Config{CentralPrivateKey: "zz"})
+	assert.NotNil(t, err)
+}
+
+func patchTransfer(t *testing.T, url, transferID, update string) {
+	req, _ := http.NewRequest(http.MethodPatch, url+"/admin/transfers/"+transferID, strings.NewReader(update))
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestCancel(t *testing.T) {
+	originator, beneficiary := newTestVASP(t), newTestVASP(t)
+	s, server := newTestServer(t, originator, beneficiary)
+	originatorAPI := &bridgeutil.BridgeAPI{APIDomain: server.URL + "/", APIKey: "originator-key"}
+
+	var tests = []struct {
+		name   string
+		update string
+		err    string
+	}{
+		{"accepted", `{"status":"ACCEPTED"}`, ""},
+		{"pending", `{}`, "is PENDING"},
+		{"rejected", `{"status":"REJECTED","reject_code":"BVRC999"}`, "is REJECTED"},
+		{"canceled", `{"status":"CANCELED"}`, "is CANCELED"},
+		{"accepted with txid", `{"status":"ACCEPTED","txid":"9b0d2e58"}`, "already has a txid"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := originatorAPI.PostPermissionRequest(permissionRequest(t, originator.privateKey, originator.server.URL+"/permission", strconv.Itoa(i+1)))
+			assert.Nil(t, err)
+			transferID, _ := response.Get("transfer_id")
+			patchTransfer(t, server.URL, transferID.(string), tt.update)
+			before, _ := s.Transfer(transferID.(string))
+
+			_, err = originatorAPI.PostTransactionCancel(signed(t, originator.privateKey, `{"transfer_id":"`+transferID.(string)+`"}`))
+			transfer, _ := s.Transfer(transferID.(string))
+			if tt.err == "" {
+				assert.Nil(t, err)
+				assert.Equal(t, StatusCanceled, transfer.Status)
+				return
+			}
+			assert.Contains(t, err.Error(), `"status":400`)
+			assert.Contains(t, err.Error(), tt.err)
+			assert.Equal(t, before.Status, transfer.Status)
+		})
+	}
+	s.Wait()
+}
+
+func TestResetTransfersDuringDelivery(t *testing.T) {
+	originator, beneficiary := newTestVASP(t), newTestVASP(t)
+	arrived, release := make(chan struct{}), make(chan struct{})
+	beneficiary.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(arrived)
+		<-release
+	})
+	s, server := newTestServer(t, originator, beneficiary)
+	originatorAPI := &bridgeutil.BridgeAPI{APIDomain: server.URL + "/", APIKey: "originator-key"}
+
+	_, err := originatorAPI.PostPermissionRequest(permissionRequest(t, originator.privateKey, originator.server.URL+"/permission", "1"))
+	assert.Nil(t, err)
+	<-arrived
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/admin/transfers", nil)
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+
+	close(release)
+	s.Wait()
+	assert.Empty(t, s.Transfers())
+}
+
+func TestRetry(t *testing.T) {
+	originator, beneficiary := newTestVASP(t), newTestVASP(t)
+	var calls int32
+	beneficiary.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	s, server := newTestServer(t, originator, beneficiary)
+	originatorAPI := &bridgeutil.BridgeAPI{APIDomain: server.URL + "/", APIKey: "originator-key"}
+	beneficiaryAPI := &bridgeutil.BridgeAPI{APIDomain: server.URL + "/", APIKey: "VASPJPJT4"}
+
+	response, err := originatorAPI.PostPermissionRequest(permissionRequest(t, originator.privateKey, originator.server.URL+"/permission", "1"))
+	assert.Nil(t, err)
+	transferID, _ := response.Get("transfer_id")
+	s.Wait()
+
+	_, err = beneficiaryAPI.PostRetry(bridgeutil.StringToOrderedMap(`{"vasp_code":"VASPUSNY1"}`))
+	assert.Contains(t, err.Error(), "cannot act for VASPUSNY1")
+
+	retried, err := beneficiaryAPI.PostRetry(bridgeutil.StringToOrderedMap(`{"vasp_code":"VASPJPJT4"}`))
+	assert.Nil(t, err)
+	items, _ := retried.Get("retryItems")
+	assert.Equal(t, float64(1), items)
+	s.Wait()
+
+	transfer, _ := s.Transfer(transferID.(string))
+	assert.Len(t, transfer.Deliveries, 2)
+	assert.Empty(t, transfer.Deliveries[1].Error)
+	retried, err = beneficiaryAPI.PostRetry(bridgeutil.StringToOrderedMap(`{"vasp_code":"VASPJPJT4"}`))
+	assert.Nil(t, err)
+	items, _ = retried.Get("retryItems")
+	assert.Equal(t, float64(0), items)
+}
@@ -0,0 +1,86 @@
+package devserver
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VASP is a VASP registered on the dev server
+type VASP struct {
+	VASPCode   string `yaml:"vasp_code" json:"vasp_code"`
+	VASPName   string `yaml:"vasp_name" json:"vasp_name"`
+	VASPPubkey string `yaml:"vasp_pubkey" json:"vasp_pubkey"`
+	// APIKey authenticates the VASP in X-Api-Key, the VASP code when empty
+	APIKey                       string `yaml:"api_key" json:"-"`
+	CallbackPermissionRequestURL string `yaml:"callback_permission_request_url" json:"callback_permission_request_url,omitempty"`
+	CallbackTxIDURL              string `yaml:"callback_txid_url" json:"callback_txid_url,omitempty"`
+	CallbackValidateAddrURL      string `yaml:"callback_validate_addr_url" json:"callback_validate_addr_url,omitempty"`
+	// Addresses are reported as belonging to the VASP by the wallet address filter
+	Addresses []string `yaml:"addresses" json:"addresses,omitempty"`
+	// ServerStatus and CheckingRule are the last values posted by the VASP
+	ServerStatus interface{} `yaml:"-" json:"server_status,omitempty"`
+	CheckingRule interface{} `yaml:"-" json:"checking_rule,omitempty"`
+}
+
+// Currency is a currency returned by the currencies endpoint
+type Currency struct {
+	CurrencyID     string   `yaml:"currency_id" json:"currency_id"`
+	CurrencyName   string   `yaml:"currency_name" json:"currency_name"`
+	CurrencySymbol string   `yaml:"currency_symbol" json:"currency_symbol"`
+	IsActive       bool     `yaml:"is_active" json:"is_active"`
+	AddrExtraInfo  []string `yaml:"addr_extra_info" json:"addr_extra_info"`
+}
+
+// Seed is the initial state of the dev server, usually loaded from YAML:
+//
+//	vasps:
+//	  - vasp_code: VASPUSNY1
+//	    vasp_pubkey: 04...
+//	    api_key: originator-key
+//	    callback_permission_request_url: http://localhost:9001/permission-request
+//	currencies:
+//	  - currency_id: sygna:0x80000090
+//	    currency_symbol: XRP
+//	    is_active: true
+//	    addr_extra_info: [tag]
+type Seed struct {
+	VASPs      []VASP     `yaml:"vasps"`
+	Currencies []Currency `yaml:"currencies"`
+}
+
+// DefaultCurrencies are served when the seed has no currencies
+var DefaultCurrencies = []Currency{
+	{CurrencyID: "sygna:0x80000000", CurrencyName: "Bitcoin", CurrencySymbol: "BTC", IsActive: true, AddrExtraInfo: []string{}},
+	{CurrencyID: "sygna:0x8000003c", CurrencyName: "Ethereum", CurrencySymbol: "ETH", IsActive: true, AddrExtraInfo: []string{}},
+	{CurrencyID: "sygna:0x80000090", CurrencyName: "XRP", CurrencySymbol: "XRP", IsActive: true, AddrExtraInfo: []string{"tag"}},
+}
+
+// ParseSeed decodes a YAML seed
+func ParseSeed(b []byte) (*Seed, error) {
+	seed := &Seed{}
+	if err := yaml.Unmarshal(b, seed); err != nil {
+		return nil, err
+	}
+	codes := map[string]bool{}
+	for _, v := range seed.VASPs {
+		if v.VASPCode == "" {
+			return nil, fmt.Errorf("seed vasp without vasp_code")
+		}
+		if codes[v.VASPCode] {
+			return nil, fmt.Errorf("duplicate seed vasp %s", v.VASPCode)
+		}
+		codes[v.VASPCode] = true
+	}
+	return seed, nil
+}
+
+// LoadSeed reads a YAML seed file
+func LoadSeed(path string) (*Seed, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSeed(b)
+}
@@ -0,0 +1,494 @@
+package devserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	bridgeutil "github.com/CoolBitX-Technology/sygna-bridge-util-go"
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/address"
+	"github.com/iancoleman/orderedmap"
+)
+
+// Transfer states
+const (
+	StatusPending  = "PENDING"
+	StatusAccepted = bridgeutil.PermissionStatusAccepted
+	StatusRejected = bridgeutil.PermissionStatusRejected
+	StatusCanceled = "CANCELED"
+)
+
+// Delivery is an attempt to post a callback
+type Delivery struct {
+	Kind       string    `json:"kind"`
+	URL        string    `json:"url"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// Event is a request about a transfer which does not change its state, e.g. cdd
+type Event struct {
+	Kind     string      `json:"kind"`
+	VASPCode string      `json:"vasp_code"`
+	Body     interface{} `json:"body"`
+	At       time.Time   `json:"at"`
+}
+
+// Transfer is the state of a permission request
+type Transfer struct {
+	TransferID          string `json:"transfer_id"`
+	OriginatorVASPCode  string `json:"originator_vasp_code"`
+	BeneficiaryVASPCode string `json:"beneficiary_vasp_code"`
+	// Data is the permission request data signed by the originator
+	Data                *orderedmap.OrderedMap `json:"data"`
+	CallbackURL         string                 `json:"callback_url"`
+	Status              string                 `json:"status"`
+	PermissionSignature string                 `json:"permission_signature,omitempty"`
+	RejectCode          string                 `json:"reject_code,omitempty"`
+	RejectMessage       string                 `json:"reject_message,omitempty"`
+	TxID                string                 `json:"txid,omitempty"`
+	TxIDSignature       string                 `json:"txid_signature,omitempty"`
+	CreatedAt           time.Time              `json:"created_at"`
+	Deliveries          []Delivery             `json:"deliveries"`
+	Events              []Event                `json:"events"`
+}
+
+// Transfers returns copies of the transfers in creation order
+func (s *Server) Transfers() []Transfer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	transfers := make([]Transfer, len(s.order))
+	for i, id := range s.order {
+		transfers[i] = s.transfers[id].copy()
+	}
+	return transfers
+}
+
+// Transfer returns a copy of a transfer
+func (s *Server) Transfer(transferID string) (Transfer, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.transfers[transferID]
+	if !ok {
+		return Transfer{}, false
+	}
+	return t.copy(), true
+}
+
+func (t *Transfer) copy() Transfer {
+	c := *t
+	c.Deliveries = append([]Delivery{}, t.Deliveries...)
+	c.Events = append([]Event{}, t.Events...)
+	return c
+}
+
+// transferOf returns the transfer of the transfer_id of body, which vasp must be party to
+func (s *Server) transferOf(vasp *VASP, body *orderedmap.OrderedMap) (*Transfer, error) {
+	id := getString(body, "transfer_id")
+	t, ok := s.transfers[id]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "transfer %q not found", id)
+	}
+	if vasp.VASPCode != t.OriginatorVASPCode && vasp.VASPCode != t.BeneficiaryVASPCode {
+		return nil, errorf(http.StatusForbidden, "%s is not party to transfer %s", vasp.VASPCode, id)
+	}
+	return t, nil
+}
+
+func (s *Server) handleStatus(vasp *VASP, r *http.Request) (interface{}, error) {
+	query := orderedmap.New()
+	query.Set("transfer_id", r.URL.Query().Get("transfer_id"))
+	s.mu.Lock()
+	t, err := s.transferOf(vasp, query)
+	var transferData *orderedmap.OrderedMap
+	if err == nil {
+		transferData = t.statusData()
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	response := orderedmap.New()
+	response.Set("transferData", transferData)
+	return s.sign(response), nil
+}
+
+func (t *Transfer) statusData() *orderedmap.OrderedMap {
+	o := orderedmap.New()
+	o.Set("transfer_id", t.TransferID)
+	for _, k := range []string{"private_info", "transaction", "data_dt"} {
+		v, _ := t.Data.Get(k)
+		o.Set(k, v)
+	}
+	o.Set("permission_request_data_signature", getString(t.Data, "signature"))
+	permission := orderedmap.New()
+	permission.Set("status", t.Status)
+	permission.Set("signature", t.PermissionSignature)
+	if t.RejectCode != "" {
+		permission.Set("reject_code", t.RejectCode)
+		permission.Set("reject_message", t.RejectMessage)
+	}
+	o.Set("permission", permission)
+	o.Set("txid", t.TxID)
+	o.Set("txid_signature", t.TxIDSignature)
+	o.Set("created_at", t.CreatedAt.UTC().Format(time.RFC3339Nano))
+	return o
+}
+
+func (s *Server) handlePermissionRequest(vasp *VASP, r *http.Request) (interface{}, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	data, callback := getObject(body, "data"), getObject(body, "callback")
+	if data == nil || callback == nil {
+		return nil, errorf(http.StatusBadRequest, "data and callback are required")
+	}
+	transaction := getObject(data, "transaction")
+	if transaction == nil || getString(data, "private_info") == "" {
+		return nil, errorf(http.StatusBadRequest, "data.private_info and data.transaction are required")
+	}
+	originator, beneficiary := getObject(transaction, "originator_vasp"), getObject(transaction, "beneficiary_vasp")
+	if originator == nil || beneficiary == nil {
+		return nil, errorf(http.StatusBadRequest, "transaction.originator_vasp and transaction.beneficiary_vasp are required")
+	}
+	if code := getString(originator, "vasp_code"); code != vasp.VASPCode {
+		return nil, errorf(http.StatusForbidden, "api key of %s cannot act for %s", vasp.VASPCode, code)
+	}
+	beneficiaryCode := getString(beneficiary, "vasp_code")
+	if _, ok := s.vasp(beneficiaryCode); !ok {
+		return nil, errorf(http.StatusBadRequest, "beneficiary vasp %q is not registered", beneficiaryCode)
+	}
+	if err := s.verify(vasp, data); err != nil {
+		return nil, err
+	}
+	if err := s.verify(vasp, callback); err != nil {
+		return nil, err
+	}
+
+	b, _ := json.Marshal(data)
+	sum := sha256.Sum256(b)
+	t := &Transfer{
+		TransferID:          hex.EncodeToString(sum[:]),
+		OriginatorVASPCode:  vasp.VASPCode,
+		BeneficiaryVASPCode: beneficiaryCode,
+		Data:                data,
+		CallbackURL:         getString(callback, "callback_url"),
+		Status:              StatusPending,
+		CreatedAt:           now(),
+	}
+	s.mu.Lock()
+	if _, ok := s.transfers[t.TransferID]; ok {
+		s.mu.Unlock()
+		return nil, errorf(http.StatusConflict, "duplicate permission request %s", t.TransferID)
+	}
+	s.transfers[t.TransferID] = t
+	s.order = append(s.order, t.TransferID)
+	s.mu.Unlock()
+
+	s.forwardPermissionRequest(t.TransferID)
+
+	response := orderedmap.New()
+	response.Set("transfer_id", t.TransferID)
+	return response, nil
+}
+
+func (s *Server) handlePermission(vasp *VASP, r *http.Request) (interface{}, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	status := getString(body, "permission_status")
+	if status != StatusAccepted && status != StatusRejected {
+		return nil, errorf(http.StatusBadRequest, "permission_status must be %s or %s", StatusAccepted, StatusRejected)
+	}
+	if err := s.verify(vasp, body); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	t, err := s.transferOf(vasp, body)
+	if err == nil && vasp.VASPCode != t.BeneficiaryVASPCode {
+		err = errorf(http.StatusForbidden, "only the beneficiary can answer transfer %s", t.TransferID)
+	}
+	if err == nil && t.Status != StatusPending {
+		err = errorf(http.StatusBadRequest, "transfer %s is already %s", t.TransferID, t.Status)
+	}
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	t.Status = status
+	t.PermissionSignature = getString(body, "signature")
+	t.RejectCode = getString(body, "reject_code")
+	t.RejectMessage = getString(body, "reject_message")
+	s.mu.Unlock()
+
+	s.forwardPermission(t.TransferID)
+	return okBody(), nil
+}
+
+func (s *Server) handleTxID(vasp *VASP, r *http.Request) (interface{}, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if getString(body, "txid") == "" {
+		return nil, errorf(http.StatusBadRequest, "txid is required")
+	}
+	if err := s.verify(vasp, body); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	t, err := s.transferOf(vasp, body)
+	if err == nil && vasp.VASPCode != t.OriginatorVASPCode {
+		err = errorf(http.StatusForbidden, "only the originator can send the txid of transfer %s", t.TransferID)
+	}
+	if err == nil && t.Status != StatusAccepted {
+		err = errorf(http.StatusBadRequest, "transfer %s is %s", t.TransferID, t.Status)
+	}
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	t.TxID = getString(body, "txid")
+	t.TxIDSignature = getString(body, "signature")
+	s.mu.Unlock()
+
+	s.forwardTxID(t.TransferID)
+	return okBody(), nil
+}
+
+func (s *Server) handleCancel(vasp *VASP, r *http.Request) (interface{}, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verify(vasp, body); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.transferOf(vasp, body)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != StatusAccepted {
+		return nil, errorf(http.StatusBadRequest, "transfer %s is %s", t.TransferID, t.Status)
+	}
+	if t.TxID != "" {
+		return nil, errorf(http.StatusBadRequest, "transfer %s already has a txid", t.TransferID)
+	}
+	t.Status = StatusCanceled
+	return okBody(), nil
+}
+
+// handleRetry resends the permission requests of the VASP whose last delivery failed.
+// Like Sygna Bridge, it takes an unsigned body.
+func (s *Server) handleRetry(vasp *VASP, r *http.Request) (interface{}, error) {
+	if _, err := readOwnBody(vasp, r); err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	s.mu.Lock()
+	for _, id := range s.order {
+		t := s.transfers[id]
+		if t.BeneficiaryVASPCode == vasp.VASPCode && t.Status == StatusPending && !t.delivered("permission_request") {
+			ids = append(ids, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, id := range ids {
+		s.forwardPermissionRequest(id)
+	}
+	response := orderedmap.New()
+	response.Set("retryItems", len(ids))
+	return response, nil
+}
+
+func (t *Transfer) delivered(kind string) bool {
+	for i := len(t.Deliveries) - 1; i >= 0; i-- {
+		if t.Deliveries[i].Kind == kind {
+			return t.Deliveries[i].Error == ""
+		}
+	}
+	return false
+}
+
+func (s *Server) handleCDD(kind string) handler {
+	return func(vasp *VASP, r *http.Request) (interface{}, error) {
+		body, err := readBody(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.verify(vasp, body); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		t, err := s.transferOf(vasp, body)
+		if err != nil {
+			return nil, err
+		}
+		t.Events = append(t.Events, Event{Kind: kind, VASPCode: vasp.VASPCode, Body: body, At: now()})
+		return okBody(), nil
+	}
+}
+
+// handleAddressValidation checks the format of the addresses offline
+func (s *Server) handleAddressValidation(vasp *VASP, r *http.Request) (interface{}, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verify(vasp, body); err != nil {
+		return nil, err
+	}
+	currencyID := getString(body, "currency_id")
+	addrs, _ := body.Get("addrs")
+	list, _ := addrs.([]interface{})
+
+	valid := true
+	for _, v := range list {
+		o, ok := v.(orderedmap.OrderedMap)
+		if !ok {
+			return nil, errorf(http.StatusBadRequest, "addrs must be objects")
+		}
+		a, err := address.FromOrderedMap(&o)
+		if err == nil {
+			err = a.Validate(currencyID)
+		}
+		if err != nil && !errors.Is(err, address.ErrUnsupportedCurrency) {
+			valid = false
+		}
+	}
+	response := orderedmap.New()
+	response.Set("vasp_code", getString(body, "vasp_code"))
+	response.Set("is_valid", valid)
+	return response, nil
+}
+
+// handleWalletAddressFilter reports the seeded VASP owning each address
+func (s *Server) handleWalletAddressFilter(_ *VASP, r *http.Request) (interface{}, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	addrs, _ := body.Get("addrs")
+	list, _ := addrs.([]interface{})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]*orderedmap.OrderedMap, 0, len(list))
+	for _, v := range list {
+		addr, _ := v.(string)
+		o := orderedmap.New()
+		o.Set("address", addr)
+		o.Set("vasp_code", s.ownerOf(addr))
+		result = append(result, o)
+	}
+	return result, nil
+}
+
+func (s *Server) ownerOf(addr string) string {
+	for _, code := range s.vaspOrder {
+		for _, a := range s.vasps[code].Addresses {
+			if a == addr {
+				return code
+			}
+		}
+	}
+	return ""
+}
+
+func (s *Server) forwardPermissionRequest(transferID string) {
+	s.mu.Lock()
+	t, ok := s.transfers[transferID]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	url := s.vasps[t.BeneficiaryVASPCode].CallbackPermissionRequestURL
+	body := orderedmap.New()
+	body.Set("transfer_id", t.TransferID)
+	body.Set("data", t.Data)
+	s.mu.Unlock()
+
+	s.deliver(transferID, "permission_request", url, s.sign(body))
+}
+
+func (s *Server) forwardPermission(transferID string) {
+	s.mu.Lock()
+	t, ok := s.transfers[transferID]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	body := orderedmap.New()
+	body.Set("transfer_id", t.TransferID)
+	body.Set("permission_status", t.Status)
+	if t.RejectCode != "" {
+		body.Set("reject_code", t.RejectCode)
+		body.Set("reject_message", t.RejectMessage)
+	}
+	url := t.CallbackURL
+	s.mu.Unlock()
+
+	s.deliver(transferID, "permission", url, s.sign(body))
+}
+
+func (s *Server) forwardTxID(transferID string) {
+	s.mu.Lock()
+	t, ok := s.transfers[transferID]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	url := s.vasps[t.BeneficiaryVASPCode].CallbackTxIDURL
+	body := orderedmap.New()
+	body.Set("transfer_id", t.TransferID)
+	body.Set("txid", t.TxID)
+	s.mu.Unlock()
+
+	s.deliver(transferID, "txid", url, s.sign(body))
+}
+
+// deliver posts body to url in the background and records the attempt; an empty url is skipped
+func (s *Server) deliver(transferID, kind, url string, body *orderedmap.OrderedMap) {
+	if url == "" {
+		return
+	}
+	b, _ := json.Marshal(body)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		d := Delivery{Kind: kind, URL: url, At: now()}
+		resp, err := s.client.Post(url, "application/json", bytes.NewReader(b))
+		if err != nil {
+			d.Error = err.Error()
+		} else {
+			resp.Body.Close()
+			d.StatusCode = resp.StatusCode
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				d.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+			}
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// the transfers may have been reset while the callback was in flight
+		if t, ok := s.transfers[transferID]; ok {
+			t.Deliveries = append(t.Deliveries, d)
+		}
+	}()
+}
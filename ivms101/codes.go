@@ -0,0 +1,108 @@
+package ivms101
+
+// NaturalPersonNameTypeCode specifies the nature of a natural person name
+type NaturalPersonNameTypeCode string
+
+const (
+	// NaturalPersonNameTypeAlias ALIA, a name other than the legal name
+	NaturalPersonNameTypeAlias NaturalPersonNameTypeCode = "ALIA"
+	// NaturalPersonNameTypeBirth BIRT, the name given at birth
+	NaturalPersonNameTypeBirth NaturalPersonNameTypeCode = "BIRT"
+	// NaturalPersonNameTypeMaiden MAID, the family name before marriage
+	NaturalPersonNameTypeMaiden NaturalPersonNameTypeCode = "MAID"
+	// NaturalPersonNameTypeLegal LEGL, the name that identifies the person for legal purposes
+	NaturalPersonNameTypeLegal NaturalPersonNameTypeCode = "LEGL"
+	// NaturalPersonNameTypeMisc MISC, an unspecified name
+	NaturalPersonNameTypeMisc NaturalPersonNameTypeCode = "MISC"
+)
+
+// LegalPersonNameTypeCode specifies the nature of a legal person name
+type LegalPersonNameTypeCode string
+
+const (
+	// LegalPersonNameTypeLegal LEGL, the official registered name
+	LegalPersonNameTypeLegal LegalPersonNameTypeCode = "LEGL"
+	// LegalPersonNameTypeShort SHRT, the short name
+	LegalPersonNameTypeShort LegalPersonNameTypeCode = "SHRT"
+	// LegalPersonNameTypeTrading TRAD, the name used for trading
+	LegalPersonNameTypeTrading LegalPersonNameTypeCode = "TRAD"
+)
+
+// NationalIdentifierTypeCode specifies the kind of a national identifier
+type NationalIdentifierTypeCode string
+
+const (
+	// NationalIdentifierTypeAlienRegistration ARNU, alien registration number
+	NationalIdentifierTypeAlienRegistration NationalIdentifierTypeCode = "ARNU"
+	// NationalIdentifierTypePassport CCPT, passport number
+	NationalIdentifierTypePassport NationalIdentifierTypeCode = "CCPT"
+	// NationalIdentifierTypeRegistrationAuthority RAID, registration authority identifier
+	NationalIdentifierTypeRegistrationAuthority NationalIdentifierTypeCode = "RAID"
+	// NationalIdentifierTypeDriverLicense DRLC, driver license number
+	NationalIdentifierTypeDriverLicense NationalIdentifierTypeCode = "DRLC"
+	// NationalIdentifierTypeForeignInvestment FIIN, foreign investment identity number
+	NationalIdentifierTypeForeignInvestment NationalIdentifierTypeCode = "FIIN"
+	// NationalIdentifierTypeTax TXID, tax identification number
+	NationalIdentifierTypeTax NationalIdentifierTypeCode = "TXID"
+	// NationalIdentifierTypeSocialSecurity SOCS, social security number
+	NationalIdentifierTypeSocialSecurity NationalIdentifierTypeCode = "SOCS"
+	// NationalIdentifierTypeIdentityCard IDCD, identity card number
+	NationalIdentifierTypeIdentityCard NationalIdentifierTypeCode = "IDCD"
+	// NationalIdentifierTypeLEI LEIX, legal entity identifier
+	NationalIdentifierTypeLEI NationalIdentifierTypeCode = "LEIX"
+	// NationalIdentifierTypeMisc MISC, unspecified identifier
+	NationalIdentifierTypeMisc NationalIdentifierTypeCode = "MISC"
+)
+
+// AddressTypeCode specifies the nature of an address
+type AddressTypeCode string
+
+const (
+	// AddressTypeHome HOME, residential address
+	AddressTypeHome AddressTypeCode = "HOME"
+	// AddressTypeBusiness BIZZ, business address
+	AddressTypeBusiness AddressTypeCode = "BIZZ"
+	// AddressTypeGeographic GEOG, unspecified physical address
+	AddressTypeGeographic AddressTypeCode = "GEOG"
+)
+
+// IsValid reports whether c is a known natural person name type code
+func (c NaturalPersonNameTypeCode) IsValid() bool {
+	switch c {
+	case NaturalPersonNameTypeAlias, NaturalPersonNameTypeBirth, NaturalPersonNameTypeMaiden,
+		NaturalPersonNameTypeLegal, NaturalPersonNameTypeMisc:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether c is a known legal person name type code
+func (c LegalPersonNameTypeCode) IsValid() bool {
+	switch c {
+	case LegalPersonNameTypeLegal, LegalPersonNameTypeShort, LegalPersonNameTypeTrading:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether c is a known national identifier type code
+func (c NationalIdentifierTypeCode) IsValid() bool {
+	switch c {
+	case NationalIdentifierTypeAlienRegistration, NationalIdentifierTypePassport,
+		NationalIdentifierTypeRegistrationAuthority, NationalIdentifierTypeDriverLicense,
+		NationalIdentifierTypeForeignInvestment, NationalIdentifierTypeTax,
+		NationalIdentifierTypeSocialSecurity, NationalIdentifierTypeIdentityCard,
+		NationalIdentifierTypeLEI, NationalIdentifierTypeMisc:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether c is a known address type code
+func (c AddressTypeCode) IsValid() bool {
+	switch c {
+	case AddressTypeHome, AddressTypeBusiness, AddressTypeGeographic:
+		return true
+	}
+	return false
+}
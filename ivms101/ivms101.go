@@ -0,0 +1,146 @@
+/*
+Package ivms101 models the IVMS101 (interVASP Messaging Standard) payload that
+Sygna Bridge VASPs exchange, encrypted, as private_info.
+
+The JSON layout follows the snake_case binding used by Sygna Bridge, e.g.
+
+	{"originator":{"originator_persons":[{"natural_person":{"name":{"name_identifiers":[...]}}}]}}
+*/
+package ivms101
+
+import (
+	"encoding/json"
+)
+
+// Payload is the private_info exchanged between originator and beneficiary VASPs
+type Payload struct {
+	Originator      *Originator      `json:"originator,omitempty"`
+	Beneficiary     *Beneficiary     `json:"beneficiary,omitempty"`
+	OriginatingVASP *OriginatingVASP `json:"originating_vasp,omitempty"`
+	BeneficiaryVASP *BeneficiaryVASP `json:"beneficiary_vasp,omitempty"`
+}
+
+// Originator is the account holder who allows the transfer
+type Originator struct {
+	OriginatorPersons []Person `json:"originator_persons"`
+	AccountNumbers    []string `json:"account_numbers,omitempty"`
+}
+
+// Beneficiary is the intended receiver of the transfer
+type Beneficiary struct {
+	BeneficiaryPersons []Person `json:"beneficiary_persons"`
+	AccountNumbers     []string `json:"account_numbers,omitempty"`
+}
+
+// OriginatingVASP is the VASP which initiates the transfer
+type OriginatingVASP struct {
+	OriginatingVASP *Person `json:"originating_vasp,omitempty"`
+}
+
+// BeneficiaryVASP is the VASP which receives the transfer
+type BeneficiaryVASP struct {
+	BeneficiaryVASP *Person `json:"beneficiary_vasp,omitempty"`
+}
+
+// Person is either a natural person or a legal person
+type Person struct {
+	NaturalPerson *NaturalPerson `json:"natural_person,omitempty"`
+	LegalPerson   *LegalPerson   `json:"legal_person,omitempty"`
+}
+
+// NaturalPerson is a uniquely distinguishable individual
+type NaturalPerson struct {
+	Name                   *NaturalPersonName      `json:"name,omitempty"`
+	GeographicAddress      []Address               `json:"geographic_address,omitempty"`
+	NationalIdentification *NationalIdentification `json:"national_identification,omitempty"`
+	CustomerIdentification string                  `json:"customer_identification,omitempty"`
+	DateAndPlaceOfBirth    *DateAndPlaceOfBirth    `json:"date_and_place_of_birth,omitempty"`
+	CountryOfResidence     string                  `json:"country_of_residence,omitempty"`
+}
+
+// NaturalPersonName holds the names by which a natural person is known
+type NaturalPersonName struct {
+	NameIdentifiers         []NaturalPersonNameIdentifier      `json:"name_identifiers"`
+	LocalNameIdentifiers    []LocalNaturalPersonNameIdentifier `json:"local_name_identifiers,omitempty"`
+	PhoneticNameIdentifiers []LocalNaturalPersonNameIdentifier `json:"phonetic_name_identifiers,omitempty"`
+}
+
+// NaturalPersonNameIdentifier is one name of a natural person in Latin script.
+// PrimaryIdentifier is the surname or family name, SecondaryIdentifier the forenames.
+type NaturalPersonNameIdentifier struct {
+	PrimaryIdentifier   string                    `json:"primary_identifier"`
+	SecondaryIdentifier string                    `json:"secondary_identifier,omitempty"`
+	NameIdentifierType  NaturalPersonNameTypeCode `json:"name_identifier_type"`
+}
+
+// LocalNaturalPersonNameIdentifier is one name of a natural person in any script
+type LocalNaturalPersonNameIdentifier struct {
+	PrimaryIdentifier   string                    `json:"primary_identifier"`
+	SecondaryIdentifier string                    `json:"secondary_identifier,omitempty"`
+	NameIdentifierType  NaturalPersonNameTypeCode `json:"name_identifier_type"`
+}
+
+// LegalPerson is an entity other than a natural person
+type LegalPerson struct {
+	Name                   *LegalPersonName        `json:"name,omitempty"`
+	GeographicAddress      []Address               `json:"geographic_address,omitempty"`
+	CustomerNumber         string                  `json:"customer_number,omitempty"`
+	NationalIdentification *NationalIdentification `json:"national_identification,omitempty"`
+	CountryOfRegistration  string                  `json:"country_of_registration,omitempty"`
+}
+
+// LegalPersonName holds the names by which a legal person is known
+type LegalPersonName struct {
+	NameIdentifiers         []LegalPersonNameIdentifier `json:"name_identifiers"`
+	LocalNameIdentifiers    []LegalPersonNameIdentifier `json:"local_name_identifiers,omitempty"`
+	PhoneticNameIdentifiers []LegalPersonNameIdentifier `json:"phonetic_name_identifiers,omitempty"`
+}
+
+// LegalPersonNameIdentifier is one name of a legal person
+type LegalPersonNameIdentifier struct {
+	LegalPersonName               string                  `json:"legal_person_name"`
+	LegalPersonNameIdentifierType LegalPersonNameTypeCode `json:"legal_person_name_identifier_type"`
+}
+
+// NationalIdentification is an identifier issued by an appropriate issuing authority
+type NationalIdentification struct {
+	NationalIdentifier     string                     `json:"national_identifier"`
+	NationalIdentifierType NationalIdentifierTypeCode `json:"national_identifier_type"`
+	CountryOfIssue         string                     `json:"country_of_issue,omitempty"`
+	RegistrationAuthority  string                     `json:"registration_authority,omitempty"`
+}
+
+// Address is a geographic address of a person
+type Address struct {
+	AddressType        AddressTypeCode `json:"address_type"`
+	Department         string          `json:"department,omitempty"`
+	SubDepartment      string          `json:"sub_department,omitempty"`
+	StreetName         string          `json:"street_name,omitempty"`
+	BuildingNumber     string          `json:"building_number,omitempty"`
+	BuildingName       string          `json:"building_name,omitempty"`
+	Floor              string          `json:"floor,omitempty"`
+	PostBox            string          `json:"post_box,omitempty"`
+	Room               string          `json:"room,omitempty"`
+	PostCode           string          `json:"post_code,omitempty"`
+	TownName           string          `json:"town_name,omitempty"`
+	TownLocationName   string          `json:"town_location_name,omitempty"`
+	DistrictName       string          `json:"district_name,omitempty"`
+	CountrySubDivision string          `json:"country_sub_division,omitempty"`
+	AddressLine        []string        `json:"address_line,omitempty"`
+	Country            string          `json:"country"`
+}
+
+// DateAndPlaceOfBirth of a natural person; DateOfBirth is formatted as YYYY-MM-DD
+type DateAndPlaceOfBirth struct {
+	DateOfBirth  string `json:"date_of_birth"`
+	PlaceOfBirth string `json:"place_of_birth"`
+}
+
+// Parse decodes a decrypted private_info json into Payload
+func Parse(data []byte) (*Payload, error) {
+	payload := &Payload{}
+	if err := json.Unmarshal(data, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
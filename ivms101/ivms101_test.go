@@ -0,0 +1,73 @@
+package ivms101
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const fakePayload = `{"originator":{"originator_persons":[{"natural_person":{"name":{"name_identifiers":[{"primary_identifier":"Wu Xinli","name_identifier_type":"LEGL"}]},"national_identification":{"national_identifier":"446005","national_identifier_type":"RAID","registration_authority":"RA000553"},"country_of_residence":"TZ"}}],"account_numbers":["r3kmLJN5D28dHuH8vZNUZpMC43pEHpaocV"]},"beneficiary":{"beneficiary_persons":[{"legal_person":{"name":{"name_identifiers":[{"legal_person_name":"ABC Limited","legal_person_name_identifier_type":"LEGL"}]}}}],"account_numbers":["rAPERVgXZavGgiGv6xBgtiZurirW2yAmY"]}}`
+
+func TestParse(t *testing.T) {
+	payload, err := Parse([]byte(fakePayload))
+	assert.Nil(t, err)
+	assert.Nil(t, payload.Validate())
+
+	b, err := json.Marshal(payload)
+	assert.Nil(t, err)
+	assert.Equal(t, fakePayload, string(b), "should be equal")
+}
+
+func TestValidate(t *testing.T) {
+	payload, _ := Parse([]byte(fakePayload))
+	natural := payload.Originator.OriginatorPersons[0].NaturalPerson
+	natural.Name.NameIdentifiers[0].NameIdentifierType = "NICK"
+	natural.CountryOfResidence = "ZZ"
+	natural.NationalIdentification = nil
+	natural.GeographicAddress = []Address{{AddressType: AddressTypeHome, StreetName: "Main Street", Country: "TW"}}
+	payload.Beneficiary.BeneficiaryPersons[0].LegalPerson.Name.NameIdentifiers[0].LegalPersonNameIdentifierType = LegalPersonNameTypeTrading
+
+	err := payload.Validate()
+	assert.NotNil(t, err)
+
+	var paths []string
+	for _, fieldError := range err.(ValidationError) {
+		paths = append(paths, fieldError.Path)
+	}
+	assert.Equal(t, []string{
+		"originator.originator_persons[0].natural_person.name.name_identifiers[0].name_identifier_type",
+		"originator.originator_persons[0].natural_person.name.name_identifiers",
+		"originator.originator_persons[0].natural_person.geographic_address[0]",
+		"originator.originator_persons[0].natural_person.country_of_residence",
+		"beneficiary.beneficiary_persons[0].legal_person.name.name_identifiers",
+	}, paths)
+}
+
+func TestValidateOriginatorIdentification(t *testing.T) {
+	payload, _ := Parse([]byte(fakePayload))
+	payload.Originator.OriginatorPersons[0].NaturalPerson.NationalIdentification = nil
+	assert.NotNil(t, payload.Validate(), "originator without any identification should be invalid")
+
+	payload.Originator.OriginatorPersons[0].NaturalPerson.DateAndPlaceOfBirth = &DateAndPlaceOfBirth{
+		DateOfBirth:  "1991-03-21",
+		PlaceOfBirth: "Macon",
+	}
+	assert.Nil(t, payload.Validate())
+
+	payload.Beneficiary = nil
+	assert.NotNil(t, payload.Validate(), "beneficiary should be required")
+}
+
+func TestIsValidLEI(t *testing.T) {
+	assert.True(t, IsValidLEI("5493001KJTIIGC8Y1R12"))
+	assert.False(t, IsValidLEI("5493001KJTIIGC8Y1R13"))
+	assert.False(t, IsValidLEI("5493001KJTIIGC8Y1R1"))
+}
+
+func TestIsValidCountryCode(t *testing.T) {
+	assert.True(t, IsValidCountryCode("TW"))
+	assert.True(t, IsValidCountryCode("XX"))
+	assert.False(t, IsValidCountryCode("tw"))
+	assert.False(t, IsValidCountryCode("UK"))
+}
@@ -0,0 +1,278 @@
+package ivms101
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FieldError describes one IVMS101 constraint violated at Path
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError lists every IVMS101 constraint a payload violates
+type ValidationError []*FieldError
+
+func (e ValidationError) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldError := range e {
+		messages[i] = fieldError.Error()
+	}
+	return "invalid IVMS101 payload: " + strings.Join(messages, "; ")
+}
+
+var registrationAuthorityPattern = regexp.MustCompile(`^RA\d{6}$`)
+
+type validator struct {
+	errors ValidationError
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, &FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) required(path, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.fail(path, "is required")
+	}
+}
+
+func (v *validator) country(path, value string) {
+	if value != "" && !IsValidCountryCode(value) {
+		v.fail(path, "%q is not an ISO 3166-1 alpha-2 country code", value)
+	}
+}
+
+// Validate checks the payload against the IVMS101 constraints Sygna Bridge relies on:
+// mandatory fields, enumeration codes, ISO 3166 country codes, the legal name rules
+// and the originator identification rules. It returns a ValidationError listing
+// every violation, or nil.
+func (p *Payload) Validate() error {
+	v := &validator{}
+
+	if p.Originator == nil || len(p.Originator.OriginatorPersons) == 0 {
+		v.fail("originator.originator_persons", "at least one person is required")
+	} else {
+		for i, person := range p.Originator.OriginatorPersons {
+			path := fmt.Sprintf("originator.originator_persons[%d]", i)
+			v.person(path, &person)
+			v.originatorPerson(path, &person)
+		}
+	}
+
+	if p.Beneficiary == nil || len(p.Beneficiary.BeneficiaryPersons) == 0 {
+		v.fail("beneficiary.beneficiary_persons", "at least one person is required")
+	} else {
+		for i, person := range p.Beneficiary.BeneficiaryPersons {
+			v.person(fmt.Sprintf("beneficiary.beneficiary_persons[%d]", i), &person)
+		}
+	}
+
+	if p.OriginatingVASP != nil && p.OriginatingVASP.OriginatingVASP != nil {
+		v.person("originating_vasp.originating_vasp", p.OriginatingVASP.OriginatingVASP)
+	}
+	if p.BeneficiaryVASP != nil && p.BeneficiaryVASP.BeneficiaryVASP != nil {
+		v.person("beneficiary_vasp.beneficiary_vasp", p.BeneficiaryVASP.BeneficiaryVASP)
+	}
+
+	if len(v.errors) > 0 {
+		return v.errors
+	}
+	return nil
+}
+
+func (v *validator) person(path string, person *Person) {
+	switch {
+	case person.NaturalPerson != nil && person.LegalPerson != nil:
+		v.fail(path, "must be either a natural_person or a legal_person, not both")
+	case person.NaturalPerson != nil:
+		v.naturalPerson(path+".natural_person", person.NaturalPerson)
+	case person.LegalPerson != nil:
+		v.legalPerson(path+".legal_person", person.LegalPerson)
+	default:
+		v.fail(path, "natural_person or legal_person is required")
+	}
+}
+
+// originatorPerson checks the originator must carry one identifying element besides its name
+func (v *validator) originatorPerson(path string, person *Person) {
+	if n := person.NaturalPerson; n != nil {
+		if len(n.GeographicAddress) == 0 && n.CustomerIdentification == "" &&
+			n.NationalIdentification == nil && n.DateAndPlaceOfBirth == nil {
+			v.fail(path+".natural_person", "geographic_address, customer_identification, national_identification or date_and_place_of_birth is required for originator")
+		}
+	}
+	if l := person.LegalPerson; l != nil {
+		if len(l.GeographicAddress) == 0 && l.CustomerNumber == "" && l.NationalIdentification == nil {
+			v.fail(path+".legal_person", "geographic_address, customer_number or national_identification is required for originator")
+		}
+	}
+}
+
+func (v *validator) naturalPerson(path string, n *NaturalPerson) {
+	if n.Name == nil || len(n.Name.NameIdentifiers) == 0 {
+		v.fail(path+".name.name_identifiers", "at least one name identifier is required")
+	} else {
+		hasLegalName := false
+		for i, identifier := range n.Name.NameIdentifiers {
+			identifierPath := fmt.Sprintf("%s.name.name_identifiers[%d]", path, i)
+			v.naturalPersonNameIdentifier(identifierPath, identifier.PrimaryIdentifier, identifier.NameIdentifierType)
+			hasLegalName = hasLegalName || identifier.NameIdentifierType == NaturalPersonNameTypeLegal
+		}
+		if !hasLegalName {
+			v.fail(path+".name.name_identifiers", "a name identifier of type LEGL is required")
+		}
+		for i, identifier := range n.Name.LocalNameIdentifiers {
+			identifierPath := fmt.Sprintf("%s.name.local_name_identifiers[%d]", path, i)
+			v.naturalPersonNameIdentifier(identifierPath, identifier.PrimaryIdentifier, identifier.NameIdentifierType)
+		}
+		for i, identifier := range n.Name.PhoneticNameIdentifiers {
+			identifierPath := fmt.Sprintf("%s.name.phonetic_name_identifiers[%d]", path, i)
+			v.naturalPersonNameIdentifier(identifierPath, identifier.PrimaryIdentifier, identifier.NameIdentifierType)
+		}
+	}
+
+	v.addresses(path+".geographic_address", n.GeographicAddress)
+	if n.NationalIdentification != nil {
+		v.nationalIdentification(path+".national_identification", n.NationalIdentification, false)
+	}
+	if n.DateAndPlaceOfBirth != nil {
+		v.dateAndPlaceOfBirth(path+".date_and_place_of_birth", n.DateAndPlaceOfBirth)
+	}
+	v.country(path+".country_of_residence", n.CountryOfResidence)
+}
+
+func (v *validator) naturalPersonNameIdentifier(path, primaryIdentifier string, nameType NaturalPersonNameTypeCode) {
+	v.required(path+".primary_identifier", primaryIdentifier)
+	if !nameType.IsValid() {
+		v.fail(path+".name_identifier_type", "%q is not a valid name type code", nameType)
+	}
+}
+
+func (v *validator) legalPerson(path string, l *LegalPerson) {
+	if l.Name == nil || len(l.Name.NameIdentifiers) == 0 {
+		v.fail(path+".name.name_identifiers", "at least one name identifier is required")
+	} else {
+		hasLegalName := false
+		for i, identifier := range l.Name.NameIdentifiers {
+			v.legalPersonNameIdentifier(fmt.Sprintf("%s.name.name_identifiers[%d]", path, i), &identifier)
+			hasLegalName = hasLegalName || identifier.LegalPersonNameIdentifierType == LegalPersonNameTypeLegal
+		}
+		if !hasLegalName {
+			v.fail(path+".name.name_identifiers", "a name identifier of type LEGL is required")
+		}
+		for i, identifier := range l.Name.LocalNameIdentifiers {
+			v.legalPersonNameIdentifier(fmt.Sprintf("%s.name.local_name_identifiers[%d]", path, i), &identifier)
+		}
+		for i, identifier := range l.Name.PhoneticNameIdentifiers {
+			v.legalPersonNameIdentifier(fmt.Sprintf("%s.name.phonetic_name_identifiers[%d]", path, i), &identifier)
+		}
+	}
+
+	v.addresses(path+".geographic_address", l.GeographicAddress)
+	if l.NationalIdentification != nil {
+		v.nationalIdentification(path+".national_identification", l.NationalIdentification, true)
+	}
+	v.country(path+".country_of_registration", l.CountryOfRegistration)
+}
+
+func (v *validator) legalPersonNameIdentifier(path string, identifier *LegalPersonNameIdentifier) {
+	v.required(path+".legal_person_name", identifier.LegalPersonName)
+	if !identifier.LegalPersonNameIdentifierType.IsValid() {
+		v.fail(path+".legal_person_name_identifier_type", "%q is not a valid name type code", identifier.LegalPersonNameIdentifierType)
+	}
+}
+
+func (v *validator) nationalIdentification(path string, id *NationalIdentification, legalPerson bool) {
+	v.required(path+".national_identifier", id.NationalIdentifier)
+	if !id.NationalIdentifierType.IsValid() {
+		v.fail(path+".national_identifier_type", "%q is not a valid national identifier type code", id.NationalIdentifierType)
+	}
+	v.country(path+".country_of_issue", id.CountryOfIssue)
+	if id.RegistrationAuthority != "" && !registrationAuthorityPattern.MatchString(id.RegistrationAuthority) {
+		v.fail(path+".registration_authority", "%q is not a GLEIF registration authority code", id.RegistrationAuthority)
+	}
+
+	if legalPerson {
+		switch id.NationalIdentifierType {
+		case NationalIdentifierTypeRegistrationAuthority, NationalIdentifierTypeMisc,
+			NationalIdentifierTypeLEI, NationalIdentifierTypeTax:
+		default:
+			v.fail(path+".national_identifier_type", "%q is not allowed for a legal person", id.NationalIdentifierType)
+		}
+		if id.CountryOfIssue != "" {
+			v.fail(path+".country_of_issue", "must be empty for a legal person")
+		}
+		if id.NationalIdentifierType != NationalIdentifierTypeLEI && id.RegistrationAuthority == "" {
+			v.fail(path+".registration_authority", "is required unless national_identifier_type is LEIX")
+		}
+	} else if id.NationalIdentifierType == NationalIdentifierTypeLEI {
+		v.fail(path+".national_identifier_type", "LEIX is not allowed for a natural person")
+	}
+
+	if id.NationalIdentifierType == NationalIdentifierTypeLEI && !IsValidLEI(id.NationalIdentifier) {
+		v.fail(path+".national_identifier", "%q is not a valid LEI", id.NationalIdentifier)
+	}
+}
+
+func (v *validator) addresses(path string, addresses []Address) {
+	for i, address := range addresses {
+		addressPath := fmt.Sprintf("%s[%d]", path, i)
+		if !address.AddressType.IsValid() {
+			v.fail(addressPath+".address_type", "%q is not a valid address type code", address.AddressType)
+		}
+		v.required(addressPath+".country", address.Country)
+		v.country(addressPath+".country", address.Country)
+		if len(address.AddressLine) > 7 {
+			v.fail(addressPath+".address_line", "must not have more than 7 lines")
+		}
+		if len(address.AddressLine) == 0 &&
+			(address.StreetName == "" || (address.BuildingName == "" && address.BuildingNumber == "")) {
+			v.fail(addressPath, "address_line or street_name with building_name or building_number is required")
+		}
+	}
+}
+
+func (v *validator) dateAndPlaceOfBirth(path string, birth *DateAndPlaceOfBirth) {
+	v.required(path+".place_of_birth", birth.PlaceOfBirth)
+	date, err := time.Parse("2006-01-02", birth.DateOfBirth)
+	if err != nil {
+		v.fail(path+".date_of_birth", "%q is not a YYYY-MM-DD date", birth.DateOfBirth)
+		return
+	}
+	if !date.Before(time.Now()) {
+		v.fail(path+".date_of_birth", "must be in the past")
+	}
+}
+
+// IsValidLEI reports whether lei is a 20 character ISO 17442 legal entity identifier with a valid checksum
+func IsValidLEI(lei string) bool {
+	if len(lei) != 20 {
+		return false
+	}
+	remainder := 0
+	for _, c := range lei {
+		var digit int
+		switch {
+		case c >= '0' && c <= '9':
+			digit = int(c - '0')
+		case c >= 'A' && c <= 'Z':
+			digit = int(c-'A') + 10
+		default:
+			return false
+		}
+		if digit >= 10 {
+			remainder = (remainder*100 + digit) % 97
+		} else {
+			remainder = (remainder*10 + digit) % 97
+		}
+	}
+	return remainder == 1
+}
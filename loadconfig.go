@@ -0,0 +1,342 @@
+package bridgeutil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"gopkg.in/yaml.v3"
+)
+
+// Sygna Bridge environments
+const (
+	EnvironmentProduction = "production"
+	EnvironmentTest       = "test"
+	EnvironmentDev        = "dev"
+	EnvironmentSandbox    = "sandbox"
+)
+
+// Private key sources
+const (
+	PrivateKeySourceHex      = "hex"
+	PrivateKeySourceFile     = "file"
+	PrivateKeySourceKeystore = "keystore"
+	PrivateKeySourcePlugin   = "plugin"
+)
+
+// Config is the configuration of a service talking to Sygna Bridge, see LoadConfig
+type Config struct {
+	// Environment selects the default domain and the Sygna Bridge public key
+	Environment string `yaml:"environment"`
+	// APIDomain overrides the domain of Environment
+	APIDomain  string           `yaml:"api_domain"`
+	APIKey     string           `yaml:"api_key"`
+	VASPCode   string           `yaml:"vasp_code"`
+	UserAgent  string           `yaml:"user_agent"`
+	PrivateKey PrivateKeyConfig `yaml:"private_key"`
+	// Timeout bounds each API call, 0 for no timeout
+	Timeout  time.Duration  `yaml:"timeout"`
+	Retry    RetryConfig    `yaml:"retry"`
+	Callback CallbackConfig `yaml:"callback"`
+}
+
+// PrivateKeyConfig is where the private key of the VASP comes from
+type PrivateKeyConfig struct {
+	// Source is one of hex, file, keystore or plugin; it is inferred when a single source is set
+	Source string `yaml:"source"`
+	// Hex is the hex private key
+	Hex string `yaml:"hex"`
+	// File holds the hex private key
+	File string `yaml:"file"`
+	// Keystore is a go-ethereum keystore file encrypted with Password
+	Keystore string `yaml:"keystore"`
+	Password string `yaml:"password"`
+	// Plugin is the command line of a signer plugin, see CommandSigner
+	Plugin []string `yaml:"plugin"`
+}
+
+// RetryConfig retries API calls failing with a network error or a 429, 502, 503 or 504 status.
+// 429 is not retried when a rate limit is set, see WithRetry.
+type RetryConfig struct {
+	// MaxAttempts includes the first attempt; 0 or 1 disables retries
+	MaxAttempts int           `yaml:"max_attempts"`
+	MinBackoff  time.Duration `yaml:"min_backoff"`
+	MaxBackoff  time.Duration `yaml:"max_backoff"`
+	// RetryPOST also retries POST calls, which may then be received twice
+	RetryPOST bool `yaml:"retry_post"`
+}
+
+// CallbackConfig configures the server receiving callbacks from Sygna Bridge
+type CallbackConfig struct {
+	ListenAddr   string        `yaml:"listen_addr"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+}
+
+var environmentDomains = map[string]string{
+	EnvironmentProduction: SygnaBridgeAPIDomain,
+	EnvironmentTest:       SygnaBridgeAPITestDomain,
+}
+
+var environmentPublicKeys = map[string]string{
+	EnvironmentProduction: SygnaBridgeCentralPubkey,
+	EnvironmentTest:       SygnaBridgeTestPubkey,
+	EnvironmentDev:        SygnaBridgeDevPubkey,
+	EnvironmentSandbox:    SygnaBridgeSandboxPubkey,
+}
+
+/*
+LoadConfig reads the YAML or JSON file at path, if path is not empty, then applies the
+SYGNA_* environment variables over it and validates the result:
+
+	SYGNA_ENVIRONMENT, SYGNA_API_DOMAIN, SYGNA_API_KEY, SYGNA_VASP_CODE, SYGNA_USER_AGENT,
+	SYGNA_PRIVATE_KEY, SYGNA_PRIVATE_KEY_FILE, SYGNA_KEYSTORE, SYGNA_KEYSTORE_PASSWORD,
+	SYGNA_SIGNER_PLUGIN, SYGNA_TIMEOUT, SYGNA_RETRY_MAX_ATTEMPTS, SYGNA_RETRY_MIN_BACKOFF,
+	SYGNA_RETRY_MAX_BACKOFF, SYGNA_RETRY_POST, SYGNA_CALLBACK_LISTEN_ADDR
+
+Durations are written like 10s or 1m30s, booleans like true or false. SYGNA_SIGNER_PLUGIN is split on whitespace, without quoting.
+*/
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{Environment: EnvironmentTest}
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// JSON is valid YAML
+		if err := yaml.Unmarshal(b, cfg); err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+		}
+	}
+	if err := cfg.applyEnv(os.Getenv); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) applyEnv(getenv func(string) string) error {
+	stringFields := map[string]*string{
+		"SYGNA_ENVIRONMENT":          &c.Environment,
+		"SYGNA_API_DOMAIN":           &c.APIDomain,
+		"SYGNA_API_KEY":              &c.APIKey,
+		"SYGNA_VASP_CODE":            &c.VASPCode,
+		"SYGNA_USER_AGENT":           &c.UserAgent,
+		"SYGNA_PRIVATE_KEY":          &c.PrivateKey.Hex,
+		"SYGNA_PRIVATE_KEY_FILE":     &c.PrivateKey.File,
+		"SYGNA_KEYSTORE":             &c.PrivateKey.Keystore,
+		"SYGNA_KEYSTORE_PASSWORD":    &c.PrivateKey.Password,
+		"SYGNA_CALLBACK_LISTEN_ADDR": &c.Callback.ListenAddr,
+	}
+	for name, field := range stringFields {
+		if v := getenv(name); v != "" {
+			*field = v
+		}
+	}
+	if v := getenv("SYGNA_SIGNER_PLUGIN"); v != "" {
+		c.PrivateKey.Plugin = strings.Fields(v)
+	}
+
+	durations := map[string]*time.Duration{
+		"SYGNA_TIMEOUT":           &c.Timeout,
+		"SYGNA_RETRY_MIN_BACKOFF": &c.Retry.MinBackoff,
+		"SYGNA_RETRY_MAX_BACKOFF": &c.Retry.MaxBackoff,
+	}
+	for name, field := range durations {
+		if v := getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*field = d
+		}
+	}
+	if v := getenv("SYGNA_RETRY_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid SYGNA_RETRY_MAX_ATTEMPTS: %w", err)
+		}
+		c.Retry.MaxAttempts = n
+	}
+	if v := getenv("SYGNA_RETRY_POST"); v != "" {
+		retryPOST, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid SYGNA_RETRY_POST: %w", err)
+		}
+		c.Retry.RetryPOST = retryPOST
+	}
+	return nil
+}
+
+// Validate checks the configuration, filling in the domain of the environment and the private key source
+func (c *Config) Validate() error {
+	var errs []error
+	if _, ok := environmentPublicKeys[c.Environment]; !ok {
+		errs = append(errs, fmt.Errorf("unknown environment %q", c.Environment))
+	}
+	if c.APIDomain == "" {
+		c.APIDomain = environmentDomains[c.Environment]
+	}
+	if c.APIDomain == "" {
+		errs = append(errs, fmt.Errorf("api_domain is required for the %s environment", c.Environment))
+	} else if u, err := url.Parse(c.APIDomain); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("invalid api_domain %q", c.APIDomain))
+	} else if !strings.HasSuffix(c.APIDomain, "/") {
+		c.APIDomain += "/"
+	}
+	if c.APIKey == "" {
+		errs = append(errs, errors.New("api_key is required"))
+	}
+	if err := c.PrivateKey.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, errors.New("timeout must not be negative"))
+	}
+	if c.Retry.MaxAttempts < 0 || c.Retry.MinBackoff < 0 || c.Retry.MaxBackoff < c.Retry.MinBackoff {
+		errs = append(errs, errors.New("retry needs max_attempts >= 0 and 0 <= min_backoff <= max_backoff"))
+	}
+	return errors.Join(errs...)
+}
+
+func (p *PrivateKeyConfig) validate() error {
+	var sources []string
+	for source, set := range map[string]bool{
+		PrivateKeySourceHex:      p.Hex != "",
+		PrivateKeySourceFile:     p.File != "",
+		PrivateKeySourceKeystore: p.Keystore != "",
+		PrivateKeySourcePlugin:   len(p.Plugin) > 0,
+	} {
+		if set {
+			sources = append(sources, source)
+		}
+	}
+	if p.Source == "" {
+		switch len(sources) {
+		case 0:
+			// the private key is optional for read only clients
+			return nil
+		case 1:
+			p.Source = sources[0]
+		default:
+			return errors.New("private_key has several sources, set private_key.source")
+		}
+	}
+
+	switch p.Source {
+	case PrivateKeySourceHex:
+		if _, err := PublicKeyFromPrivateKey(p.Hex); err != nil || len(p.Hex) != 64 {
+			return errors.New("private_key.hex must be 64 hex characters")
+		}
+	case PrivateKeySourceFile:
+		if p.File == "" {
+			return errors.New("private_key.file is required")
+		}
+	case PrivateKeySourceKeystore:
+		if p.Keystore == "" || p.Password == "" {
+			return errors.New("private_key.keystore and private_key.password are required")
+		}
+	case PrivateKeySourcePlugin:
+		if len(p.Plugin) == 0 {
+			return errors.New("private_key.plugin is required")
+		}
+	default:
+		return fmt.Errorf("unknown private_key.source %q", p.Source)
+	}
+	return nil
+}
+
+// BridgePublicKey returns the public key Sygna Bridge signs with in the environment, for Verify
+func (c *Config) BridgePublicKey() string {
+	return environmentPublicKeys[c.Environment]
+}
+
+// LoadPrivateKey returns the hex private key; it is not available from a signer plugin
+func (c *Config) LoadPrivateKey() (string, error) {
+	p := c.PrivateKey
+	switch p.Source {
+	case PrivateKeySourceHex:
+		return p.Hex, nil
+	case PrivateKeySourceFile:
+		b, err := os.ReadFile(p.File)
+		if err != nil {
+			return "", err
+		}
+		privateKey := strings.TrimSpace(string(b))
+		if _, err := PublicKeyFromPrivateKey(privateKey); err != nil {
+			return "", fmt.Errorf("invalid private key in %s: %w", p.File, err)
+		}
+		return privateKey, nil
+	case PrivateKeySourceKeystore:
+		b, err := os.ReadFile(p.Keystore)
+		if err != nil {
+			return "", err
+		}
+		key, err := keystore.DecryptKey(b, p.Password)
+		if err != nil {
+			return "", fmt.Errorf("cannot decrypt %s: %w", p.Keystore, err)
+		}
+		return fmt.Sprintf("%x", ethcrypto.FromECDSA(key.PrivateKey)), nil
+	case PrivateKeySourcePlugin:
+		return "", errors.New("the private key of a signer plugin is not available")
+	default:
+		return "", errors.New("no private key configured")
+	}
+}
+
+// Signer returns the Signer of the configured private key
+func (c *Config) Signer() (Signer, error) {
+	if c.PrivateKey.Source == PrivateKeySourcePlugin {
+		return &CommandSigner{Path: c.PrivateKey.Plugin[0], Args: c.PrivateKey.Plugin[1:]}, nil
+	}
+	privateKey, err := c.LoadPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return PrivateKeySigner(privateKey), nil
+}
+
+/*
+NewBridgeAPI returns a client using the domain, API key, user agent, timeout and retry policy of the
+configuration, which signs the request bodies with the configured private key, if any, see SigningMiddleware,
+and verifies the signed responses with the Sygna Bridge key of the environment.
+opts are applied after the configuration, e.g. to add a proxy, a rate limit or a logger.
+
+	api, err := cfg.NewBridgeAPI(bridgeutil.WithLogger(logger), bridgeutil.WithRateLimit(limit))
+*/
+func (c *Config) NewBridgeAPI(opts ...Option) (*BridgeAPI, error) {
+	base := []Option{WithAPIKey(c.APIKey), WithUserAgent(c.UserAgent), WithTimeout(c.Timeout), WithRetry(c.Retry)}
+	if c.APIDomain != "" {
+		base = append(base, WithBaseURL(c.APIDomain))
+	}
+	if key := c.BridgePublicKey(); key != "" {
+		base = append(base, WithBridgePublicKey(key))
+	}
+	if c.PrivateKey.Source != "" {
+		signer, err := c.Signer()
+		if err != nil {
+			return nil, err
+		}
+		base = append(base, WithMiddleware(SigningMiddleware(signer)))
+	}
+	return NewBridgeAPI(append(base, opts...)...)
+}
+
+// NewCallbackServer returns a server for the callbacks of Sygna Bridge on the configured listen address
+func (c *Config) NewCallbackServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         c.Callback.ListenAddr,
+		Handler:      handler,
+		ReadTimeout:  c.Callback.ReadTimeout,
+		WriteTimeout: c.Callback.WriteTimeout,
+	}
+}
@@ -0,0 +1,193 @@
+package bridgeutil
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iancoleman/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeFile(t, "sygna.yaml", `
+environment: production
+api_key: from-file
+vasp_code: VASPUSNY1
+timeout: 10s
+retry:
+  max_attempts: 3
+  min_backoff: 100ms
+  max_backoff: 2s
+callback:
+  listen_addr: ":9000"
+`)
+	t.Setenv("SYGNA_API_KEY", "from-env")
+	t.Setenv("SYGNA_PRIVATE_KEY", fakePrivateKey)
+
+	cfg, err := LoadConfig(path)
+	assert.Nil(t, err)
+	assert.Equal(t, SygnaBridgeAPIDomain, cfg.APIDomain)
+	assert.Equal(t, SygnaBridgeCentralPubkey, cfg.BridgePublicKey())
+	assert.Equal(t, "from-env", cfg.APIKey)
+	assert.Equal(t, 10*time.Second, cfg.Timeout)
+	assert.Equal(t, 2*time.Second, cfg.Retry.MaxBackoff)
+	assert.Equal(t, PrivateKeySourceHex, cfg.PrivateKey.Source)
+	assert.Equal(t, ":9000", cfg.NewCallbackServer(http.NotFoundHandler()).Addr)
+
+	signer, err := cfg.Signer()
+	assert.Nil(t, err)
+	message := StringToOrderedMap(`{"transfer_id":"b97903fd"}`)
+	assert.Nil(t, signer.Sign(message))
+	valid, _ := Verify(message, fakePublicKey)
+	assert.True(t, valid)
+}
+
+func TestLoadConfigJSONAndKeyFile(t *testing.T) {
+	keyFile := writeFile(t, "key", fakePrivateKey+"\n")
+	path := writeFile(t, "sygna.json", `{"environment":"sandbox","api_domain":"http://localhost:8080","api_key":"k","private_key":{"file":"`+keyFile+`"}}`)
+
+	cfg, err := LoadConfig(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:8080/", cfg.APIDomain)
+	assert.Equal(t, SygnaBridgeSandboxPubkey, cfg.BridgePublicKey())
+	privateKey, err := cfg.LoadPrivateKey()
+	assert.Nil(t, err)
+	assert.Equal(t, fakePrivateKey, privateKey)
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	var tests = []struct {
+		config   string
+		expected string
+	}{
+		{`environment: staging`, `unknown environment "staging"`},
+		{`environment: dev`, "api_domain is required for the dev environment"},
+		{`api_domain: "ftp://x"`, `invalid api_domain "ftp://x"`},
+		{`api_key: ""`, "api_key is required"},
+		{`private_key: {hex: "zz"}`, "private_key.hex must be 64 hex characters"},
+		{`private_key: {hex: "` + fakePrivateKey + `", file: key}`, "private_key has several sources"},
+		{`private_key: {keystore: key.json}`, "private_key.keystore and private_key.password are required"},
+		{`retry: {min_backoff: 2s, max_backoff: 1s}`, "retry needs"},
+	}
+	for _, test := range tests {
+		_, err := LoadConfig(writeFile(t, "sygna.yaml", test.config))
+		assert.ErrorContains(t, err, test.expected, test.config)
+	}
+
+	t.Setenv("SYGNA_API_KEY", "k")
+	t.Setenv("SYGNA_TIMEOUT", "ten seconds")
+	_, err := LoadConfig("")
+	assert.ErrorContains(t, err, "invalid SYGNA_TIMEOUT")
+}
+
+func TestConfigNewBridgeAPIRetries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"unavailable"}`))
+			return
+		}
+		assert.Equal(t, "k", r.Header.Get("X-Api-Key"))
+		w.Write([]byte(`{"supported_coins":[]}`))
+	}))
+	defer server.Close()
+
+	cfg := &Config{Environment: EnvironmentTest, APIDomain: server.URL, APIKey: "k", Retry: RetryConfig{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}}
+	assert.Nil(t, cfg.Validate())
+	api, err := cfg.NewBridgeAPI()
+	assert.Nil(t, err)
+	_, err = api.GetCurrencies(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	atomic.StoreInt32(&calls, 0)
+	_, err = api.PostRetry(StringToOrderedMap(`{"vasp_code":"VASPUSNY1"}`))
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	t.Setenv("SYGNA_RETRY_POST", "true")
+	assert.Nil(t, cfg.applyEnv(os.Getenv))
+	assert.True(t, cfg.Retry.RetryPOST)
+	api, err = cfg.NewBridgeAPI()
+	assert.Nil(t, err)
+	atomic.StoreInt32(&calls, 0)
+	_, err = api.PostRetry(StringToOrderedMap(`{"vasp_code":"VASPUSNY1"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	t.Setenv("SYGNA_RETRY_POST", "sometimes")
+	assert.ErrorContains(t, cfg.applyEnv(os.Getenv), "invalid SYGNA_RETRY_POST")
+}
+
+func TestConfigNewBridgeAPISignsAndTakesOptions(t *testing.T) {
+	var received *orderedmap.OrderedMap
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "settlement", r.Header.Get("X-Request-Source"))
+		assert.Equal(t, "my-exchange/1.0", r.Header.Get("User-Agent"))
+		b, _ := io.ReadAll(r.Body)
+		received = StringToOrderedMap(string(b))
+		w.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	cfg := &Config{Environment: EnvironmentTest, APIDomain: server.URL, APIKey: "k", UserAgent: "my-exchange/1.0", PrivateKey: PrivateKeyConfig{Hex: fakePrivateKey}}
+	assert.Nil(t, cfg.Validate())
+	api, err := cfg.NewBridgeAPI(WithHeader("X-Request-Source", "settlement"))
+	assert.Nil(t, err)
+	_, err = api.PostTransactionCancel(StringToOrderedMap(`{"transfer_id":"b97903fd"}`))
+	assert.Nil(t, err)
+	valid, err := Verify(received, fakePublicKey)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	_, err = cfg.NewBridgeAPI(WithTimeout(-time.Second))
+	assert.NotNil(t, err)
+}
+
+func TestCommandSigner(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	t.Setenv("SYGNA_API_KEY", "k")
+	t.Setenv("SYGNA_SIGNER_PLUGIN", "/usr/local/bin/hsm-signer --slot 1")
+	cfg, err := LoadConfig("")
+	assert.Nil(t, err)
+	assert.Equal(t, PrivateKeySourcePlugin, cfg.PrivateKey.Source)
+	s, err := cfg.Signer()
+	assert.Nil(t, err)
+	assert.Equal(t, &CommandSigner{Path: "/usr/local/bin/hsm-signer", Args: []string{"--slot", "1"}}, s)
+	_, err = cfg.LoadPrivateKey()
+	assert.NotNil(t, err)
+
+	signer := &CommandSigner{Path: "sh", Args: []string{"-c", "cat >/dev/null; echo a599a99d018f544701e3ae1217f783581a23228d23a5fe18ff96e9fb6471d75127943bd791e3d69495a787cc0a689b4777c875f5302bf116ee88ac27f5562b2a"}}
+	message := StringToOrderedMap(`{"transfer_id":"b97903fd68fcff05cfe035482bc3cf7fd934505b4e0644e612087dca4bae37e4","txid":"6f721fba0d405df21fb27dd76cfe2b548907f3881c5625b9cfe624c15c3178ae"}`)
+	assert.Nil(t, signer.Sign(message))
+	valid, _ := Verify(message, fakePublicKey)
+	assert.True(t, valid)
+
+	lowS, _ := message.Get("signature")
+
+	highS := &CommandSigner{Path: "sh", Args: []string{"-c", "cat >/dev/null; echo a599a99d018f544701e3ae1217f783581a23228d23a5fe18ff96e9fb6471d751d86bc4286e1c296b6a587833f59764b742e666f17f1caf24d149b264dae01617"}}
+	assert.Nil(t, highS.Sign(message))
+	signature, _ := message.Get("signature")
+	assert.Equal(t, lowS, signature, "high S is normalized")
+	valid, _ = Verify(message, fakePublicKey)
+	assert.True(t, valid)
+
+	assert.ErrorContains(t, (&CommandSigner{Path: "sh", Args: []string{"-c", "echo nope"}}).Sign(message), "invalid signature")
+	assert.ErrorContains(t, (&CommandSigner{Path: "sh", Args: []string{"-c", "echo broken >&2; exit 1"}}).Sign(message), "broken")
+}
@@ -0,0 +1,289 @@
+package bridgeutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/redact"
+	"github.com/iancoleman/orderedmap"
+)
+
+// secretKeys are attribute keys, compared case-insensitively, whose values are never logged
+var secretKeys = map[string]bool{
+	"x-api-key":    true,
+	"api_key":      true,
+	"apikey":       true,
+	"private_key":  true,
+	"privatekey":   true,
+	"private_info": true,
+	"password":     true,
+}
+
+// secretPolicy masks the secret fields nested in logged objects such as bodies and headers
+var secretPolicy = func() *redact.Policy {
+	p := &redact.Policy{Fields: map[string]redact.Action{}}
+	for k := range secretKeys {
+		p.Fields[k] = redact.Mask
+	}
+	p.Fields["X-Api-Key"] = redact.Mask
+	p.Fields["privateKey"] = redact.Mask
+	return p
+}()
+
+type scrubbingHandler struct {
+	h       slog.Handler
+	secrets []string
+}
+
+/*
+NewScrubbingHandler returns a slog.Handler which masks secrets before passing records to h:
+the values of attributes such as X-Api-Key, private_key and private_info, the same fields nested
+in logged objects, and every occurrence of secrets, e.g. the API key, in messages and string values.
+Objects such as bodies and headers are logged as redacted json.
+*/
+func NewScrubbingHandler(h slog.Handler, secrets ...string) slog.Handler {
+	var nonEmpty []string
+	for _, s := range secrets {
+		if s != "" {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	return &scrubbingHandler{h: h, secrets: nonEmpty}
+}
+
+func (s *scrubbingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.h.Enabled(ctx, level)
+}
+
+func (s *scrubbingHandler) Handle(ctx context.Context, r slog.Record) error {
+	scrubbed := slog.NewRecord(r.Time, r.Level, s.scrubString(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		scrubbed.AddAttrs(s.scrubAttr(a))
+		return true
+	})
+	return s.h.Handle(ctx, scrubbed)
+}
+
+func (s *scrubbingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	scrubbed := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		scrubbed[i] = s.scrubAttr(a)
+	}
+	return &scrubbingHandler{h: s.h.WithAttrs(scrubbed), secrets: s.secrets}
+}
+
+func (s *scrubbingHandler) WithGroup(name string) slog.Handler {
+	return &scrubbingHandler{h: s.h.WithGroup(name), secrets: s.secrets}
+}
+
+func (s *scrubbingHandler) scrubAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if secretKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redact.Masked)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, s.scrubString(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		scrubbed := make([]any, len(group))
+		for i, child := range group {
+			scrubbed[i] = s.scrubAttr(child)
+		}
+		return slog.Group(a.Key, scrubbed...)
+	case slog.KindAny:
+		return slog.String(a.Key, s.scrubAny(a.Value.Any()))
+	}
+	return a
+}
+
+func (s *scrubbingHandler) scrubAny(v any) string {
+	if err, ok := v.(error); ok {
+		return s.scrubString(err.Error())
+	}
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return s.scrubString(secretPolicy.String(v))
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "<unloggable>"
+	}
+	return s.scrubString(string(b))
+}
+
+func (s *scrubbingHandler) scrubString(str string) string {
+	for _, secret := range s.secrets {
+		str = strings.ReplaceAll(str, secret, redact.Masked)
+	}
+	return str
+}
+
+// WithLogger logs every call with logger: endpoint, method, status, duration and transfer id at
+// debug level, failures at warn level. X-Api-Key, private keys and private_info are never logged,
+// see NewScrubbingHandler. The logging middleware is the outermost one.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) error {
+		if logger == nil {
+			return errors.New("logger is nil")
+		}
+		o.logger = logger
+		return nil
+	}
+}
+
+// loggingMiddleware is added by NewBridgeAPI for WithLogger
+func loggingMiddleware(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ex *Exchange) (interface{}, error) {
+			start := time.Now()
+			response, err := next(ex)
+
+			attrs := []slog.Attr{
+				slog.String("endpoint", ex.Endpoint),
+				slog.String("method", ex.Method),
+				slog.Int("status", ex.StatusCode),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if transferID := exchangeTransferID(ex, response); transferID != "" {
+				attrs = append(attrs, slog.String("transfer_id", transferID))
+			}
+			switch {
+			case err == nil:
+				logger.LogAttrs(ex.Context, slog.LevelDebug, "sygna bridge call", attrs...)
+			case json.Valid([]byte(err.Error())):
+				// error bodies of Sygna Bridge may echo personal data, only the status is logged
+				logger.LogAttrs(ex.Context, slog.LevelWarn, "sygna bridge call failed", attrs...)
+			default:
+				logger.LogAttrs(ex.Context, slog.LevelWarn, "sygna bridge call failed", append(attrs, slog.Any("error", err))...)
+			}
+			return response, err
+		}
+	}
+}
+
+// exchangeTransferID returns the transfer id of the query, the body or the response
+func exchangeTransferID(ex *Exchange, response interface{}) string {
+	if id, ok := ex.Query["transfer_id"].(string); ok {
+		return id
+	}
+	if body, ok := ex.Body.(*orderedmap.OrderedMap); ok && body != nil {
+		if id := transferID(body); id != "" {
+			return id
+		}
+	}
+	if m, ok := response.(*orderedmap.OrderedMap); ok {
+		return transferID(m)
+	}
+	return ""
+}
+
+// transferID returns the transfer_id of a message or of its data
+func transferID(m *orderedmap.OrderedMap) string {
+	if m == nil {
+		return ""
+	}
+	if id, ok := m.Get("transfer_id"); ok {
+		s, _ := id.(string)
+		return s
+	}
+	data, _ := m.Get("data")
+	switch data := data.(type) {
+	case *orderedmap.OrderedMap:
+		return transferID(data)
+	case orderedmap.OrderedMap:
+		return transferID(&data)
+	}
+	return ""
+}
+
+var defaultLogger atomic.Pointer[slog.Logger]
+
+// SetLogger logs Encrypt, Decrypt, Sign and Verify with logger, nil stops logging.
+// Operations are logged at debug level and failures at warn level, never with keys or data.
+// Nothing is logged by default.
+func SetLogger(logger *slog.Logger) {
+	if logger != nil {
+		logger = slog.New(NewScrubbingHandler(logger.Handler()))
+	}
+	defaultLogger.Store(logger)
+}
+
+// logCrypto logs the outcome of a crypto operation when a logger is set
+func logCrypto(ctx context.Context, operation, transferID string, start time.Time, err error) {
+	logger := defaultLogger.Load()
+	if logger == nil {
+		return
+	}
+	attrs := []slog.Attr{slog.String("operation", operation), slog.Duration("duration", time.Since(start))}
+	if transferID != "" {
+		attrs = append(attrs, slog.String("transfer_id", transferID))
+	}
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelWarn, "sygna crypto operation failed", append(attrs, slog.Any("error", err))...)
+		return
+	}
+	logger.LogAttrs(ctx, slog.LevelDebug, "sygna crypto operation", attrs...)
+}
+
+// maxLoggedCallbackBody limits the callback body read to find the transfer id
+const maxLoggedCallbackBody = 1 << 20
+
+/*
+LoggingCallbackHandler logs every callback handled by h with logger: callback name, e.g.
+"permission-request", method, status, duration and transfer id. Bodies are never logged.
+
+	http.Handle("/permission-request", bridgeutil.LoggingCallbackHandler(logger, "permission-request", handler))
+*/
+func LoggingCallbackHandler(logger *slog.Logger, callback string, h http.Handler) http.Handler {
+	logger = slog.New(NewScrubbingHandler(logger.Handler()))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		var transferID string
+		if r.Body != nil {
+			b, err := io.ReadAll(io.LimitReader(r.Body, maxLoggedCallbackBody))
+			if err == nil {
+				transferID = callbackTransferID(b)
+			}
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(b), r.Body), r.Body}
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(sw, r)
+
+		attrs := []slog.Attr{
+			slog.String("callback", callback),
+			slog.String("method", r.Method),
+			slog.Int("status", sw.status),
+			slog.Duration("duration", time.Since(start)),
+		}
+		if transferID != "" {
+			attrs = append(attrs, slog.String("transfer_id", transferID))
+		}
+		level := slog.LevelInfo
+		if sw.status >= http.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+		logger.LogAttrs(r.Context(), level, "sygna callback", attrs...)
+	})
+}
+
+func callbackTransferID(body []byte) string {
+	m := orderedmap.New()
+	if len(body) == 0 || body[0] != '{' || m.UnmarshalJSON(body) != nil {
+		return ""
+	}
+	return transferID(m)
+}
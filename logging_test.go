@@ -0,0 +1,120 @@
+package bridgeutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const secretAPIKey = "3f5c6e2a-api-key"
+
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestScrubbingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewScrubbingHandler(slog.NewJSONHandler(&buf, nil), secretAPIKey))
+
+	body := StringToOrderedMap(`{"transfer_id":"b97903fd","private_info":"04a1b2","data":{"private_key":"` + fakePrivateKey + `"}}`)
+	header := http.Header{"X-Api-Key": {secretAPIKey}, "X-Tenant": {"a"}}
+	logger.With("api_key", secretAPIKey).Info("calling with "+secretAPIKey,
+		"X-Api-Key", secretAPIKey,
+		"body", body,
+		"header", header,
+		slog.Group("request", "private_info", "04a1b2", "url", "https://api.sygna.io/?key="+secretAPIKey),
+		"error", errors.New("rejected key "+secretAPIKey),
+	)
+
+	out := buf.String()
+	for _, secret := range []string{secretAPIKey, fakePrivateKey, "04a1b2"} {
+		assert.NotContains(t, out, secret)
+	}
+	assert.Contains(t, out, "b97903fd")
+	assert.Contains(t, out, "X-Tenant")
+	assert.Contains(t, out, `"msg":"calling with [REDACTED]"`)
+}
+
+func TestWithLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/bridge/transaction/permission" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid private_info of Wu Xinli"}`))
+			return
+		}
+		w.Write([]byte(`{"transfer_id":"b97903fd","transfer_status":"ACCEPTED"}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	api, err := NewBridgeAPI(WithBaseURL(server.URL), WithAPIKey(secretAPIKey), WithLogger(newTestLogger(&buf)))
+	assert.Nil(t, err)
+	_, err = api.GetStatus("b97903fd")
+	assert.Nil(t, err)
+	_, err = api.PostPermission(StringToOrderedMap(`{"transfer_id":"c1d2e3f4","private_info":"04a1b2"}`))
+	assert.NotNil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"level":"DEBUG"`)
+	assert.Contains(t, lines[0], `"endpoint":"GetStatus"`)
+	assert.Contains(t, lines[0], `"status":200`)
+	assert.Contains(t, lines[0], `"transfer_id":"b97903fd"`)
+	assert.Contains(t, lines[1], `"level":"WARN"`)
+	assert.Contains(t, lines[1], `"transfer_id":"c1d2e3f4"`)
+	for _, secret := range []string{secretAPIKey, "04a1b2", "Wu Xinli"} {
+		assert.NotContains(t, buf.String(), secret)
+	}
+
+	_, err = NewBridgeAPI(WithLogger(nil))
+	assert.NotNil(t, err)
+}
+
+func TestSetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(newTestLogger(&buf))
+	t.Cleanup(func() { SetLogger(nil) })
+
+	message := StringToOrderedMap(`{"transfer_id":"b97903fd"}`)
+	assert.Nil(t, Sign(message, fakePrivateKey))
+	message.Set("transfer_id", "tampered")
+	valid, err := Verify(message, fakePublicKey)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+	_, err = Decrypt("00", fakePrivateKey)
+	assert.NotNil(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, `"msg":"sygna crypto operation","operation":"sign"`)
+	assert.Contains(t, out, `"msg":"sygna signature is invalid","operation":"verify","transfer_id":"tampered"`)
+	assert.Contains(t, out, `"msg":"sygna crypto operation failed","operation":"decrypt"`)
+	assert.NotContains(t, out, fakePrivateKey)
+}
+
+func TestLoggingCallbackHandler(t *testing.T) {
+	var buf bytes.Buffer
+	body := `{"transfer_id":"b97903fd","data":{"private_info":"04a1b2"},"signature":"00"}`
+	handler := LoggingCallbackHandler(newTestLogger(&buf), "permission-request", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, body, string(b), "the handler still reads the whole body")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(body)))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	out := buf.String()
+	assert.Contains(t, out, `"callback":"permission-request"`)
+	assert.Contains(t, out, `"status":202`)
+	assert.Contains(t, out, `"transfer_id":"b97903fd"`)
+	assert.NotContains(t, out, "04a1b2")
+}
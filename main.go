@@ -1,42 +1,166 @@
 package bridgeutil
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/CoolBitX-Technology/sygna-bridge-util-go/crypto"
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/ivms101"
 	"github.com/iancoleman/orderedmap"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 //Encrypt Encrypt private info to hex string.
 func Encrypt(sensitiveData *orderedmap.OrderedMap, publicKey string) (string, error) {
+	return EncryptContext(context.Background(), sensitiveData, publicKey)
+}
+
+//EncryptContext Encrypt private info to hex string, in a telemetry span child of the span of ctx.
+func EncryptContext(ctx context.Context, sensitiveData *orderedmap.OrderedMap, publicKey string) (string, error) {
 	b, err := json.Marshal(sensitiveData)
 	if err != nil {
 		return "", err
 	}
-	return crypto.Encrypt(b, publicKey)
+	return instrumentCrypto(ctx, "encrypt", "", func(context.Context) (string, error) {
+		return crypto.Encrypt(b, publicKey)
+	})
 }
 
 //EncryptString Encrypt private info(string) to hex string.
 func EncryptString(sensitiveData, publicKey string) (string, error) {
 	b := []byte(sensitiveData)
-	return crypto.Encrypt(b, publicKey)
+	return instrumentCrypto(context.Background(), "encrypt", "", func(context.Context) (string, error) {
+		return crypto.Encrypt(b, publicKey)
+	})
+}
+
+//EncryptWithVersion Encrypt private info to hex string using the given envelope version.
+func EncryptWithVersion(sensitiveData *orderedmap.OrderedMap, publicKey string, version crypto.Version) (string, error) {
+	b, err := json.Marshal(sensitiveData)
+	if err != nil {
+		return "", err
+	}
+	return instrumentCrypto(context.Background(), "encrypt", "", func(context.Context) (string, error) {
+		return crypto.EncryptWithVersion(b, publicKey, version)
+	})
+}
+
+//EncryptStringWithVersion Encrypt private info(string) to hex string using the given envelope version.
+func EncryptStringWithVersion(sensitiveData, publicKey string, version crypto.Version) (string, error) {
+	return instrumentCrypto(context.Background(), "encrypt", "", func(context.Context) (string, error) {
+		return crypto.EncryptWithVersion([]byte(sensitiveData), publicKey, version)
+	})
+}
+
+//EncryptIVMS Validate IVMS101 private info and encrypt it to hex string.
+//Invalid payloads are rejected with ivms101.ValidationError and never encrypted.
+func EncryptIVMS(sensitiveData *ivms101.Payload, publicKey string) (string, error) {
+	if err := sensitiveData.Validate(); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(sensitiveData)
+	if err != nil {
+		return "", err
+	}
+	return instrumentCrypto(context.Background(), "encrypt", "", func(context.Context) (string, error) {
+		return crypto.Encrypt(b, publicKey)
+	})
+}
+
+//DecryptIVMS Decrypt IVMS101 private info from recipient server.
+func DecryptIVMS(encryptedData, privateKey string) (*ivms101.Payload, error) {
+	decrypted, err := instrumentCrypto(context.Background(), "decrypt", "", func(context.Context) ([]byte, error) {
+		return crypto.DecryptBytes(encryptedData, privateKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ivms101.Parse(decrypted)
 }
 
-//Decrypt Decrypt private info from recipient server.
+//Decrypt Decrypt private info from recipient server. The envelope version is detected automatically.
 func Decrypt(encryptedData, privateKey string) (interface{}, error) {
-	return crypto.Decrypt(encryptedData, privateKey)
+	return DecryptContext(context.Background(), encryptedData, privateKey)
+}
+
+//DecryptContext Decrypt private info from recipient server, in a telemetry span child of the span of ctx.
+func DecryptContext(ctx context.Context, encryptedData, privateKey string) (interface{}, error) {
+	return instrumentCrypto(ctx, "decrypt", "", func(context.Context) (interface{}, error) {
+		return crypto.Decrypt(encryptedData, privateKey)
+	})
 }
 
 //Sign Sign data with provided Private Key.
 func Sign(message *orderedmap.OrderedMap, privateKey string) error {
-	return crypto.Sign(message, privateKey)
+	return SignContext(context.Background(), message, privateKey)
+}
+
+//SignContext Sign data with provided Private Key, in a telemetry span child of the span of ctx.
+func SignContext(ctx context.Context, message *orderedmap.OrderedMap, privateKey string) error {
+	_, err := instrumentCrypto(ctx, "sign", transferID(message), func(context.Context) (struct{}, error) {
+		return struct{}{}, crypto.Sign(message, privateKey)
+	})
+	if err != nil {
+		recordSignatureFailure(ctx, "sign", transferID(message), err)
+	}
+	return err
 }
 
 //Verify Verify data with provided Public Key or default sygna bridge
 func Verify(message *orderedmap.OrderedMap, publicKey ...string) (bool, error) {
+	return VerifyContext(context.Background(), message, publicKey...)
+}
+
+//VerifyContext Verify data with provided Public Key or default sygna bridge, in a telemetry span
+//child of the span of ctx. An invalid signature sets the span status to error.
+func VerifyContext(ctx context.Context, message *orderedmap.OrderedMap, publicKey ...string) (bool, error) {
 	defaultPublicKey := SygnaBridgeCentralPubkey
 	if len(publicKey) > 0 {
 		defaultPublicKey = publicKey[0]
 	}
-	return crypto.Verify(message, defaultPublicKey)
+	valid, err := instrumentCrypto(ctx, "verify", transferID(message), func(ctx context.Context) (bool, error) {
+		valid, err := crypto.Verify(message, defaultPublicKey)
+		if err == nil && !valid {
+			trace.SpanFromContext(ctx).SetStatus(codes.Error, "signature is invalid")
+		}
+		return valid, err
+	})
+	if err != nil || !valid {
+		recordSignatureFailure(ctx, "verify", transferID(message), err)
+	}
+	return valid, err
+}
+
+//ParsePublicKey Parse hex Public Key once to reuse it for Verify and Encrypt.
+func ParsePublicKey(publicKey string) (*crypto.PublicKey, error) {
+	return crypto.ParsePublicKey(publicKey)
+}
+
+//EncryptMultiRecipient Encrypt private info for the beneficiary and additional recipients such as archive keys.
+func EncryptMultiRecipient(sensitiveData *orderedmap.OrderedMap, beneficiaryPublicKey string, additionalPublicKeys ...string) (*crypto.MultiRecipientCiphertext, error) {
+	b, err := json.Marshal(sensitiveData)
+	if err != nil {
+		return nil, err
+	}
+	return instrumentCrypto(context.Background(), "encrypt", "", func(context.Context) (*crypto.MultiRecipientCiphertext, error) {
+		return crypto.EncryptMultiRecipient(b, beneficiaryPublicKey, additionalPublicKeys...)
+	})
+}
+
+//DecryptMultiRecipient Decrypt whichever copy of multi-recipient private info the private key can open.
+func DecryptMultiRecipient(ciphertext *crypto.MultiRecipientCiphertext, privateKey string) (interface{}, error) {
+	return instrumentCrypto(context.Background(), "decrypt", "", func(context.Context) (interface{}, error) {
+		return crypto.DecryptMultiRecipient(ciphertext, privateKey)
+	})
+}
+
+//GenerateKeyPair Generate a hex Private Key and its uncompressed hex Public Key.
+func GenerateKeyPair() (string, string, error) {
+	return crypto.GenerateKeyPair()
+}
+
+//PublicKeyFromPrivateKey Derive the uncompressed hex Public Key of a hex Private Key.
+func PublicKeyFromPrivateKey(privateKey string) (string, error) {
+	return crypto.PublicKeyFromPrivateKey(privateKey)
 }
@@ -0,0 +1,42 @@
+package bridgeutil
+
+import (
+	"testing"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/ivms101"
+	"github.com/stretchr/testify/assert"
+)
+
+const fakePrivateKey = "ba4523e5091939113423a709b5924708af30fc5a958ac71f48eb030b84494702"
+const fakePublicKey = "04c1a0d4269ce2b0e1dab89e8defbfc9c0c780e6b769f1dba7cbc3531c8167ae7f0b49b1a36d574fd0cbb353f5d31152110daa541213cf0919c1be708a112163e3"
+
+func TestEncryptIVMS(t *testing.T) {
+	payload := &ivms101.Payload{
+		Originator: &ivms101.Originator{
+			OriginatorPersons: []ivms101.Person{{NaturalPerson: &ivms101.NaturalPerson{
+				Name: &ivms101.NaturalPersonName{NameIdentifiers: []ivms101.NaturalPersonNameIdentifier{
+					{PrimaryIdentifier: "Wu Xinli", NameIdentifierType: ivms101.NaturalPersonNameTypeLegal},
+				}},
+				CustomerIdentification: "1002390",
+			}}},
+		},
+		Beneficiary: &ivms101.Beneficiary{
+			BeneficiaryPersons: []ivms101.Person{{LegalPerson: &ivms101.LegalPerson{
+				Name: &ivms101.LegalPersonName{NameIdentifiers: []ivms101.LegalPersonNameIdentifier{
+					{LegalPersonName: "ABC Limited", LegalPersonNameIdentifierType: ivms101.LegalPersonNameTypeLegal},
+				}},
+			}}},
+		},
+	}
+
+	ciphertext, err := EncryptIVMS(payload, fakePublicKey)
+	assert.Nil(t, err)
+
+	decrypted, err := DecryptIVMS(ciphertext, fakePrivateKey)
+	assert.Nil(t, err)
+	assert.Equal(t, payload, decrypted, "should be equal")
+
+	payload.Beneficiary = nil
+	_, err = EncryptIVMS(payload, fakePublicKey)
+	assert.IsType(t, ivms101.ValidationError{}, err)
+}
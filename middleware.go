@@ -0,0 +1,285 @@
+package bridgeutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/iancoleman/orderedmap"
+)
+
+// ErrInjectedFault is returned by FaultInjectionMiddleware for faults without Err or StatusCode
+var ErrInjectedFault = errors.New("injected fault")
+
+// Exchange is a single call to Sygna Bridge as seen by middlewares.
+// Middlewares may change the request fields before calling the next Handler.
+type Exchange struct {
+	// Context is the context of the call, see BridgeAPI.WithContext. It is never nil.
+	Context context.Context
+	// Endpoint is the name of the BridgeAPI method, e.g. "PostPermissionRequest"
+	Endpoint string
+	Method   string
+	// Path is relative to APIDomain, e.g. "v2/bridge/transaction/permission-request"
+	Path  string
+	Query map[string]interface{}
+	// Body is the request body, nil for GET. It holds private info, redact it before logging.
+	Body interface{}
+	// Header is sent with the request, Content-Type, X-Api-Key and User-Agent are set afterwards
+	Header http.Header
+	// StatusCode is the HTTP status of the response, 0 until the request was sent
+	StatusCode int
+	// ResponseHeader is the header of the response, nil until the request was sent
+	ResponseHeader http.Header
+}
+
+// Handler performs an exchange and returns the parsed response
+type Handler func(ex *Exchange) (interface{}, error)
+
+// Middleware wraps a Handler, e.g. to add headers, observe the response or fail the call
+type Middleware func(next Handler) Handler
+
+// WithMiddleware adds middlewares around every call, the first one being the outermost
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(o *options) error {
+		for _, m := range middlewares {
+			if m == nil {
+				return errors.New("middleware is nil")
+			}
+		}
+		o.middlewares = append(o.middlewares, middlewares...)
+		return nil
+	}
+}
+
+// signedObjects lists, per endpoint, the objects of the request body signed by the sender.
+// An empty list means the body itself is signed.
+var signedObjects = map[string][]string{
+	"PostBeneficiaryEndpointURL":      nil,
+	"PostPermissionRequest":           {"data", "callback"},
+	"PostPermission":                  nil,
+	"PostTransactionID":               nil,
+	"PostTransactionCDDRequest":       nil,
+	"PostTransactionCDD":              nil,
+	"PostServerStatus":                nil,
+	"PostVASPBeneficiaryCheckingRule": nil,
+	"PostTransactionCancel":           nil,
+	"PostAddressValidation":           nil,
+}
+
+/*
+SigningMiddleware signs the request bodies which Sygna Bridge expects to be signed and which
+have no signature yet, e.g. the data and callback of PostPermissionRequest.
+Middlewares added before it see the unsigned body, middlewares added after it the signed one.
+
+	api, err := bridgeutil.NewBridgeAPI(
+		bridgeutil.WithMiddleware(auditUnsigned, bridgeutil.SigningMiddleware(signer), auditSigned),
+	)
+*/
+func SigningMiddleware(signer Signer) Middleware {
+	return func(next Handler) Handler {
+		return func(ex *Exchange) (interface{}, error) {
+			fields, ok := signedObjects[ex.Endpoint]
+			body, isMap := ex.Body.(*orderedmap.OrderedMap)
+			if !ok || !isMap || body == nil {
+				return next(ex)
+			}
+
+			if len(fields) == 0 {
+				if err := signIfUnsigned(signer, body); err != nil {
+					return nil, err
+				}
+				return next(ex)
+			}
+			for _, field := range fields {
+				value, _ := body.Get(field)
+				switch object := value.(type) {
+				case *orderedmap.OrderedMap:
+					if err := signIfUnsigned(signer, object); err != nil {
+						return nil, err
+					}
+				case orderedmap.OrderedMap:
+					if err := signIfUnsigned(signer, &object); err != nil {
+						return nil, err
+					}
+					body.Set(field, &object)
+				}
+			}
+			return next(ex)
+		}
+	}
+}
+
+func signIfUnsigned(signer Signer, message *orderedmap.OrderedMap) error {
+	if signature, _ := message.Get("signature"); signature != nil && signature != "" {
+		return nil
+	}
+	return signer.Sign(message)
+}
+
+/*
+LoggingMiddleware logs the endpoint, status and duration of every call with logf, e.g. log.Printf.
+Bodies are not logged; use ExchangeLogger with the redact package to audit them.
+Errors carrying a json response body are reduced to their status.
+*/
+func LoggingMiddleware(logf func(format string, args ...interface{})) Middleware {
+	return func(next Handler) Handler {
+		return func(ex *Exchange) (interface{}, error) {
+			start := time.Now()
+			response, err := next(ex)
+			duration := time.Since(start)
+			switch {
+			case err == nil:
+				logf("%s %s %s status=%d duration=%s", ex.Endpoint, ex.Method, ex.Path, ex.StatusCode, duration)
+			case json.Valid([]byte(err.Error())):
+				logf("%s %s %s status=%d duration=%s error", ex.Endpoint, ex.Method, ex.Path, ex.StatusCode, duration)
+			default:
+				logf("%s %s %s status=%d duration=%s error: %v", ex.Endpoint, ex.Method, ex.Path, ex.StatusCode, duration, err)
+			}
+			return response, err
+		}
+	}
+}
+
+// MetricsRecorder receives the outcome of every call
+type MetricsRecorder interface {
+	RecordExchange(endpoint string, statusCode int, duration time.Duration, err error)
+}
+
+// MetricsRecorderFunc adapts a function to MetricsRecorder
+type MetricsRecorderFunc func(endpoint string, statusCode int, duration time.Duration, err error)
+
+// RecordExchange implements MetricsRecorder
+func (f MetricsRecorderFunc) RecordExchange(endpoint string, statusCode int, duration time.Duration, err error) {
+	f(endpoint, statusCode, duration, err)
+}
+
+// MetricsMiddleware reports the endpoint, status, duration and error of every call to recorder
+func MetricsMiddleware(recorder MetricsRecorder) Middleware {
+	return func(next Handler) Handler {
+		return func(ex *Exchange) (interface{}, error) {
+			start := time.Now()
+			response, err := next(ex)
+			recorder.RecordExchange(ex.Endpoint, ex.StatusCode, time.Since(start), err)
+			return response, err
+		}
+	}
+}
+
+// EndpointStats are the counters of an endpoint collected by ExchangeMetrics
+type EndpointStats struct {
+	Calls         int64
+	Errors        int64
+	StatusCodes   map[int]int64
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
+
+// ExchangeMetrics is an in-memory MetricsRecorder, safe for concurrent use
+type ExchangeMetrics struct {
+	mu    sync.Mutex
+	stats map[string]*EndpointStats
+}
+
+// RecordExchange implements MetricsRecorder
+func (m *ExchangeMetrics) RecordExchange(endpoint string, statusCode int, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stats == nil {
+		m.stats = map[string]*EndpointStats{}
+	}
+	s, ok := m.stats[endpoint]
+	if !ok {
+		s = &EndpointStats{StatusCodes: map[int]int64{}}
+		m.stats[endpoint] = s
+	}
+	s.Calls++
+	if err != nil {
+		s.Errors++
+	}
+	if statusCode != 0 {
+		s.StatusCodes[statusCode]++
+	}
+	s.TotalDuration += duration
+	if duration > s.MaxDuration {
+		s.MaxDuration = duration
+	}
+}
+
+// Stats returns a copy of the counters keyed by endpoint
+func (m *ExchangeMetrics) Stats() map[string]EndpointStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make(map[string]EndpointStats, len(m.stats))
+	for endpoint, s := range m.stats {
+		c := *s
+		c.StatusCodes = make(map[int]int64, len(s.StatusCodes))
+		for code, n := range s.StatusCodes {
+			c.StatusCodes[code] = n
+		}
+		stats[endpoint] = c
+	}
+	return stats
+}
+
+// Fault is a failure injected by FaultInjectionMiddleware.
+// A fault with only Latency slows calls down, otherwise the call fails without being sent.
+type Fault struct {
+	// Endpoint restricts the fault to an endpoint such as "PostPermissionRequest", empty matches every endpoint
+	Endpoint string
+	// Rate is the fraction of matching calls affected, 1 affects every call
+	Rate float64
+	// Latency delays the call
+	Latency time.Duration
+	// Err is returned instead of the response
+	Err error
+	// StatusCode fails the call with a Sygna Bridge error response of that HTTP status
+	StatusCode int
+}
+
+func (f Fault) fails() bool {
+	return f.Err != nil || f.StatusCode != 0 || f.Latency == 0
+}
+
+// FaultInjectionMiddleware applies the first matching fault to calls, to test how the application
+// copes with a slow or failing Sygna Bridge
+func FaultInjectionMiddleware(faults ...Fault) Middleware {
+	return func(next Handler) Handler {
+		return func(ex *Exchange) (interface{}, error) {
+			for _, f := range faults {
+				if (f.Endpoint != "" && f.Endpoint != ex.Endpoint) || rand.Float64() >= f.Rate {
+					continue
+				}
+				if f.Latency > 0 {
+					timer := time.NewTimer(f.Latency)
+					select {
+					case <-timer.C:
+					case <-ex.Context.Done():
+						timer.Stop()
+						return nil, ex.Context.Err()
+					}
+				}
+				if !f.fails() {
+					break
+				}
+				switch {
+				case f.Err != nil:
+					return nil, f.Err
+				case f.StatusCode != 0:
+					ex.StatusCode = f.StatusCode
+					m := orderedmap.New()
+					m.Set("status", f.StatusCode)
+					m.Set("message", ErrInjectedFault.Error())
+					bMessage, _ := json.Marshal(m)
+					return nil, errors.New(string(bMessage))
+				default:
+					return nil, ErrInjectedFault
+				}
+			}
+			return next(ex)
+		}
+	}
+}
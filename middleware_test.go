@@ -0,0 +1,163 @@
+package bridgeutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iancoleman/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestAPI returns a BridgeAPI calling handler with the API key "k"
+func newTestAPI(t *testing.T, handler http.HandlerFunc, opts ...Option) *BridgeAPI {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	api, err := NewBridgeAPI(append([]Option{WithBaseURL(server.URL), WithAPIKey("k")}, opts...)...)
+	assert.Nil(t, err)
+	return api
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ex *Exchange) (interface{}, error) {
+				order = append(order, name+" before")
+				ex.Header.Set("X-Trace-"+name, "1")
+				response, err := next(ex)
+				order = append(order, name+" after")
+				return response, err
+			}
+		}
+	}
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1", r.Header.Get("X-Trace-outer"))
+		assert.Equal(t, "1", r.Header.Get("X-Trace-inner"))
+		assert.Equal(t, "k", r.Header.Get("X-Api-Key"))
+		w.Write([]byte(`{"supported_coins":[]}`))
+	}, WithMiddleware(trace("outer"), trace("inner")))
+
+	_, err := api.GetCurrencies(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"outer before", "inner before", "inner after", "outer after"}, order)
+}
+
+func TestSigningMiddleware(t *testing.T) {
+	var unsigned, signed []string
+	capture := func(bodies *[]string) Middleware {
+		return func(next Handler) Handler {
+			return func(ex *Exchange) (interface{}, error) {
+				b, _ := json.Marshal(ex.Body)
+				*bodies = append(*bodies, string(b))
+				return next(ex)
+			}
+		}
+	}
+	var received *orderedmap.OrderedMap
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		received = orderedmap.New()
+		assert.Nil(t, received.UnmarshalJSON(readAll(t, r)))
+		w.Write([]byte(`{"status":"OK"}`))
+	}, WithMiddleware(capture(&unsigned), SigningMiddleware(PrivateKeySigner(fakePrivateKey)), capture(&signed)))
+
+	body := StringToOrderedMap(`{"data":{"transfer_id":"b97903fd"},"callback":{"callback_url":"https://vasp/callback"}}`)
+	_, err := api.PostPermissionRequest(body)
+	assert.Nil(t, err)
+	assert.NotContains(t, unsigned[0], "signature")
+	assert.Contains(t, signed[0], "signature")
+	for _, field := range []string{"data", "callback"} {
+		value, _ := received.Get(field)
+		object := value.(orderedmap.OrderedMap)
+		valid, err := Verify(&object, fakePublicKey)
+		assert.Nil(t, err)
+		assert.True(t, valid, field)
+	}
+
+	body = StringToOrderedMap(`{"transfer_id":"b97903fd","signature":"presigned"}`)
+	_, err = api.PostTransactionID(body)
+	assert.Nil(t, err)
+	signature, _ := received.Get("signature")
+	assert.Equal(t, "presigned", signature, "signed bodies are left alone")
+
+	_, err = api.PostRetry(StringToOrderedMap(`{"vasp_code":"VASPUSNY1"}`))
+	assert.Nil(t, err)
+	_, signedRetry := received.Get("signature")
+	assert.False(t, signedRetry)
+}
+
+func TestLoggingAndMetricsMiddleware(t *testing.T) {
+	var lines []string
+	logf := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	metrics := &ExchangeMetrics{}
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/bridge/transaction/status" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"transfer b97903fd of Wu Xinli not found"}`))
+			return
+		}
+		w.Write([]byte(`{"supported_coins":[]}`))
+	}, WithMiddleware(LoggingMiddleware(logf), MetricsMiddleware(metrics)))
+
+	_, err := api.GetCurrencies(nil)
+	assert.Nil(t, err)
+	_, err = api.GetStatus("b97903fd")
+	assert.NotNil(t, err)
+
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "GetCurrencies GET v2/bridge/transaction/currencies status=200 duration="), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "GetStatus GET v2/bridge/transaction/status status=404 duration="), lines[1])
+	assert.NotContains(t, lines[1], "Wu Xinli")
+
+	stats := metrics.Stats()
+	assert.Equal(t, int64(1), stats["GetCurrencies"].Calls)
+	assert.Equal(t, int64(0), stats["GetCurrencies"].Errors)
+	assert.Equal(t, int64(1), stats["GetStatus"].Errors)
+	assert.Equal(t, map[int]int64{http.StatusNotFound: 1}, stats["GetStatus"].StatusCodes)
+}
+
+func TestFaultInjectionMiddleware(t *testing.T) {
+	var calls int32
+	errTimeout := errors.New("timeout")
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"status":"OK"}`))
+	}, WithMiddleware(FaultInjectionMiddleware(
+		Fault{Endpoint: "PostPermission", Rate: 1, Err: errTimeout},
+		Fault{Endpoint: "PostTransactionID", Rate: 1, StatusCode: http.StatusServiceUnavailable},
+		Fault{Endpoint: "PostRetry", Rate: 1},
+		Fault{Endpoint: "PostTransactionCancel", Rate: 1, Latency: 20 * time.Millisecond},
+		Fault{Rate: 0, Err: errTimeout},
+	)))
+
+	_, err := api.PostPermission(orderedmap.New())
+	assert.Equal(t, errTimeout, err)
+	_, err = api.PostTransactionID(orderedmap.New())
+	assert.Equal(t, `{"status":503,"message":"injected fault"}`, err.Error())
+	_, err = api.PostRetry(orderedmap.New())
+	assert.Equal(t, ErrInjectedFault, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+	start := time.Now()
+	_, err = api.PostTransactionCancel(orderedmap.New())
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	_, err = api.PostServerStatus(orderedmap.New())
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func readAll(t *testing.T, r *http.Request) []byte {
+	b, err := io.ReadAll(r.Body)
+	assert.Nil(t, err)
+	return b
+}
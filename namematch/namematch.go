@@ -0,0 +1,165 @@
+/*
+Package namematch compares IVMS101 name identifiers with the name of a customer
+record, to help a beneficiary VASP decide whether to reject a transfer with
+RejectCodeBVRC007.
+
+Names are lowercased, Cyrillic and Greek letters are transliterated to Latin,
+diacritics are stripped, punctuation is ignored and tokens may appear in any
+order. Legal form designations like "Ltd" are ignored for legal persons.
+Scripts without a transliteration, such as CJK, are compared as written, so
+records should be compared with the local_name_identifiers in that script.
+*/
+package namematch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/ivms101"
+)
+
+// DefaultThreshold is the score from which names are considered matched
+const DefaultThreshold = 0.9
+
+// Matcher compares IVMS101 names with customer records
+type Matcher struct {
+	// Threshold is the score between 0 and 1 from which names are considered matched
+	Threshold float64
+}
+
+// Result is the outcome of a comparison, suitable for an audit trail
+type Result struct {
+	// Score is the best similarity between 0 and 1 of any candidate name
+	Score float64
+	// Matched reports whether Score reached the threshold
+	Matched bool
+	// Candidate is the IVMS101 name which scored best
+	Candidate string
+	// Explanation describes how the score was obtained
+	Explanation []string
+}
+
+// NewMatcher returns a Matcher with the given threshold, or DefaultThreshold if it is not in (0, 1]
+func NewMatcher(threshold float64) *Matcher {
+	if threshold <= 0 || threshold > 1 {
+		threshold = DefaultThreshold
+	}
+	return &Matcher{Threshold: threshold}
+}
+
+// MatchPerson compares the names of an IVMS101 person with the record name
+func (m *Matcher) MatchPerson(person ivms101.Person, record string) Result {
+	switch {
+	case person.NaturalPerson != nil:
+		return m.MatchNaturalPerson(person.NaturalPerson, record)
+	case person.LegalPerson != nil:
+		return m.MatchLegalPerson(person.LegalPerson, record)
+	default:
+		return Result{Explanation: []string{"person has no natural_person or legal_person"}}
+	}
+}
+
+// MatchNaturalPerson compares every name identifier of a natural person with the record name
+func (m *Matcher) MatchNaturalPerson(person *ivms101.NaturalPerson, record string) Result {
+	var candidates []string
+	if person.Name != nil {
+		for _, identifier := range person.Name.NameIdentifiers {
+			candidates = append(candidates, joinName(identifier.PrimaryIdentifier, identifier.SecondaryIdentifier))
+		}
+		for _, identifier := range person.Name.LocalNameIdentifiers {
+			candidates = append(candidates, joinName(identifier.PrimaryIdentifier, identifier.SecondaryIdentifier))
+		}
+		for _, identifier := range person.Name.PhoneticNameIdentifiers {
+			candidates = append(candidates, joinName(identifier.PrimaryIdentifier, identifier.SecondaryIdentifier))
+		}
+	}
+	return m.best(candidates, record, false)
+}
+
+// MatchLegalPerson compares every name identifier of a legal person with the record name
+func (m *Matcher) MatchLegalPerson(person *ivms101.LegalPerson, record string) Result {
+	var candidates []string
+	if person.Name != nil {
+		for _, identifier := range person.Name.NameIdentifiers {
+			candidates = append(candidates, identifier.LegalPersonName)
+		}
+		for _, identifier := range person.Name.LocalNameIdentifiers {
+			candidates = append(candidates, identifier.LegalPersonName)
+		}
+		for _, identifier := range person.Name.PhoneticNameIdentifiers {
+			candidates = append(candidates, identifier.LegalPersonName)
+		}
+	}
+	return m.best(candidates, record, true)
+}
+
+// Compare returns the similarity of two names between 0 and 1 with an explanation
+func (m *Matcher) Compare(name, record string) (float64, []string) {
+	return compare(name, record, false)
+}
+
+func (m *Matcher) best(candidates []string, record string, legalPerson bool) Result {
+	if len(candidates) == 0 {
+		return Result{Explanation: []string{"person has no name identifiers"}}
+	}
+
+	result := Result{Score: -1}
+	for _, candidate := range candidates {
+		score, explanation := compare(candidate, record, legalPerson)
+		if score > result.Score {
+			result.Score, result.Candidate, result.Explanation = score, candidate, explanation
+		}
+	}
+	result.Matched = result.Score >= m.Threshold
+	verdict := "below"
+	if result.Matched {
+		verdict = "reached"
+	}
+	result.Explanation = append(result.Explanation,
+		fmt.Sprintf("best of %d candidate name(s) %q scored %.3f, %s threshold %.3f", len(candidates), result.Candidate, result.Score, verdict, m.Threshold))
+	return result
+}
+
+func compare(name, record string, legalPerson bool) (float64, []string) {
+	var explanation []string
+
+	nameTokens := tokenize(transliterate(name))
+	recordTokens := tokenize(transliterate(record))
+	explanation = append(explanation, fmt.Sprintf("normalized %q to %q and %q to %q",
+		name, strings.Join(nameTokens, " "), record, strings.Join(recordTokens, " ")))
+
+	if legalPerson {
+		stripped, strippedRecord := withoutLegalSuffixes(nameTokens), withoutLegalSuffixes(recordTokens)
+		if len(stripped) != len(nameTokens) || len(strippedRecord) != len(recordTokens) {
+			explanation = append(explanation, "ignored legal form designations")
+		}
+		nameTokens, recordTokens = stripped, strippedRecord
+	}
+
+	ordered := jaroWinkler(strings.Join(nameTokens, " "), strings.Join(recordTokens, " "))
+	reordered := jaroWinkler(sortedJoin(nameTokens), sortedJoin(recordTokens))
+	// names written without spaces, e.g. CJK or "XinliWu", are compared as a whole
+	compact := jaroWinkler(strings.Join(nameTokens, ""), strings.Join(recordTokens, ""))
+
+	score := ordered
+	switch {
+	case reordered > score && reordered >= compact:
+		score = reordered
+		explanation = append(explanation, "compared tokens in sorted order")
+	case compact > score:
+		score = compact
+		explanation = append(explanation, "compared names without separators")
+	}
+	return score, explanation
+}
+
+func joinName(primary, secondary string) string {
+	return strings.TrimSpace(primary + " " + secondary)
+}
+
+func sortedJoin(tokens []string) string {
+	sorted := append([]string{}, tokens...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, " ")
+}
@@ -0,0 +1,98 @@
+package namematch
+
+import (
+	"testing"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/ivms101"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransliterate(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected string
+	}{
+		{"José Müller-Lüdenscheidt", "jose muller-ludenscheidt"},
+		{"Дмитрий Шостакович", "dmitrii shostakovich"},
+		{"Νίκος Καζαντζάκης", "nikos kazantzakis"},
+		{"Straße Øresund", "strasse oresund"},
+		{"利昂內爾 梅西", "利昂內爾 梅西"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, transliterate(test.input), "should be equal")
+	}
+}
+
+func TestMatchNaturalPerson(t *testing.T) {
+	person := &ivms101.NaturalPerson{
+		Name: &ivms101.NaturalPersonName{
+			NameIdentifiers: []ivms101.NaturalPersonNameIdentifier{
+				{PrimaryIdentifier: "Wu", SecondaryIdentifier: "Xinli", NameIdentifierType: ivms101.NaturalPersonNameTypeLegal},
+			},
+			LocalNameIdentifiers: []ivms101.LocalNaturalPersonNameIdentifier{
+				{PrimaryIdentifier: "吳", SecondaryIdentifier: "欣黎", NameIdentifierType: ivms101.NaturalPersonNameTypeLegal},
+			},
+		},
+	}
+	matcher := NewMatcher(0)
+
+	var tests = []struct {
+		record  string
+		matched bool
+	}{
+		{"XINLI WU", true},
+		{"Wu, Xinli", true},
+		{"吳欣黎", true},
+		{"Wu Xinly", true},
+		{"Antoine Griezmann", false},
+	}
+
+	for _, test := range tests {
+		result := matcher.MatchNaturalPerson(person, test.record)
+		assert.Equal(t, test.matched, result.Matched, "record %q scored %v: %v", test.record, result.Score, result.Explanation)
+		assert.NotEmpty(t, result.Explanation)
+	}
+}
+
+func TestMatchLegalPerson(t *testing.T) {
+	person := ivms101.Person{LegalPerson: &ivms101.LegalPerson{
+		Name: &ivms101.LegalPersonName{NameIdentifiers: []ivms101.LegalPersonNameIdentifier{
+			{LegalPersonName: "ABC Limited", LegalPersonNameIdentifierType: ivms101.LegalPersonNameTypeLegal},
+		}},
+	}}
+
+	result := NewMatcher(0.95).MatchPerson(person, "A.B.C. Ltd")
+	assert.True(t, result.Matched)
+	assert.Contains(t, result.Explanation, "compared names without separators")
+
+	result = NewMatcher(0.95).MatchPerson(person, "abc ltd.")
+	assert.True(t, result.Matched)
+	assert.Equal(t, 1.0, result.Score)
+	assert.Contains(t, result.Explanation, "ignored legal form designations")
+}
+
+func TestMatchLegalPersonSuffixes(t *testing.T) {
+	var tests = []struct {
+		name, record string
+		matched      bool
+	}{
+		{"ABC Pte Ltd", "ABC", true},
+		{"ABC Company Limited", "ABC Ltd", true},
+		{"ABC Co", "ABC", false},
+		{"ABC AG", "ABC", false},
+		{"ABC SA", "ABC AB", false},
+		{"Limited Brands", "Brands", false},
+		{"Company Store", "Store", false},
+		{"ABC Pte Ltd Inc", "ABC", false},
+	}
+	for _, tt := range tests {
+		person := ivms101.Person{LegalPerson: &ivms101.LegalPerson{
+			Name: &ivms101.LegalPersonName{NameIdentifiers: []ivms101.LegalPersonNameIdentifier{
+				{LegalPersonName: tt.name, LegalPersonNameIdentifierType: ivms101.LegalPersonNameTypeLegal},
+			}},
+		}}
+		result := NewMatcher(0.95).MatchPerson(person, tt.record)
+		assert.Equal(t, tt.matched, result.Matched, "%s / %s scored %.3f", tt.name, tt.record, result.Score)
+	}
+}
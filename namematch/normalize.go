@@ -0,0 +1,80 @@
+package namematch
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// latinLetters are Latin letters which do not decompose into a base letter and diacritics
+var latinLetters = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th", 'ı': "i",
+}
+
+// cyrillicLetters transliterates Russian, Ukrainian and Belarusian Cyrillic letters (ICAO 9303)
+var cyrillicLetters = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'ґ': "g", 'д': "d", 'е': "e", 'ё': "e", 'є': "ie",
+	'ж': "zh", 'з': "z", 'и': "i", 'і': "i", 'ї': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ў': "u", 'ф': "f",
+	'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "ie", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "iu", 'я': "ia",
+}
+
+// greekLetters transliterates Greek letters (ELOT 743)
+var greekLetters = map[rune]string{
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i",
+	'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s",
+	'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// legalSuffixes are legal form designations ignored at the end of legal person names. Two-letter
+// forms such as "co", "ag" or "sa" are left out as they are also ordinary name tokens.
+var legalSuffixes = map[string]struct{}{
+	"ltd": {}, "limited": {}, "inc": {}, "incorporated": {}, "corp": {}, "corporation": {},
+	"company": {}, "llc": {}, "plc": {}, "gmbh": {}, "sas": {}, "srl": {}, "pte": {}, "pty": {},
+}
+
+// maxLegalSuffixes is the number of trailing legal form designations ignored, e.g. "pte ltd"
+const maxLegalSuffixes = 2
+
+// transliterate lowercases s, converts Cyrillic and Greek letters to Latin and
+// strips diacritics. Other scripts such as CJK are kept as they are.
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if latin, ok := latinLetters[r]; ok {
+			b.WriteString(latin)
+		} else if latin, ok := cyrillicLetters[r]; ok {
+			b.WriteString(latin)
+		} else if latin, ok := greekLetters[r]; ok {
+			b.WriteString(latin)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return norm.NFC.String(b.String())
+}
+
+// tokenize splits a transliterated name on anything which is not a letter or digit
+func tokenize(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// withoutLegalSuffixes drops up to maxLegalSuffixes trailing legal form designations such as "ltd"
+// from tokens, keeping at least one token
+func withoutLegalSuffixes(tokens []string) []string {
+	end := len(tokens)
+	for end > 1 && len(tokens)-end < maxLegalSuffixes {
+		if _, ok := legalSuffixes[tokens[end-1]]; !ok {
+			break
+		}
+		end--
+	}
+	return tokens[:end]
+}
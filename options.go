@@ -0,0 +1,295 @@
+package bridgeutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+const defaultUserAgent = "util-go"
+
+// Option configures the BridgeAPI returned by NewBridgeAPI
+type Option func(*options) error
+
+type options struct {
+	apiDomain          string
+	apiKey             string
+	bridgePublicKey    string
+	userAgent          string
+	headers            http.Header
+	exchangeLogger     ExchangeLogger
+	middlewares        []Middleware
+	logger             *slog.Logger
+	rateLimit          *RateLimit
+	endpointRateLimits map[string]RateLimit
+	circuitBreaker     *CircuitBreaker
+	httpClient         *http.Client
+	transport          http.RoundTripper
+	timeout            time.Duration
+	retry              *RetryConfig
+	proxy              *url.URL
+	rootCAs            *x509.CertPool
+	certificates       []tls.Certificate
+}
+
+// WithBaseURL sets the domain of Sygna Bridge, SygnaBridgeAPIDomain by default
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) error {
+		u, err := url.Parse(baseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid base url %q", baseURL)
+		}
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		o.apiDomain = baseURL
+		return nil
+	}
+}
+
+// WithBridgePublicKey sets the key verifying the responses signed by Sygna Bridge, e.g. the
+// BridgePublicKey of a Config, instead of the key chosen by the isProdEnv argument of GetVASP
+func WithBridgePublicKey(publicKey string) Option {
+	return func(o *options) error {
+		if _, err := ParsePublicKey(publicKey); err != nil {
+			return fmt.Errorf("invalid bridge public key: %w", err)
+		}
+		o.bridgePublicKey = publicKey
+		return nil
+	}
+}
+
+// WithAPIKey sets the API key sent in X-Api-Key
+func WithAPIKey(apiKey string) Option {
+	return func(o *options) error {
+		o.apiKey = apiKey
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header, "util-go" by default
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) error {
+		o.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithHeader adds a header to every request.
+// Content-Type, X-Api-Key and User-Agent are always set by the client and cannot be overridden.
+func WithHeader(key, value string) Option {
+	return func(o *options) error {
+		if o.headers == nil {
+			o.headers = http.Header{}
+		}
+		o.headers.Add(key, value)
+		return nil
+	}
+}
+
+// WithExchangeLogger sets the ExchangeLogger called after every API call
+func WithExchangeLogger(logger ExchangeLogger) Option {
+	return func(o *options) error {
+		o.exchangeLogger = logger
+		return nil
+	}
+}
+
+// WithHTTPClient sends the requests with the transport, jar, redirect policy and timeout of client.
+// It cannot be combined with WithTransport, WithProxy, WithRootCAs or WithClientCertificate,
+// configure those on the client instead.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) error {
+		if client == nil {
+			return errors.New("http client is nil")
+		}
+		o.httpClient = client
+		return nil
+	}
+}
+
+// WithTransport sends the requests with transport.
+// It cannot be combined with WithHTTPClient, WithProxy, WithRootCAs or WithClientCertificate,
+// configure those on the transport instead.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) error {
+		if transport == nil {
+			return errors.New("transport is nil")
+		}
+		o.transport = transport
+		return nil
+	}
+}
+
+// WithTimeout sets the time limit of a call, including its retries and reading the response.
+// Waiting for WithRateLimit is not included, bound it with the context of WithContext.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		if timeout < 0 {
+			return fmt.Errorf("invalid timeout %v", timeout)
+		}
+		o.timeout = timeout
+		return nil
+	}
+}
+
+// WithRetry retries calls failing with a network error or a 429, 502, 503 or 504 status, see RetryConfig.
+// With WithRateLimit or WithEndpointRateLimit, 429 is not retried but paces the next calls instead.
+func WithRetry(retry RetryConfig) Option {
+	return func(o *options) error {
+		if retry.MaxAttempts < 0 || retry.MinBackoff < 0 || retry.MaxBackoff < retry.MinBackoff {
+			return fmt.Errorf("invalid retry %+v", retry)
+		}
+		o.retry = &retry
+		return nil
+	}
+}
+
+// WithProxy sends the requests through proxyURL, e.g. http://proxy.internal:3128.
+// Without it the proxy is read from HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+func WithProxy(proxyURL string) Option {
+	return func(o *options) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid proxy url %q", proxyURL)
+		}
+		o.proxy = u
+		return nil
+	}
+}
+
+// WithRootCAs verifies the certificate of Sygna Bridge against pool instead of the system roots
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(o *options) error {
+		if pool == nil {
+			return errors.New("root CA pool is nil")
+		}
+		o.rootCAs = pool
+		return nil
+	}
+}
+
+// WithClientCertificate presents cert to Sygna Bridge for mutual TLS
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(o *options) error {
+		o.certificates = append(o.certificates, cert)
+		return nil
+	}
+}
+
+/*
+NewBridgeAPI returns a BridgeAPI configured by opts.
+The returned BridgeAPI is safe for concurrent use as long as its fields are not modified.
+
+	api, err := bridgeutil.NewBridgeAPI(
+		bridgeutil.WithAPIKey(apiKey),
+		bridgeutil.WithTimeout(10*time.Second),
+		bridgeutil.WithRootCAs(pool),
+	)
+*/
+func NewBridgeAPI(opts ...Option) (*BridgeAPI, error) {
+	o := &options{apiDomain: SygnaBridgeAPIDomain}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := o.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	api := &BridgeAPI{
+		APIDomain:       o.apiDomain,
+		APIKey:          o.apiKey,
+		UserAgent:       o.userAgent,
+		ExchangeLogger:  o.exchangeLogger,
+		bridgePublicKey: o.bridgePublicKey,
+		timeout:         o.timeout,
+		headers:         o.headers,
+		middlewares:     o.middlewares,
+	}
+	if o.circuitBreaker != nil {
+		api.middlewares = append(api.middlewares, o.circuitBreaker.Middleware())
+	}
+	if o.rateLimit != nil || len(o.endpointRateLimits) > 0 {
+		var global *limiter
+		if o.rateLimit != nil {
+			global = newLimiter(*o.rateLimit)
+		}
+		endpoints := make(map[string]*limiter, len(o.endpointRateLimits))
+		for endpoint, limit := range o.endpointRateLimits {
+			endpoints[endpoint] = newLimiter(limit)
+		}
+		api.middlewares = append(api.middlewares, rateLimitMiddleware(global, endpoints))
+	}
+	if o.logger != nil {
+		logger := slog.New(NewScrubbingHandler(o.logger.Handler(), o.apiKey))
+		api.middlewares = append([]Middleware{loggingMiddleware(logger)}, api.middlewares...)
+	}
+	api.setClient(client)
+	return api, nil
+}
+
+func (o *options) newClient() (*req.Client, error) {
+	customTLS := o.proxy != nil || o.rootCAs != nil || len(o.certificates) > 0
+	if o.httpClient != nil && o.transport != nil {
+		return nil, errors.New("WithHTTPClient cannot be combined with WithTransport")
+	}
+	if (o.httpClient != nil || o.transport != nil) && customTLS {
+		return nil, errors.New("WithProxy, WithRootCAs and WithClientCertificate cannot be combined with WithHTTPClient or WithTransport")
+	}
+
+	client := req.C()
+	switch {
+	case o.httpClient != nil:
+		*client.GetClient() = *o.httpClient
+		if client.GetClient().Transport == nil {
+			client.GetClient().Transport = http.DefaultTransport
+		}
+	case o.transport != nil:
+		client.GetClient().Transport = o.transport
+	}
+
+	if o.proxy != nil {
+		client.SetProxy(http.ProxyURL(o.proxy))
+	}
+	if o.rootCAs != nil {
+		client.GetTLSClientConfig().RootCAs = o.rootCAs
+	}
+	if len(o.certificates) > 0 {
+		client.SetCerts(o.certificates...)
+	}
+	if o.retry != nil && o.retry.MaxAttempts > 1 {
+		retryPOST := o.retry.RetryPOST
+		// a rate limit handles 429 itself, retrying here would skip its token bucket and Retry-After
+		retryTooManyRequests := o.rateLimit == nil && len(o.endpointRateLimits) == 0
+		client.SetCommonRetryCount(o.retry.MaxAttempts-1).
+			SetCommonRetryBackoffInterval(o.retry.MinBackoff, o.retry.MaxBackoff).
+			SetCommonRetryCondition(func(resp *req.Response, err error) bool {
+				if resp != nil && resp.Request != nil && resp.Request.Method == http.MethodPost && !retryPOST {
+					return false
+				}
+				if err != nil {
+					return true
+				}
+				switch resp.StatusCode {
+				case http.StatusTooManyRequests:
+					return retryTooManyRequests
+				case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+					return true
+				}
+				return false
+			})
+	}
+	return client, nil
+}
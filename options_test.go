@@ -0,0 +1,193 @@
+package bridgeutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func currenciesHandler(check func(r *http.Request)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if check != nil {
+			check(r)
+		}
+		w.Write([]byte(`{"supported_coins":[]}`))
+	})
+}
+
+func TestNewBridgeAPIHeaders(t *testing.T) {
+	server := httptest.NewServer(currenciesHandler(func(r *http.Request) {
+		assert.Equal(t, "/v2/bridge/transaction/currencies", r.URL.Path)
+		assert.Equal(t, "k", r.Header.Get("X-Api-Key"))
+		assert.Equal(t, "exchange/1.0", r.Header.Get("User-Agent"))
+		assert.Equal(t, "tenant-a", r.Header.Get("X-Tenant"))
+	}))
+	defer server.Close()
+
+	api, err := NewBridgeAPI(
+		WithBaseURL(server.URL),
+		WithAPIKey("k"),
+		WithUserAgent("exchange/1.0"),
+		WithHeader("X-Tenant", "tenant-a"),
+		WithHeader("X-Api-Key", "ignored"),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, server.URL+"/", api.APIDomain)
+	_, err = api.GetCurrencies(nil)
+	assert.Nil(t, err)
+}
+
+func TestNewBridgeAPITransport(t *testing.T) {
+	var calls int32
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return http.DefaultTransport.RoundTrip(r)
+	})
+	server := httptest.NewServer(currenciesHandler(nil))
+	defer server.Close()
+
+	api, err := NewBridgeAPI(WithBaseURL(server.URL), WithTransport(transport))
+	assert.Nil(t, err)
+	_, err = api.GetCurrencies(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	api, err = NewBridgeAPI(WithBaseURL(server.URL), WithHTTPClient(&http.Client{Transport: transport}))
+	assert.Nil(t, err)
+	_, err = api.GetCurrencies(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestNewBridgeAPITimeout(t *testing.T) {
+	server := httptest.NewServer(currenciesHandler(func(r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	api, err := NewBridgeAPI(WithBaseURL(server.URL), WithTimeout(20*time.Millisecond))
+	assert.Nil(t, err)
+	_, err = api.GetCurrencies(nil)
+	assert.NotNil(t, err)
+}
+
+func TestNewBridgeAPITimeoutIncludesRetries(t *testing.T) {
+	var calls int32
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(30 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, WithTimeout(50*time.Millisecond), WithRetry(RetryConfig{MaxAttempts: 5, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}))
+
+	start := time.Now()
+	_, err := api.GetCurrencies(nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestNewBridgeAPIMutualTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(currenciesHandler(func(r *http.Request) {
+		assert.Len(t, r.TLS.PeerCertificates, 1)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	clientCert := server.TLS.Certificates[0]
+
+	api, err := NewBridgeAPI(WithBaseURL(server.URL), WithRootCAs(pool), WithClientCertificate(clientCert))
+	assert.Nil(t, err)
+	_, err = api.GetCurrencies(nil)
+	assert.Nil(t, err)
+
+	api, err = NewBridgeAPI(WithBaseURL(server.URL), WithClientCertificate(clientCert))
+	assert.Nil(t, err)
+	_, err = api.GetCurrencies(nil)
+	assert.NotNil(t, err, "server certificate is not trusted without the pool")
+
+	api, err = NewBridgeAPI(WithBaseURL(server.URL), WithRootCAs(pool))
+	assert.Nil(t, err)
+	_, err = api.GetCurrencies(nil)
+	assert.NotNil(t, err, "server requires a client certificate")
+}
+
+func TestNewBridgeAPIInvalidOptions(t *testing.T) {
+	var tests = []struct {
+		name string
+		opts []Option
+	}{
+		{"relative base url", []Option{WithBaseURL("api.sygna.io")}},
+		{"nil http client", []Option{WithHTTPClient(nil)}},
+		{"nil transport", []Option{WithTransport(nil)}},
+		{"negative timeout", []Option{WithTimeout(-time.Second)}},
+		{"invalid proxy", []Option{WithProxy("proxy:3128")}},
+		{"nil root CAs", []Option{WithRootCAs(nil)}},
+		{"client and transport", []Option{WithHTTPClient(http.DefaultClient), WithTransport(http.DefaultTransport)}},
+		{"transport and proxy", []Option{WithTransport(http.DefaultTransport), WithProxy("http://proxy:3128")}},
+		{"client and root CAs", []Option{WithHTTPClient(http.DefaultClient), WithRootCAs(x509.NewCertPool())}},
+		{"invalid bridge public key", []Option{WithBridgePublicKey("04zz")}},
+	}
+	for _, tt := range tests {
+		_, err := NewBridgeAPI(tt.opts...)
+		assert.NotNil(t, err, tt.name)
+	}
+
+	api, err := NewBridgeAPI()
+	assert.Nil(t, err)
+	assert.Equal(t, SygnaBridgeAPIDomain, api.APIDomain)
+}
+
+func TestWithBridgePublicKey(t *testing.T) {
+	response := StringToOrderedMap(`{"vasp_data":[{"vasp_code":"VASPUSNY1","vasp_pubkey":"` + fakePublicKey + `"}]}`)
+	assert.Nil(t, Sign(response, fakePrivateKey))
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := OrderedMapToString(response)
+		w.Write([]byte(b))
+	}, WithBridgePublicKey(fakePublicKey))
+
+	publicKey, err := api.GetVASPPublicKey("VASPUSNY1", true)
+	assert.Nil(t, err)
+	assert.Equal(t, fakePublicKey, publicKey)
+	_, err = api.WithContext(context.Background()).GetVASP(true, true)
+	assert.Nil(t, err, "the key overrides isProdEnv")
+}
+
+func TestBridgeAPIConcurrentUse(t *testing.T) {
+	server := httptest.NewServer(currenciesHandler(func(r *http.Request) {
+		assert.Equal(t, defaultUserAgent, r.Header.Get("User-Agent"))
+	}))
+	defer server.Close()
+
+	api := &BridgeAPI{APIDomain: server.URL + "/", APIKey: "k"}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := api.GetCurrencies(nil)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, "", api.UserAgent)
+}
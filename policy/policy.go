@@ -0,0 +1,210 @@
+/*
+Package policy decides whether a withdrawal needs a Sygna Bridge permission request,
+according to the travel rule threshold of the originator's jurisdiction.
+*/
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/currency"
+	"github.com/iancoleman/orderedmap"
+)
+
+// Fields of the originator and beneficiary information a jurisdiction may require
+const (
+	OriginatorName                   = "originator.name"
+	OriginatorAccountNumber          = "originator.account_number"
+	OriginatorGeographicAddress      = "originator.geographic_address"
+	OriginatorNationalIdentification = "originator.national_identification"
+	OriginatorCustomerIdentification = "originator.customer_identification"
+	OriginatorDateAndPlaceOfBirth    = "originator.date_and_place_of_birth"
+	BeneficiaryName                  = "beneficiary.name"
+	BeneficiaryAccountNumber         = "beneficiary.account_number"
+)
+
+// SunriseAction is what to do when the beneficiary's jurisdiction does not enforce the travel rule yet
+type SunriseAction string
+
+const (
+	//SunriseSend send the permission request anyway, the beneficiary VASP may still be on Sygna Bridge
+	SunriseSend SunriseAction = "SEND"
+	//SunriseProceed proceed with the withdrawal without a permission request
+	SunriseProceed SunriseAction = "PROCEED"
+	//SunriseReject reject the withdrawal
+	SunriseReject SunriseAction = "REJECT"
+)
+
+// ErrUnknownJurisdiction is returned when a jurisdiction has no rule and the policy has no default
+var ErrUnknownJurisdiction = errors.New("no travel rule for jurisdiction")
+
+// RateProvider returns the price of one unit of a currency in a fiat currency such as "USD"
+type RateProvider interface {
+	Rate(ctx context.Context, currencyID string, fiat string) (*big.Rat, error)
+}
+
+// RateFunc adapts a function to a RateProvider
+type RateFunc func(ctx context.Context, currencyID string, fiat string) (*big.Rat, error)
+
+// Rate calls f
+func (f RateFunc) Rate(ctx context.Context, currencyID string, fiat string) (*big.Rat, error) {
+	return f(ctx, currencyID, fiat)
+}
+
+// Rule is the travel rule of a jurisdiction
+type Rule struct {
+	// Enforced reports whether the travel rule is in force in the jurisdiction
+	Enforced bool
+	// Threshold is the value from which a transfer is required, in Fiat; 0 requires it for every transfer
+	Threshold currency.Amount
+	// Fiat is the currency of Threshold, e.g. "USD" or "EUR"
+	Fiat string
+	// RequiredFields are required for transfers at or above the threshold
+	RequiredFields []string
+	// BelowThresholdFields are required for transfers below the threshold, which need no Sygna transfer
+	BelowThresholdFields []string
+	// Sunrise is applied when the beneficiary's jurisdiction does not enforce the travel rule
+	Sunrise SunriseAction
+}
+
+// Policy holds the rules of jurisdictions keyed by ISO 3166 country code
+type Policy struct {
+	Rules map[string]Rule
+	// Default applies to jurisdictions without a rule; nil makes them an error
+	Default *Rule
+	Rates   RateProvider
+}
+
+// New returns a policy with rules and rates
+func New(rules map[string]Rule, rates RateProvider) *Policy {
+	return &Policy{Rules: rules, Rates: rates}
+}
+
+// Transfer is a withdrawal to evaluate
+type Transfer struct {
+	CurrencyID string
+	Amount     currency.Amount
+	// OriginatorJurisdiction and BeneficiaryJurisdiction are the countries of the VASPs.
+	// An empty BeneficiaryJurisdiction is treated as unknown, which triggers the sunrise fallback.
+	OriginatorJurisdiction  string
+	BeneficiaryJurisdiction string
+}
+
+// Decision is the result of evaluating a transfer
+type Decision struct {
+	// Required reports whether a Sygna transfer (PostPermissionRequest) is required
+	Required bool
+	// Value is the value of the transfer in Fiat, nil when the rate was not needed
+	Value *big.Rat
+	Fiat  string
+	// RequiredFields are the fields the originator VASP must collect
+	RequiredFields []string
+	// SunriseFallback reports whether the beneficiary's jurisdiction does not enforce the travel rule,
+	// in which case Sunrise should be applied
+	SunriseFallback bool
+	Sunrise         SunriseAction
+}
+
+func (p *Policy) rule(jurisdiction string) (Rule, bool) {
+	if rule, ok := p.Rules[strings.ToUpper(jurisdiction)]; ok {
+		return rule, true
+	}
+	if p.Default != nil {
+		return *p.Default, true
+	}
+	return Rule{}, false
+}
+
+// Evaluate decides whether the transfer requires a Sygna transfer under the rule of the originator's jurisdiction
+func (p *Policy) Evaluate(ctx context.Context, t Transfer) (*Decision, error) {
+	if _, err := currency.ParseID(t.CurrencyID); err != nil {
+		return nil, err
+	}
+	rule, ok := p.rule(t.OriginatorJurisdiction)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownJurisdiction, t.OriginatorJurisdiction)
+	}
+	decision := &Decision{Fiat: rule.Fiat}
+	if !rule.Enforced {
+		return decision, nil
+	}
+
+	if rule.Threshold.IsZero() {
+		decision.Required = true
+	} else {
+		if p.Rates == nil {
+			return nil, errors.New("policy has no rate provider")
+		}
+		rate, err := p.Rates.Rate(ctx, t.CurrencyID, rule.Fiat)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s rate of %s: %w", rule.Fiat, t.CurrencyID, err)
+		}
+		decision.Value = new(big.Rat).Mul(t.Amount.Rat(), rate)
+		decision.Required = decision.Value.Cmp(rule.Threshold.Rat()) >= 0
+	}
+
+	if !decision.Required {
+		decision.RequiredFields = append([]string(nil), rule.BelowThresholdFields...)
+		return decision, nil
+	}
+	decision.RequiredFields = append([]string(nil), rule.RequiredFields...)
+
+	beneficiaryRule, ok := p.rule(t.BeneficiaryJurisdiction)
+	if t.BeneficiaryJurisdiction == "" || !ok || !beneficiaryRule.Enforced {
+		decision.SunriseFallback = true
+		decision.Sunrise = rule.Sunrise
+	}
+	return decision, nil
+}
+
+// EvaluateTransaction evaluates the currency_id and amount of a permission request transaction
+func (p *Policy) EvaluateTransaction(ctx context.Context, transaction *orderedmap.OrderedMap, originatorJurisdiction, beneficiaryJurisdiction string) (*Decision, error) {
+	currencyID, _ := transaction.Get("currency_id")
+	strCurrencyID, ok := currencyID.(string)
+	if !ok {
+		return nil, errors.New("transaction must contain currency_id")
+	}
+	amount, _ := transaction.Get("amount")
+	strAmount, ok := amount.(string)
+	if !ok {
+		return nil, errors.New("transaction must contain amount")
+	}
+	parsedAmount, err := currency.ParseAmount(strAmount)
+	if err != nil {
+		return nil, err
+	}
+	return p.Evaluate(ctx, Transfer{
+		CurrencyID:              strCurrencyID,
+		Amount:                  parsedAmount,
+		OriginatorJurisdiction:  originatorJurisdiction,
+		BeneficiaryJurisdiction: beneficiaryJurisdiction,
+	})
+}
+
+// FATFRule returns the FATF recommendation 16 rule with a threshold of 1000 in fiat
+func FATFRule(fiat string) Rule {
+	return Rule{
+		Enforced:  true,
+		Threshold: currency.MustParseAmount("1000"),
+		Fiat:      fiat,
+		RequiredFields: []string{
+			OriginatorName,
+			OriginatorAccountNumber,
+			OriginatorGeographicAddress,
+			OriginatorNationalIdentification,
+			BeneficiaryName,
+			BeneficiaryAccountNumber,
+		},
+		BelowThresholdFields: []string{
+			OriginatorName,
+			OriginatorAccountNumber,
+			BeneficiaryName,
+			BeneficiaryAccountNumber,
+		},
+		Sunrise: SunriseSend,
+	}
+}
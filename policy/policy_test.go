@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/currency"
+	"github.com/iancoleman/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+var rates = RateFunc(func(ctx context.Context, currencyID string, fiat string) (*big.Rat, error) {
+	prices := map[string]string{
+		"sygna:0x80000000/USD": "60000",
+		"sygna:0x80000000/EUR": "55000",
+		"sygna:0x80000090/USD": "0.5",
+	}
+	price, ok := prices[currencyID+"/"+fiat]
+	if !ok {
+		return nil, errors.New("no rate")
+	}
+	rate, _ := new(big.Rat).SetString(price)
+	return rate, nil
+})
+
+func newTestPolicy() *Policy {
+	zero := FATFRule("USD")
+	zero.Threshold = currency.Amount{}
+	zero.Sunrise = SunriseReject
+	return New(map[string]Rule{
+		"US": FATFRule("USD"),
+		"DE": FATFRule("EUR"),
+		"CH": zero,
+		"XX": {Fiat: "USD"},
+	}, rates)
+}
+
+func TestEvaluate(t *testing.T) {
+	var tests = []struct {
+		transfer Transfer
+		required bool
+		sunrise  bool
+		value    string
+	}{
+		{Transfer{"sygna:0x80000000", currency.MustParseAmount("0.01"), "US", "DE"}, false, false, "600"},
+		{Transfer{"sygna:0x80000000", currency.MustParseAmount("0.02"), "US", "DE"}, true, false, "1200"},
+		{Transfer{"sygna:0x80000090", currency.MustParseAmount("2000"), "us", "de"}, true, false, "1000"},
+		{Transfer{"sygna:0x80000000", currency.MustParseAmount("0.02"), "DE", "US"}, true, false, "1100"},
+		{Transfer{"sygna:0x80000000", currency.MustParseAmount("0.02"), "US", "XX"}, true, true, "1200"},
+		{Transfer{"sygna:0x80000000", currency.MustParseAmount("0.02"), "US", ""}, true, true, "1200"},
+		{Transfer{"sygna:0x80000090", currency.MustParseAmount("0.000001"), "CH", "US"}, true, false, ""},
+		{Transfer{"sygna:0x80000000", currency.MustParseAmount("100"), "XX", "US"}, false, false, ""},
+	}
+	p := newTestPolicy()
+	for _, test := range tests {
+		decision, err := p.Evaluate(context.Background(), test.transfer)
+		assert.Nil(t, err)
+		assert.Equal(t, test.required, decision.Required, "%+v", test.transfer)
+		assert.Equal(t, test.sunrise, decision.SunriseFallback, "%+v", test.transfer)
+		if test.value == "" {
+			assert.Nil(t, decision.Value)
+		} else {
+			assert.Equal(t, test.value, decision.Value.RatString())
+		}
+	}
+}
+
+func TestEvaluateFields(t *testing.T) {
+	p := newTestPolicy()
+	p.Rules["CH"] = Rule{Enforced: true, Fiat: "CHF", RequiredFields: []string{OriginatorName}, Sunrise: SunriseReject}
+
+	decision, err := p.Evaluate(context.Background(), Transfer{"sygna:0x80000000", currency.MustParseAmount("1"), "US", "DE"})
+	assert.Nil(t, err)
+	assert.Contains(t, decision.RequiredFields, OriginatorGeographicAddress)
+
+	decision, err = p.Evaluate(context.Background(), Transfer{"sygna:0x80000000", currency.MustParseAmount("0.001"), "US", "DE"})
+	assert.Nil(t, err)
+	assert.NotContains(t, decision.RequiredFields, OriginatorGeographicAddress)
+	assert.Contains(t, decision.RequiredFields, OriginatorName)
+
+	decision, err = p.Evaluate(context.Background(), Transfer{"sygna:0x80000000", currency.MustParseAmount("0.001"), "CH", "JP"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{OriginatorName}, decision.RequiredFields)
+	assert.True(t, decision.SunriseFallback)
+	assert.Equal(t, SunriseReject, decision.Sunrise)
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	p := newTestPolicy()
+
+	_, err := p.Evaluate(context.Background(), Transfer{"sygna:0x80000000", currency.MustParseAmount("1"), "JP", "US"})
+	assert.True(t, errors.Is(err, ErrUnknownJurisdiction))
+
+	p.Default = &Rule{Enforced: true, Fiat: "USD"}
+	_, err = p.Evaluate(context.Background(), Transfer{"sygna:0x80000000", currency.MustParseAmount("1"), "JP", "US"})
+	assert.Nil(t, err)
+
+	_, err = p.Evaluate(context.Background(), Transfer{"sygna:0x80000000", currency.MustParseAmount("1"), "DE", "US"})
+	assert.Nil(t, err)
+	_, err = p.Evaluate(context.Background(), Transfer{"sygna:0x80000090", currency.MustParseAmount("1"), "DE", "US"})
+	assert.EqualError(t, err, "failed to get EUR rate of sygna:0x80000090: no rate")
+
+	_, err = p.Evaluate(context.Background(), Transfer{"BTC", currency.MustParseAmount("1"), "US", "DE"})
+	assert.NotNil(t, err)
+}
+
+func TestEvaluateTransaction(t *testing.T) {
+	transaction := orderedmap.New()
+	transaction.Set("currency_id", "sygna:0x80000000")
+	transaction.Set("amount", "0.5")
+
+	decision, err := newTestPolicy().EvaluateTransaction(context.Background(), transaction, "US", "DE")
+	assert.Nil(t, err)
+	assert.True(t, decision.Required)
+
+	transaction.Set("amount", "1e-2")
+	_, err = newTestPolicy().EvaluateTransaction(context.Background(), transaction, "US", "DE")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,249 @@
+package bridgeutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetryAfter = time.Second
+	maxRetryAfter     = time.Minute
+	// minRateDivisor bounds how far 429 responses lower the rate, to Rate/16
+	minRateDivisor = 16
+	// recoverySteps is the number of successful calls needed to recover the rate after halving it
+	recoverySteps = 10
+)
+
+// RateLimit limits the calls made to Sygna Bridge. Calls over the limit wait for their turn,
+// until their context is done, rather than fail.
+type RateLimit struct {
+	// Rate is the sustained number of calls per second, 0 for no rate limit
+	Rate float64
+	// Burst is the number of calls which may be made at once after a quiet period, 1 when 0
+	Burst int
+	// MaxInFlight is the number of concurrent calls, 0 for no limit
+	MaxInFlight int
+}
+
+func (l RateLimit) validate() error {
+	if l.Rate < 0 || math.IsNaN(l.Rate) || math.IsInf(l.Rate, 0) || l.Burst < 0 || l.MaxInFlight < 0 {
+		return fmt.Errorf("invalid rate limit %+v", l)
+	}
+	return nil
+}
+
+/*
+WithRateLimit limits all the calls of the BridgeAPI together.
+When Sygna Bridge answers 429 Too Many Requests, calls are paused for its Retry-After
+and the rate is halved, then recovers gradually with successful calls. The call answered
+429 fails, WithRetry does not retry it past the limits.
+
+	api, err := bridgeutil.NewBridgeAPI(
+		bridgeutil.WithRateLimit(bridgeutil.RateLimit{Rate: 20, Burst: 5, MaxInFlight: 10}),
+		bridgeutil.WithEndpointRateLimit("PostWalletAddressFilter", bridgeutil.RateLimit{Rate: 2}),
+	)
+*/
+func WithRateLimit(limit RateLimit) Option {
+	return func(o *options) error {
+		if err := limit.validate(); err != nil {
+			return err
+		}
+		o.rateLimit = &limit
+		return nil
+	}
+}
+
+// WithEndpointRateLimit limits the calls to an endpoint such as "PostRetry", in addition to the limit
+// of WithRateLimit. Calls wait for the endpoint limit before the global one, so calls held back by
+// their endpoint never take the in-flight slots of other endpoints.
+func WithEndpointRateLimit(endpoint string, limit RateLimit) Option {
+	return func(o *options) error {
+		if endpoint == "" {
+			return errors.New("endpoint is empty")
+		}
+		if err := limit.validate(); err != nil {
+			return err
+		}
+		if o.endpointRateLimits == nil {
+			o.endpointRateLimits = map[string]RateLimit{}
+		}
+		o.endpointRateLimits[endpoint] = limit
+		return nil
+	}
+}
+
+// limiter is a token bucket with a semaphore for in-flight calls
+type limiter struct {
+	limit    RateLimit
+	inFlight chan struct{}
+
+	mu sync.Mutex
+	// rate is the current rate, lowered by 429 responses
+	rate   float64
+	tokens float64
+	// last is when tokens were last refilled; it is in the future while paused
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func newLimiter(limit RateLimit) *limiter {
+	l := &limiter{limit: limit, rate: limit.Rate, last: time.Now()}
+	l.tokens = l.burst()
+	if limit.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, limit.MaxInFlight)
+	}
+	return l
+}
+
+func (l *limiter) burst() float64 {
+	if l.limit.Burst > 0 {
+		return float64(l.limit.Burst)
+	}
+	return 1
+}
+
+// advance refills the tokens earned since last
+func (l *limiter) advance(now time.Time) {
+	if now.After(l.last) {
+		l.tokens = math.Min(l.burst(), l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+	}
+}
+
+// reserve takes a token and returns how long to wait before using it
+func (l *limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delay := l.pausedUntil.Sub(now)
+	if l.rate == 0 {
+		return delay
+	}
+	l.advance(now)
+	l.tokens--
+	if ready := l.last.Sub(now) + time.Duration(math.Max(0, -l.tokens)/l.rate*float64(time.Second)); ready > delay {
+		delay = ready
+	}
+	return delay
+}
+
+// unreserve gives back the token of a call which was not made
+func (l *limiter) unreserve() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate > 0 {
+		l.tokens = math.Min(l.burst(), l.tokens+1)
+	}
+}
+
+// acquire waits for an in-flight slot and a token, the returned function frees the slot
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	release := func() {}
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+			release = func() { <-l.inFlight }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if delay := l.reserve(time.Now()); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			l.unreserve()
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// throttled pauses the calls for retryAfter and halves the rate
+func (l *limiter) throttled(now time.Time, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := now.Add(retryAfter); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	if l.rate == 0 {
+		return
+	}
+	l.advance(now)
+	l.rate = math.Max(l.rate/2, l.limit.Rate/minRateDivisor)
+	l.tokens = math.Min(l.tokens, 0)
+	if l.pausedUntil.After(l.last) {
+		l.last = l.pausedUntil
+	}
+}
+
+// succeeded recovers the rate lowered by throttled
+func (l *limiter) succeeded(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate >= l.limit.Rate {
+		return
+	}
+	l.advance(now)
+	l.rate = math.Min(l.limit.Rate, l.rate+l.limit.Rate/recoverySteps)
+}
+
+// rateLimitMiddleware is added by NewBridgeAPI for WithRateLimit and WithEndpointRateLimit
+func rateLimitMiddleware(global *limiter, endpoints map[string]*limiter) Middleware {
+	return func(next Handler) Handler {
+		return func(ex *Exchange) (interface{}, error) {
+			var limiters []*limiter
+			if l, ok := endpoints[ex.Endpoint]; ok {
+				limiters = append(limiters, l)
+			}
+			if global != nil {
+				limiters = append(limiters, global)
+			}
+			for i, l := range limiters {
+				release, err := l.acquire(ex.Context)
+				if err != nil {
+					for _, acquired := range limiters[:i] {
+						acquired.unreserve()
+					}
+					return nil, err
+				}
+				defer release()
+			}
+
+			response, err := next(ex)
+
+			now := time.Now()
+			switch {
+			case ex.StatusCode == http.StatusTooManyRequests:
+				retryAfter := parseRetryAfter(ex.ResponseHeader.Get("Retry-After"), now)
+				for _, l := range limiters {
+					l.throttled(now, retryAfter)
+				}
+			case err == nil:
+				for _, l := range limiters {
+					l.succeeded(now)
+				}
+			}
+			return response, err
+		}
+	}
+}
+
+// parseRetryAfter parses a Retry-After header in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	d := defaultRetryAfter
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		d = time.Duration(seconds) * time.Second
+	} else if t, err := http.ParseTime(value); err == nil {
+		d = t.Sub(now)
+	}
+	return min(max(d, 0), maxRetryAfter)
+}
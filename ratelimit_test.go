@@ -0,0 +1,205 @@
+package bridgeutil
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iancoleman/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(`{"supported_coins":[],"status":"OK"}`))
+}
+
+func TestRateLimit(t *testing.T) {
+	api := newTestAPI(t, okHandler, WithRateLimit(RateLimit{Rate: 20, Burst: 2}))
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		_, err := api.GetCurrencies(nil)
+		assert.Nil(t, err)
+	}
+	// the burst of 2 is immediate, the 4 other calls wait 50ms each
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+}
+
+func TestRateLimitMaxInFlight(t *testing.T) {
+	var inFlight, maxInFlight int32
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		okHandler(w, r)
+	}, WithRateLimit(RateLimit{MaxInFlight: 2}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := api.GetCurrencies(nil)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxInFlight))
+}
+
+func TestRateLimitContext(t *testing.T) {
+	api := newTestAPI(t, okHandler, WithRateLimit(RateLimit{Rate: 0.5}))
+	_, err := api.GetCurrencies(nil)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = api.WithContext(ctx).GetCurrencies(nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestEndpointRateLimit(t *testing.T) {
+	api := newTestAPI(t, okHandler, WithEndpointRateLimit("PostRetry", RateLimit{Rate: 0.5}))
+	_, err := api.PostRetry(orderedmap.New())
+	assert.Nil(t, err)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		_, err := api.GetCurrencies(nil)
+		assert.Nil(t, err)
+	}
+	assert.Less(t, time.Since(start), time.Second, "other endpoints are not limited")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = api.WithContext(ctx).PostRetry(orderedmap.New())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestEndpointRateLimitDoesNotHoldGlobalSlots(t *testing.T) {
+	api := newTestAPI(t, okHandler,
+		WithRateLimit(RateLimit{MaxInFlight: 2}),
+		WithEndpointRateLimit("PostRetry", RateLimit{Rate: 0.5}))
+	_, err := api.PostRetry(orderedmap.New())
+	assert.Nil(t, err)
+
+	// saturate the retry endpoint with more waiting calls than global slots
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := api.WithContext(ctx).PostRetry(orderedmap.New())
+			assert.ErrorIs(t, err, context.Canceled)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	_, err = api.PostPermission(orderedmap.New())
+	assert.Nil(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "other endpoints get through")
+	cancel()
+	wg.Wait()
+}
+
+func TestRateLimitUnreservesOnFailure(t *testing.T) {
+	endpoint, global := newLimiter(RateLimit{Rate: 1}), newLimiter(RateLimit{Rate: 0.001})
+	handler := rateLimitMiddleware(global, map[string]*limiter{"PostRetry": endpoint})(func(ex *Exchange) (interface{}, error) {
+		return nil, nil
+	})
+	_, err := handler(&Exchange{Context: context.Background(), Endpoint: "GetCurrencies"})
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = handler(&Exchange{Context: ctx, Endpoint: "PostRetry"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, time.Duration(0), endpoint.reserve(time.Now()), "the endpoint token is given back")
+}
+
+func TestRateLimitTooManyRequests(t *testing.T) {
+	var calls int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":"too many requests"}`))
+			return
+		}
+		okHandler(w, r)
+	}
+	retry := WithRetry(RetryConfig{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	// without a rate limit, 429 is retried
+	_, err := newTestAPI(t, handler, retry).GetCurrencies(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	atomic.StoreInt32(&calls, 0)
+	api := newTestAPI(t, handler, retry, WithRateLimit(RateLimit{MaxInFlight: 4}))
+	_, err = api.GetCurrencies(nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "429 is not retried past the rate limit")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = api.WithContext(ctx).GetCurrencies(nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "calls are paused for Retry-After")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestLimiterAdaptsRate(t *testing.T) {
+	l := newLimiter(RateLimit{Rate: 100, Burst: 10})
+	now := time.Now()
+	l.throttled(now, 200*time.Millisecond)
+	assert.Equal(t, 50.0, l.rate)
+	assert.GreaterOrEqual(t, l.reserve(now), 200*time.Millisecond)
+
+	for i := 0; i < 4; i++ {
+		l.throttled(now, 0)
+	}
+	assert.Equal(t, 100.0/minRateDivisor, l.rate, "the rate is not lowered below Rate/16")
+
+	for i := 0; i < 2*recoverySteps; i++ {
+		l.succeeded(now)
+	}
+	assert.Equal(t, 100.0, l.rate)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", defaultRetryAfter},
+		{"3", 3 * time.Second},
+		{"Wed, 01 Jan 2020 00:00:05 GMT", 5 * time.Second},
+		{"Tue, 31 Dec 2019 00:00:00 GMT", 0},
+		{"3600", maxRetryAfter},
+		{"soon", defaultRetryAfter},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, parseRetryAfter(tt.value, now), tt.value)
+	}
+}
+
+func TestInvalidRateLimit(t *testing.T) {
+	_, err := NewBridgeAPI(WithRateLimit(RateLimit{Rate: -1}))
+	assert.NotNil(t, err)
+	_, err = NewBridgeAPI(WithEndpointRateLimit("", RateLimit{Rate: 1}))
+	assert.NotNil(t, err)
+}
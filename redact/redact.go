@@ -0,0 +1,240 @@
+/*
+Package redact masks, hashes or drops personal data in IVMS101 and Sygna Bridge
+payloads so that bridge traffic can be logged without leaking decrypted
+private_info, names, national identifiers or addresses.
+*/
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/iancoleman/orderedmap"
+)
+
+// Action is applied to the value of a sensitive field
+type Action int
+
+const (
+	// Keep leaves the value as it is
+	Keep Action = iota
+	// Mask replaces the value with Masked
+	Mask
+	// Hash replaces the value with a salted HMAC-SHA256, so equal values can still be correlated
+	Hash
+	// Drop removes the field
+	Drop
+)
+
+// Masked replaces values of masked fields
+const Masked = "[REDACTED]"
+
+// Policy maps json field names to the Action applied wherever they appear in a payload
+type Policy struct {
+	Fields map[string]Action
+	// Salt keys the HMAC of hashed values; use a secret salt so hashes cannot be brute forced
+	Salt []byte
+}
+
+// DefaultPolicy returns a policy covering the sensitive fields of IVMS101 and Sygna Bridge payloads.
+// Names, identifiers, birth data and addresses are masked; account numbers and blockchain addresses
+// are hashed so transfers can still be traced; private_info is dropped.
+func DefaultPolicy(salt []byte) *Policy {
+	fields := map[string]Action{
+		"private_info":            Drop,
+		"other_cdd_info":          Drop,
+		"account_numbers":         Hash,
+		"address":                 Hash,
+		"national_identifier":     Mask,
+		"customer_identification": Mask,
+		"customer_number":         Mask,
+		"date_of_birth":           Mask,
+		"place_of_birth":          Mask,
+		"primary_identifier":      Mask,
+		"secondary_identifier":    Mask,
+		"legal_person_name":       Mask,
+		"geographic_address":      Mask,
+		"address_line":            Mask,
+		"street_name":             Mask,
+		"building_number":         Mask,
+		"building_name":           Mask,
+		"post_box":                Mask,
+		"post_code":               Mask,
+		"floor":                   Mask,
+		"room":                    Mask,
+	}
+	return &Policy{Fields: fields, Salt: salt}
+}
+
+// With returns a copy of the policy with the action of field replaced
+func (p *Policy) With(field string, action Action) *Policy {
+	fields := make(map[string]Action, len(p.Fields)+1)
+	for k, v := range p.Fields {
+		fields[k] = v
+	}
+	fields[field] = action
+	return &Policy{Fields: fields, Salt: p.Salt}
+}
+
+// OrderedMap returns a redacted copy of o; o itself is left untouched
+func (p *Policy) OrderedMap(o *orderedmap.OrderedMap) *orderedmap.OrderedMap {
+	if o == nil {
+		return nil
+	}
+	return p.object(o)
+}
+
+// Value returns a redacted copy of v, which may be an *orderedmap.OrderedMap, a slice of them
+// or any value encodable as json such as an ivms101.Payload; structs are returned as *orderedmap.OrderedMap.
+func (p *Policy) Value(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case *orderedmap.OrderedMap:
+		return p.OrderedMap(value), nil
+	case []*orderedmap.OrderedMap:
+		return p.redact(value), nil
+	case string:
+		return value, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if b[0] == '{' {
+		o := orderedmap.New()
+		if err := o.UnmarshalJSON(b); err != nil {
+			return nil, err
+		}
+		decoded = o
+	} else if err := json.Unmarshal(b, &decoded); err != nil {
+		return nil, err
+	}
+	return p.redact(decoded), nil
+}
+
+// String returns the redacted json of v, or a placeholder if it cannot be encoded
+func (p *Policy) String(v interface{}) string {
+	redacted, err := p.Value(v)
+	if err != nil {
+		return fmt.Sprintf("<unloggable %T>", v)
+	}
+	if s, ok := redacted.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(redacted)
+	if err != nil {
+		return fmt.Sprintf("<unloggable %T>", v)
+	}
+	return string(b)
+}
+
+func (p *Policy) object(o *orderedmap.OrderedMap) *orderedmap.OrderedMap {
+	result := orderedmap.New()
+	for _, k := range o.Keys() {
+		v, _ := o.Get(k)
+		switch p.Fields[k] {
+		case Drop:
+			continue
+		case Mask:
+			result.Set(k, Masked)
+		case Hash:
+			result.Set(k, p.hash(v))
+		default:
+			result.Set(k, p.redact(v))
+		}
+	}
+	return result
+}
+
+func (p *Policy) redact(v interface{}) interface{} {
+	switch value := v.(type) {
+	case *orderedmap.OrderedMap:
+		return p.object(value)
+	case orderedmap.OrderedMap:
+		return p.object(&value)
+	case []*orderedmap.OrderedMap:
+		result := make([]*orderedmap.OrderedMap, len(value))
+		for i, child := range value {
+			result[i] = p.object(child)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, child := range value {
+			result[i] = p.redact(child)
+		}
+		return result
+	case map[string]interface{}:
+		o := orderedmap.New()
+		for k, child := range value {
+			o.Set(k, child)
+		}
+		o.SortKeys(sort.Strings)
+		return p.object(o)
+	default:
+		return v
+	}
+}
+
+// hash returns a salted HMAC-SHA256 of v; arrays are hashed element by element
+func (p *Policy) hash(v interface{}) interface{} {
+	if values, ok := v.([]interface{}); ok {
+		result := make([]interface{}, len(values))
+		for i, child := range values {
+			result[i] = p.hash(child)
+		}
+		return result
+	}
+	if values, ok := v.([]string); ok {
+		result := make([]interface{}, len(values))
+		for i, child := range values {
+			result[i] = p.hash(child)
+		}
+		return result
+	}
+
+	var b []byte
+	if s, ok := v.(string); ok {
+		b = []byte(s)
+	} else {
+		b, _ = json.Marshal(v)
+	}
+	mac := hmac.New(sha256.New, p.Salt)
+	mac.Write(b)
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// LogExchange returns a function usable as bridgeutil.BridgeAPI.ExchangeLogger which logs
+// every exchange with logf after redacting the request body, the response and json error bodies.
+func (p *Policy) LogExchange(logf func(format string, args ...interface{})) func(method, path string, body, response interface{}, err error) {
+	return func(method, path string, body, response interface{}, err error) {
+		if err != nil {
+			logf("%s %s request: %s error: %s", method, path, p.String(body), p.errorString(err))
+			return
+		}
+		logf("%s %s request: %s response: %s", method, path, p.String(body), p.String(response))
+	}
+}
+
+// errorString redacts error messages which carry a json response body
+func (p *Policy) errorString(err error) string {
+	message := err.Error()
+	var decoded interface{}
+	if json.Unmarshal([]byte(message), &decoded) != nil {
+		return message
+	}
+	if message[0] == '{' {
+		o := orderedmap.New()
+		if o.UnmarshalJSON([]byte(message)) == nil {
+			decoded = o
+		}
+	}
+	return p.String(decoded)
+}
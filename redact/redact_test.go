@@ -0,0 +1,71 @@
+package redact
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/ivms101"
+	"github.com/iancoleman/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+const fakePermissionRequest = `{"data":{"private_info":"79676feb56c7b8c2","transaction":{"originator_vasp":{"vasp_code":"VASPUSNY1","addrs":[{"address":"bnb1vynn9hamtqg9me7y6frja0rvfva9saprl55gl4","addr_extra_info":[]}]},"currency_id":"sygna:0x80000090","amount":"4.51120135938784"},"data_dt":"2020-07-13T05:56:53.088Z","signature":"2f536f6f"}}`
+
+func TestOrderedMap(t *testing.T) {
+	o := orderedmap.New()
+	assert.Nil(t, o.UnmarshalJSON([]byte(fakePermissionRequest)))
+
+	policy := DefaultPolicy([]byte("salt"))
+	redacted := policy.OrderedMap(o)
+
+	b, _ := json.Marshal(redacted)
+	assert.NotContains(t, string(b), "private_info")
+	assert.NotContains(t, string(b), "bnb1vynn9hamtqg9me7y6frja0rvfva9saprl55gl4")
+	assert.Contains(t, string(b), `"vasp_code":"VASPUSNY1"`)
+	assert.Contains(t, string(b), `"address":"hmac-sha256:`)
+
+	original, _ := json.Marshal(o)
+	assert.Equal(t, fakePermissionRequest, string(original), "original should be untouched")
+
+	b2, _ := json.Marshal(DefaultPolicy([]byte("salt")).OrderedMap(o))
+	assert.Equal(t, string(b), string(b2), "hashes should be stable for the same salt")
+}
+
+func TestValue(t *testing.T) {
+	payload := &ivms101.Payload{
+		Originator: &ivms101.Originator{
+			OriginatorPersons: []ivms101.Person{{NaturalPerson: &ivms101.NaturalPerson{
+				Name: &ivms101.NaturalPersonName{NameIdentifiers: []ivms101.NaturalPersonNameIdentifier{
+					{PrimaryIdentifier: "Wu Xinli", NameIdentifierType: ivms101.NaturalPersonNameTypeLegal},
+				}},
+				CountryOfResidence: "TZ",
+			}}},
+			AccountNumbers: []string{"r3kmLJN5D28dHuH8vZNUZpMC43pEHpaocV"},
+		},
+	}
+
+	s := DefaultPolicy(nil).With("country_of_residence", Drop).String(payload)
+	assert.NotContains(t, s, "Wu Xinli")
+	assert.NotContains(t, s, "r3kmLJN5D28dHuH8vZNUZpMC43pEHpaocV")
+	assert.NotContains(t, s, "TZ")
+	assert.Contains(t, s, `"name_identifier_type":"LEGL"`)
+}
+
+func TestLogExchange(t *testing.T) {
+	var lines []string
+	logf := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	hook := DefaultPolicy(nil).LogExchange(logf)
+
+	body := orderedmap.New()
+	body.Set("private_info", "79676feb56c7b8c2")
+	body.Set("transfer_id", "b97903fd")
+	hook("POST", "v2/bridge/transaction/permission-request", body, nil, errors.New(`{"status":400,"legal_person_name":"ABC Limited"}`))
+
+	assert.Equal(t, []string{
+		`POST v2/bridge/transaction/permission-request request: {"transfer_id":"b97903fd"} error: {"status":400,"legal_person_name":"[REDACTED]"}`,
+	}, lines)
+}
@@ -0,0 +1,63 @@
+package bridgeutil
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/crypto"
+	"github.com/iancoleman/orderedmap"
+)
+
+// Signer signs messages sent to Sygna Bridge, setting their signature field
+type Signer interface {
+	Sign(message *orderedmap.OrderedMap) error
+}
+
+// PrivateKeySigner signs with a hex private key
+type PrivateKeySigner string
+
+// Sign implements Signer
+func (k PrivateKeySigner) Sign(message *orderedmap.OrderedMap) error {
+	return Sign(message, string(k))
+}
+
+// CommandSigner signs by running an external signer plugin, e.g. a bridge to an HSM, so the
+// private key never enters the process. The plugin reads the JSON to sign on stdin and prints
+// the hex r||s secp256k1 signature of its SHA-256 hash. A high S, as many HSMs return, is
+// replaced by its low-S equivalent so the signature passes Verify.
+type CommandSigner struct {
+	Path string
+	Args []string
+}
+
+// Sign implements Signer
+func (s *CommandSigner) Sign(message *orderedmap.OrderedMap) error {
+	message.Set("signature", "")
+	b, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(s.Path, s.Args...)
+	cmd.Stdin = bytes.NewReader(b)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("signer plugin %s failed: %w: %s", s.Path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	signature, err := hex.DecodeString(strings.TrimSpace(string(out)))
+	if err == nil {
+		signature, err = crypto.NormalizeSignature(signature)
+	}
+	if err != nil {
+		return fmt.Errorf("signer plugin %s returned an invalid signature", s.Path)
+	}
+	message.Set("signature", hex.EncodeToString(signature))
+	return nil
+}
@@ -0,0 +1,246 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bridgeutil "github.com/CoolBitX-Technology/sygna-bridge-util-go"
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/address"
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/ivms101"
+	"github.com/iancoleman/orderedmap"
+)
+
+const xrpCurrencyID = "sygna:0x80000090"
+
+var samplePayload = &ivms101.Payload{
+	Originator: &ivms101.Originator{
+		OriginatorPersons: []ivms101.Person{{NaturalPerson: &ivms101.NaturalPerson{
+			Name: &ivms101.NaturalPersonName{NameIdentifiers: []ivms101.NaturalPersonNameIdentifier{
+				{PrimaryIdentifier: "Wu Xinli", NameIdentifierType: ivms101.NaturalPersonNameTypeLegal},
+			}},
+			CustomerIdentification: "1002390",
+		}}},
+		AccountNumbers: []string{"r3kmLJN5D28dHuH8vZNUZpMC43pEHpaocV"},
+	},
+	Beneficiary: &ivms101.Beneficiary{
+		BeneficiaryPersons: []ivms101.Person{{LegalPerson: &ivms101.LegalPerson{
+			Name: &ivms101.LegalPersonName{NameIdentifiers: []ivms101.LegalPersonNameIdentifier{
+				{LegalPersonName: "ABC Limited", LegalPersonNameIdentifierType: ivms101.LegalPersonNameTypeLegal},
+			}},
+		}}},
+		AccountNumbers: []string{"rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh"},
+	},
+}
+
+// play runs one scenario from the permission request to the final status
+func (s *simulation) play(ctx context.Context, scenario Scenario) error {
+	transferID, err := s.requestPermission()
+	if err != nil {
+		return err
+	}
+	if err := s.receivePermissionRequest(ctx); err != nil {
+		return err
+	}
+
+	if scenario == ScenarioCDD {
+		if err := s.requestCDD(transferID); err != nil {
+			return err
+		}
+	}
+
+	status := bridgeutil.PermissionStatusAccepted
+	if scenario == ScenarioReject {
+		status = bridgeutil.PermissionStatusRejected
+	}
+	if err := s.answer(ctx, transferID, status); err != nil {
+		return err
+	}
+
+	switch scenario {
+	case ScenarioAccept, ScenarioCDD:
+		if err := s.sendTxID(ctx, transferID); err != nil {
+			return err
+		}
+	case ScenarioCancel:
+		if err := s.cancel(transferID); err != nil {
+			return err
+		}
+	}
+	return s.checkStatus(transferID)
+}
+
+// sign signs a JSON message with the private key of v and prints it
+func (s *simulation) sign(v *vasp, title, message string) (*orderedmap.OrderedMap, error) {
+	o := orderedmap.New()
+	if err := o.UnmarshalJSON([]byte(message)); err != nil {
+		return nil, err
+	}
+	if err := bridgeutil.Sign(o, v.privateKey); err != nil {
+		return nil, err
+	}
+	s.printMessage(fmt.Sprintf("%s signed %s", v.code, title), o)
+	return o, nil
+}
+
+func (s *simulation) requestPermission() (string, error) {
+	s.beginStep(fmt.Sprintf("originator %s sends a permission request to %s", s.originator.code, s.beneficiary.code))
+
+	beneficiaryPublicKey, err := s.vaspPublicKey(s.originator, s.beneficiary.code)
+	if err != nil {
+		return "", err
+	}
+	privateInfo, err := bridgeutil.EncryptIVMS(samplePayload, beneficiaryPublicKey)
+	if err != nil {
+		return "", err
+	}
+	s.printMessage("IVMS101 private info encrypted for "+s.beneficiary.code, samplePayload)
+
+	originatorVASP := orderedmap.New()
+	originatorVASP.Set("vasp_code", s.originator.code)
+	originatorVASP.Set("addrs", address.PermissionRequestAddrs(address.Address{Address: "r3kmLJN5D28dHuH8vZNUZpMC43pEHpaocV"}))
+	beneficiaryVASP := orderedmap.New()
+	beneficiaryVASP.Set("vasp_code", s.beneficiary.code)
+	beneficiaryVASP.Set("addrs", address.PermissionRequestAddrs(address.Address{
+		Address:   "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh",
+		ExtraInfo: address.AddressExtraInfo{Tag: "123"},
+	}))
+	transaction := orderedmap.New()
+	transaction.Set("originator_vasp", originatorVASP)
+	transaction.Set("beneficiary_vasp", beneficiaryVASP)
+	transaction.Set("currency_id", xrpCurrencyID)
+	transaction.Set("amount", "4.51120135938784")
+
+	data := orderedmap.New()
+	data.Set("private_info", privateInfo)
+	data.Set("transaction", transaction)
+	data.Set("data_dt", time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	if err := bridgeutil.Sign(data, s.originator.privateKey); err != nil {
+		return "", err
+	}
+	callback, err := s.sign(s.originator, "callback", fmt.Sprintf(`{"callback_url":%q}`, s.originator.url+"/permission"))
+	if err != nil {
+		return "", err
+	}
+	request := orderedmap.New()
+	request.Set("data", data)
+	request.Set("callback", callback)
+	s.printMessage(s.originator.code+" posts permission-request", request)
+
+	response, err := s.originator.api.PostPermissionRequest(request)
+	if err != nil {
+		return "", err
+	}
+	s.printMessage("bridge responds", response)
+	return getString(response, "transfer_id"), nil
+}
+
+func (s *simulation) receivePermissionRequest(ctx context.Context) error {
+	s.beginStep(fmt.Sprintf("beneficiary %s receives the permission request and decrypts the private info", s.beneficiary.code))
+
+	callback, err := s.awaitCallback(ctx, s.beneficiary, "/permission-request")
+	if err != nil {
+		return err
+	}
+	data, err := getObject(callback, "data")
+	if err != nil {
+		return err
+	}
+	originatorPublicKey, err := s.vaspPublicKey(s.beneficiary, s.originator.code)
+	if err != nil {
+		return err
+	}
+	if err := s.verify("the permission request data", data, s.originator.code, originatorPublicKey); err != nil {
+		return err
+	}
+	payload, err := bridgeutil.DecryptIVMS(getString(data, "private_info"), s.beneficiary.privateKey)
+	if err != nil {
+		return err
+	}
+	s.printMessage("decrypted IVMS101 private info", payload)
+	return nil
+}
+
+func (s *simulation) requestCDD(transferID string) error {
+	s.beginStep(fmt.Sprintf("beneficiary %s requests customer due diligence", s.beneficiary.code))
+	request, err := s.sign(s.beneficiary, "cdd-request", fmt.Sprintf(`{"transfer_id":%q,"reason":"enhanced due diligence above threshold"}`, transferID))
+	if err != nil {
+		return err
+	}
+	if _, err := s.beneficiary.api.PostTransactionCDDRequest(request); err != nil {
+		return err
+	}
+
+	s.beginStep(fmt.Sprintf("originator %s sends the customer due diligence", s.originator.code))
+	cdd, err := bridgeutil.EncryptString(`{"source_of_funds":"salary"}`, s.beneficiary.publicKey)
+	if err != nil {
+		return err
+	}
+	response, err := s.sign(s.originator, "cdd", fmt.Sprintf(`{"transfer_id":%q,"private_info":%q}`, transferID, cdd))
+	if err != nil {
+		return err
+	}
+	_, err = s.originator.api.PostTransactionCDD(response)
+	return err
+}
+
+func (s *simulation) answer(ctx context.Context, transferID, status string) error {
+	s.beginStep(fmt.Sprintf("beneficiary %s answers %s", s.beneficiary.code, status))
+	message := fmt.Sprintf(`{"transfer_id":%q,"permission_status":%q}`, transferID, status)
+	if status == bridgeutil.PermissionStatusRejected {
+		message = fmt.Sprintf(`{"transfer_id":%q,"permission_status":%q,"reject_code":%q,"reject_message":"beneficiary is on the internal blacklist"}`,
+			transferID, status, bridgeutil.RejectCodeBVRC004)
+	}
+	permission, err := s.sign(s.beneficiary, "permission", message)
+	if err != nil {
+		return err
+	}
+	if _, err := s.beneficiary.api.PostPermission(permission); err != nil {
+		return err
+	}
+
+	s.beginStep(fmt.Sprintf("originator %s receives the permission", s.originator.code))
+	callback, err := s.awaitCallback(ctx, s.originator, "/permission")
+	if err != nil {
+		return err
+	}
+	if got := getString(callback, "permission_status"); got != status {
+		return fmt.Errorf("originator received %s instead of %s", got, status)
+	}
+	return nil
+}
+
+func (s *simulation) sendTxID(ctx context.Context, transferID string) error {
+	s.beginStep(fmt.Sprintf("originator %s broadcasts and sends the txid", s.originator.code))
+	txid, err := s.sign(s.originator, "txid", fmt.Sprintf(`{"transfer_id":%q,"txid":"9b0d2e587be4a6eb09e97e4d8e7e1f8fb0c6a3cbf5e1c57f0dc5a9b3c5b6e2a1"}`, transferID))
+	if err != nil {
+		return err
+	}
+	if _, err := s.originator.api.PostTransactionID(txid); err != nil {
+		return err
+	}
+
+	s.beginStep(fmt.Sprintf("beneficiary %s receives the txid", s.beneficiary.code))
+	_, err = s.awaitCallback(ctx, s.beneficiary, "/txid")
+	return err
+}
+
+func (s *simulation) cancel(transferID string) error {
+	s.beginStep(fmt.Sprintf("originator %s cancels the transfer before broadcasting", s.originator.code))
+	cancel, err := s.sign(s.originator, "cancel", fmt.Sprintf(`{"transfer_id":%q}`, transferID))
+	if err != nil {
+		return err
+	}
+	_, err = s.originator.api.PostTransactionCancel(cancel)
+	return err
+}
+
+func (s *simulation) checkStatus(transferID string) error {
+	s.beginStep(fmt.Sprintf("originator %s checks the transfer status", s.originator.code))
+	status, err := s.originator.api.GetStatus(transferID)
+	if err != nil {
+		return err
+	}
+	s.printMessage("bridge responds", status)
+	return s.verify("the status", status, "the bridge", s.bridge.CentralPublicKey())
+}
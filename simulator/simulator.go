@@ -0,0 +1,274 @@
+/*
+Package simulator walks an originator and a beneficiary VASP, both running in
+process, through Sygna Bridge transfers against a local devserver, printing every
+signed message and verifying each signature on the way.
+*/
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	bridgeutil "github.com/CoolBitX-Technology/sygna-bridge-util-go"
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/devserver"
+	"github.com/iancoleman/orderedmap"
+)
+
+// Scenario is a transfer flow to simulate
+type Scenario string
+
+const (
+	//ScenarioAccept the beneficiary accepts and the originator sends the txid
+	ScenarioAccept Scenario = "accept"
+	//ScenarioReject the beneficiary rejects the transfer
+	ScenarioReject Scenario = "reject"
+	//ScenarioCancel the beneficiary accepts and the originator cancels before broadcasting
+	ScenarioCancel Scenario = "cancel"
+	//ScenarioCDD the beneficiary requests customer due diligence which the originator sends
+	ScenarioCDD Scenario = "cdd"
+)
+
+// Scenarios are every scenario in the order Run plays them by default
+var Scenarios = []Scenario{ScenarioAccept, ScenarioReject, ScenarioCancel, ScenarioCDD}
+
+// callbackTimeout bounds the wait for a callback from the bridge
+const callbackTimeout = 10 * time.Second
+
+// Config configures a simulation
+type Config struct {
+	// Out receives the narration and messages, io.Discard when nil
+	Out io.Writer
+	// Step is called before each step with its description, e.g. to wait for the user; may be nil
+	Step func(description string)
+}
+
+// simulation is the bridge and the two VASPs of a run
+type simulation struct {
+	out         io.Writer
+	step        func(string)
+	bridge      *devserver.Server
+	originator  *vasp
+	beneficiary *vasp
+	servers     []*http.Server
+}
+
+// vasp is a simulated VASP with its callback server
+type vasp struct {
+	code       string
+	privateKey string
+	publicKey  string
+	api        *bridgeutil.BridgeAPI
+	url        string
+	callbacks  chan *orderedmap.OrderedMap
+	// vaspList is the last VASP list signed by the bridge, kept by recordVASPList
+	vaspList *orderedmap.OrderedMap
+}
+
+// Run plays the scenarios, all of them when none is given
+func Run(ctx context.Context, cfg Config, scenarios ...Scenario) error {
+	if len(scenarios) == 0 {
+		scenarios = Scenarios
+	}
+	s, err := start(cfg)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	for _, scenario := range scenarios {
+		s.printf("\n=== scenario %s ===\n", scenario)
+		if err := s.play(ctx, scenario); err != nil {
+			return fmt.Errorf("scenario %s: %w", scenario, err)
+		}
+	}
+	s.bridge.Wait()
+	return nil
+}
+
+func start(cfg Config) (*simulation, error) {
+	s := &simulation{out: cfg.Out, step: cfg.Step}
+	if s.out == nil {
+		s.out = io.Discard
+	}
+
+	var err error
+	if s.originator, err = s.newVASP("VASPUSNY1"); err != nil {
+		return nil, err
+	}
+	if s.beneficiary, err = s.newVASP("VASPJPJT4"); err != nil {
+		s.close()
+		return nil, err
+	}
+
+	s.bridge, err = devserver.New(devserver.Config{Seed: devserver.Seed{VASPs: []devserver.VASP{
+		{VASPCode: s.originator.code, VASPName: "Originator VASP", VASPPubkey: s.originator.publicKey},
+		{
+			VASPCode:                     s.beneficiary.code,
+			VASPName:                     "Beneficiary VASP",
+			VASPPubkey:                   s.beneficiary.publicKey,
+			CallbackPermissionRequestURL: s.beneficiary.url + "/permission-request",
+			CallbackTxIDURL:              s.beneficiary.url + "/txid",
+		},
+	}}})
+	if err != nil {
+		s.close()
+		return nil, err
+	}
+	bridgeURL, err := s.serve(s.bridge)
+	if err != nil {
+		s.close()
+		return nil, err
+	}
+	for _, v := range []*vasp{s.originator, s.beneficiary} {
+		if v.api, err = bridgeutil.NewBridgeAPI(bridgeutil.WithBaseURL(bridgeURL), bridgeutil.WithAPIKey(v.code), bridgeutil.WithMiddleware(v.recordVASPList)); err != nil {
+			s.close()
+			return nil, err
+		}
+	}
+
+	s.printf("fake bridge at %s, central public key %s\n", bridgeURL, s.bridge.CentralPublicKey())
+	s.printf("originator %s at %s, public key %s\n", s.originator.code, s.originator.url, s.originator.publicKey)
+	s.printf("beneficiary %s at %s, public key %s\n", s.beneficiary.code, s.beneficiary.url, s.beneficiary.publicKey)
+	return s, nil
+}
+
+func (s *simulation) newVASP(code string) (*vasp, error) {
+	v := &vasp{code: code, callbacks: make(chan *orderedmap.OrderedMap, 10)}
+	var err error
+	if v.privateKey, v.publicKey, err = bridgeutil.GenerateKeyPair(); err != nil {
+		return nil, err
+	}
+	v.url, err = s.serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		o := orderedmap.New()
+		if err == nil {
+			err = o.UnmarshalJSON(body)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		o.Set("callback", r.URL.Path)
+		v.callbacks <- o
+	}))
+	return v, err
+}
+
+// recordVASPList keeps the signed response of GetVASP, which returns only the list
+func (v *vasp) recordVASPList(next bridgeutil.Handler) bridgeutil.Handler {
+	return func(ex *bridgeutil.Exchange) (interface{}, error) {
+		response, err := next(ex)
+		if list, ok := response.(*orderedmap.OrderedMap); ok && err == nil && ex.Endpoint == "GetVASP" {
+			v.vaspList = list
+		}
+		return response, err
+	}
+}
+
+// serve serves h on a random local port and returns its url
+func (s *simulation) serve(h http.Handler) (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	server := &http.Server{Handler: h}
+	s.servers = append(s.servers, server)
+	go server.Serve(listener)
+	return "http://" + listener.Addr().String(), nil
+}
+
+func (s *simulation) close() {
+	for _, server := range s.servers {
+		server.Close()
+	}
+}
+
+func (s *simulation) printf(format string, a ...interface{}) {
+	fmt.Fprintf(s.out, format, a...)
+}
+
+// printMessage prints a message as indented JSON
+func (s *simulation) printMessage(title string, message interface{}) {
+	b, err := json.MarshalIndent(message, "    ", "  ")
+	if err != nil {
+		b = []byte(err.Error())
+	}
+	s.printf("  %s:\n    %s\n", title, b)
+}
+
+func (s *simulation) beginStep(description string) {
+	if s.step != nil {
+		s.step(description)
+	}
+	s.printf("\n- %s\n", description)
+}
+
+// verify checks the signature of message by the owner of publicKey
+func (s *simulation) verify(what string, message *orderedmap.OrderedMap, signer, publicKey string) error {
+	valid, err := bridgeutil.Verify(message, publicKey)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("invalid signature of %s by %s", what, signer)
+	}
+	s.printf("  verified signature of %s by %s\n", what, signer)
+	return nil
+}
+
+// vaspPublicKey fetches the public key of the VASP code as v and verifies the VASP list of the bridge
+func (s *simulation) vaspPublicKey(v *vasp, code string) (string, error) {
+	publicKey, err := v.api.GetVASPPublicKey(code, false)
+	if err != nil {
+		return "", err
+	}
+	if err := s.verify("the VASP list", v.vaspList, "the bridge", s.bridge.CentralPublicKey()); err != nil {
+		return "", err
+	}
+	return publicKey, nil
+}
+
+// awaitCallback waits for the next callback of v from the bridge and verifies its central signature
+func (s *simulation) awaitCallback(ctx context.Context, v *vasp, path string) (*orderedmap.OrderedMap, error) {
+	ctx, cancel := context.WithTimeout(ctx, callbackTimeout)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no %s callback for %s: %w", path, v.code, ctx.Err())
+	case callback := <-v.callbacks:
+		got, _ := callback.Get("callback")
+		callback.Delete("callback")
+		if got != path {
+			return nil, fmt.Errorf("%s expected a %s callback, got %v", v.code, path, got)
+		}
+		s.printMessage(fmt.Sprintf("%s received %s", v.code, path), callback)
+		if err := s.verify(path+" callback", callback, "the bridge", s.bridge.CentralPublicKey()); err != nil {
+			return nil, err
+		}
+		return callback, nil
+	}
+}
+
+func getString(o *orderedmap.OrderedMap, key string) string {
+	v, _ := o.Get(key)
+	s, _ := v.(string)
+	return s
+}
+
+func getObject(o *orderedmap.OrderedMap, key string) (*orderedmap.OrderedMap, error) {
+	v, _ := o.Get(key)
+	switch m := v.(type) {
+	case *orderedmap.OrderedMap:
+		return m, nil
+	case orderedmap.OrderedMap:
+		return &m, nil
+	default:
+		return nil, errors.New(key + " must be an object")
+	}
+}
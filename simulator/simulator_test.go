@@ -0,0 +1,37 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+	var steps []string
+	err := Run(context.Background(), Config{Out: &out, Step: func(description string) {
+		steps = append(steps, description)
+	}})
+	assert.Nil(t, err, out.String())
+
+	for _, scenario := range Scenarios {
+		assert.Contains(t, out.String(), "=== scenario "+string(scenario)+" ===")
+	}
+	assert.Contains(t, out.String(), "verified signature of the permission request data by VASPUSNY1")
+	assert.Contains(t, out.String(), `"primary_identifier": "Wu Xinli"`)
+	assert.Contains(t, out.String(), `"permission_status": "REJECTED"`)
+	assert.Contains(t, out.String(), `"status": "CANCELED"`)
+	assert.Contains(t, steps, "beneficiary VASPJPJT4 requests customer due diligence")
+	assert.Equal(t, 4, strings.Count(out.String(), "verified signature of the status by the bridge"))
+	assert.Equal(t, 8, strings.Count(out.String(), "verified signature of the VASP list by the bridge"))
+}
+
+func TestRunScenario(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, Run(context.Background(), Config{Out: &out}, ScenarioReject))
+	assert.NotContains(t, out.String(), "=== scenario accept ===")
+	assert.NotContains(t, out.String(), "sends the txid")
+}
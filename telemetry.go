@@ -0,0 +1,205 @@
+package bridgeutil
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+const instrumentationName = "github.com/CoolBitX-Technology/sygna-bridge-util-go"
+
+// Attribute keys of the spans and metrics. Values never contain private info or personal data.
+const (
+	AttributeEndpoint   = attribute.Key("sygna.endpoint")
+	AttributeOperation  = attribute.Key("sygna.operation")
+	AttributeCallback   = attribute.Key("sygna.callback")
+	AttributeErrorCode  = attribute.Key("sygna.error.code")
+	AttributeStatusCode = attribute.Key("http.response.status_code")
+	AttributeMethod     = attribute.Key("http.request.method")
+)
+
+/*
+Telemetry records OpenTelemetry spans and metrics of Sygna Bridge operations:
+
+  - a client span per BridgeAPI call and the sygna.bridge.request.duration and sygna.bridge.request.errors instruments, see Middleware
+  - a span per Encrypt, Decrypt, Sign and Verify and the sygna.signature.failures counter, see SetTelemetry
+  - a server span per callback and the sygna.callback.duration instrument, see CallbackHandler
+*/
+type Telemetry struct {
+	tracer            trace.Tracer
+	requestDuration   metric.Float64Histogram
+	requestErrors     metric.Int64Counter
+	signatureFailures metric.Int64Counter
+	callbackDuration  metric.Float64Histogram
+}
+
+// NewTelemetry returns a Telemetry recording to the providers, nil providers record nothing
+func NewTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) (*Telemetry, error) {
+	if tp == nil {
+		tp = tracenoop.NewTracerProvider()
+	}
+	if mp == nil {
+		mp = metricnoop.NewMeterProvider()
+	}
+	meter := mp.Meter(instrumentationName)
+
+	t := &Telemetry{tracer: tp.Tracer(instrumentationName)}
+	var err error
+	if t.requestDuration, err = meter.Float64Histogram("sygna.bridge.request.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of Sygna Bridge API calls")); err != nil {
+		return nil, err
+	}
+	if t.requestErrors, err = meter.Int64Counter("sygna.bridge.request.errors",
+		metric.WithDescription("Failed Sygna Bridge API calls by error code")); err != nil {
+		return nil, err
+	}
+	if t.signatureFailures, err = meter.Int64Counter("sygna.signature.failures",
+		metric.WithDescription("Signatures which failed to be created or verified")); err != nil {
+		return nil, err
+	}
+	if t.callbackDuration, err = meter.Float64Histogram("sygna.callback.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of handling Sygna Bridge callbacks")); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// WithTelemetry records a span and metrics for every call, see Telemetry.Middleware
+func WithTelemetry(t *Telemetry) Option {
+	return func(o *options) error {
+		if t == nil {
+			return errors.New("telemetry is nil")
+		}
+		o.middlewares = append(o.middlewares, t.Middleware())
+		return nil
+	}
+}
+
+// Middleware records a client span named after the endpoint, the call duration and the error code of failed calls
+func (t *Telemetry) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ex *Exchange) (interface{}, error) {
+			start := time.Now()
+			ctx, span := t.tracer.Start(ex.Context, "BridgeAPI."+ex.Endpoint,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(AttributeEndpoint.String(ex.Endpoint), AttributeMethod.String(ex.Method)))
+			defer span.End()
+			ex.Context = ctx
+
+			response, err := next(ex)
+
+			attrs := []attribute.KeyValue{AttributeEndpoint.String(ex.Endpoint)}
+			if ex.StatusCode != 0 {
+				attrs = append(attrs, AttributeStatusCode.Int(ex.StatusCode))
+			}
+			span.SetAttributes(attrs...)
+			t.requestDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+			if err != nil {
+				errorCode := errorCode(ex.StatusCode)
+				span.SetAttributes(AttributeErrorCode.String(errorCode))
+				// error bodies of Sygna Bridge may echo personal data, only the code is recorded
+				span.SetStatus(codes.Error, errorCode)
+				t.requestErrors.Add(ctx, 1, metric.WithAttributes(AttributeEndpoint.String(ex.Endpoint), AttributeErrorCode.String(errorCode)))
+			}
+			return response, err
+		}
+	}
+}
+
+// errorCode is the HTTP status of an error response, or "transport" when no response was received
+func errorCode(statusCode int) string {
+	if statusCode == 0 {
+		return "transport"
+	}
+	return strconv.Itoa(statusCode)
+}
+
+// CallbackHandler records a server span named after callback, e.g. "permission-request",
+// and the duration and response status of every callback handled by h
+func (t *Telemetry) CallbackHandler(callback string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, span := t.tracer.Start(r.Context(), "callback "+callback,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(AttributeCallback.String(callback), AttributeMethod.String(r.Method)))
+		defer span.End()
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(sw, r.WithContext(ctx))
+
+		attrs := []attribute.KeyValue{AttributeCallback.String(callback), AttributeStatusCode.Int(sw.status)}
+		span.SetAttributes(AttributeStatusCode.Int(sw.status))
+		if sw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.status))
+		}
+		t.callbackDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+var defaultTelemetry atomic.Pointer[Telemetry]
+
+// SetTelemetry records spans for Encrypt, Decrypt, Sign and Verify and counts signature failures
+// with t, nil stops recording. Nothing is recorded by default. The spans are roots of their own
+// trace, except for SignContext, VerifyContext, EncryptContext and DecryptContext whose spans
+// are children of the span of their context.
+func SetTelemetry(t *Telemetry) {
+	defaultTelemetry.Store(t)
+}
+
+// instrumentCrypto runs a crypto operation in a span child of ctx when telemetry is set and logs it
+// when a logger is set. f gets the context of the span, e.g. to set its status.
+func instrumentCrypto[T any](ctx context.Context, operation, transferID string, f func(context.Context) (T, error)) (T, error) {
+	start := time.Now()
+	t := defaultTelemetry.Load()
+	if t == nil {
+		v, err := f(ctx)
+		logCrypto(ctx, operation, transferID, start, err)
+		return v, err
+	}
+	ctx, span := t.tracer.Start(ctx, "sygna."+operation,
+		trace.WithAttributes(AttributeOperation.String(operation)))
+	defer span.End()
+	v, err := f(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, operation+" failed")
+	}
+	logCrypto(ctx, operation, transferID, start, err)
+	return v, err
+}
+
+// recordSignatureFailure counts a signature which could not be created or did not verify,
+// and logs the latter as failed operations are already logged by instrumentCrypto
+func recordSignatureFailure(ctx context.Context, operation, transferID string, err error) {
+	if t := defaultTelemetry.Load(); t != nil {
+		t.signatureFailures.Add(ctx, 1, metric.WithAttributes(AttributeOperation.String(operation)))
+	}
+	if logger := defaultLogger.Load(); logger != nil && err == nil {
+		logger.LogAttrs(ctx, slog.LevelWarn, "sygna signature is invalid",
+			slog.String("operation", operation), slog.String("transfer_id", transferID))
+	}
+}
@@ -0,0 +1,300 @@
+package bridgeutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/iancoleman/orderedmap"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// spanRecorder is a TracerProvider recording the spans it starts
+type spanRecorder struct {
+	tracenoop.TracerProvider
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (r *spanRecorder) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return &recordingTracer{recorder: r}
+}
+
+// Ended returns the ended spans in the order they were started
+func (r *spanRecorder) Ended() []*recordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ended []*recordedSpan
+	for _, s := range r.spans {
+		if s.ended {
+			ended = append(ended, s)
+		}
+	}
+	return ended
+}
+
+type recordingTracer struct {
+	tracenoop.Tracer
+	recorder *spanRecorder
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	cfg := trace.NewSpanStartConfig(opts...)
+	s := &recordedSpan{recorder: t.recorder, name: name, attrs: cfg.Attributes()}
+	if parent, ok := trace.SpanFromContext(ctx).(*recordedSpan); ok {
+		s.parent = parent
+	}
+	t.recorder.mu.Lock()
+	t.recorder.spans = append(t.recorder.spans, s)
+	t.recorder.mu.Unlock()
+	return trace.ContextWithSpan(ctx, s), s
+}
+
+type recordedSpan struct {
+	tracenoop.Span
+	recorder    *spanRecorder
+	name        string
+	parent      *recordedSpan
+	attrs       []attribute.KeyValue
+	code        codes.Code
+	description string
+	ended       bool
+}
+
+func (s *recordedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.attrs = append(s.attrs, kv...)
+}
+
+func (s *recordedSpan) SetStatus(code codes.Code, description string) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.code, s.description = code, description
+}
+
+func (s *recordedSpan) End(...trace.SpanEndOption) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.ended = true
+}
+
+// metricRecorder is a MeterProvider recording the measurements of its counters and histograms
+type metricRecorder struct {
+	metricnoop.MeterProvider
+	mu           sync.Mutex
+	measurements map[string][]measurement
+}
+
+type measurement struct {
+	value float64
+	attrs attribute.Set
+}
+
+func (r *metricRecorder) Meter(string, ...metric.MeterOption) metric.Meter {
+	return &recordingMeter{recorder: r}
+}
+
+func (r *metricRecorder) record(name string, value float64, attrs attribute.Set) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.measurements == nil {
+		r.measurements = map[string][]measurement{}
+	}
+	r.measurements[name] = append(r.measurements[name], measurement{value, attrs})
+}
+
+type recordingMeter struct {
+	metricnoop.Meter
+	recorder *metricRecorder
+}
+
+func (m *recordingMeter) Int64Counter(name string, _ ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return &recordingCounter{name: name, recorder: m.recorder}, nil
+}
+
+func (m *recordingMeter) Float64Histogram(name string, _ ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return &recordingHistogram{name: name, recorder: m.recorder}, nil
+}
+
+type recordingCounter struct {
+	metricnoop.Int64Counter
+	name     string
+	recorder *metricRecorder
+}
+
+func (c *recordingCounter) Add(_ context.Context, incr int64, opts ...metric.AddOption) {
+	c.recorder.record(c.name, float64(incr), metric.NewAddConfig(opts).Attributes())
+}
+
+type recordingHistogram struct {
+	metricnoop.Float64Histogram
+	name     string
+	recorder *metricRecorder
+}
+
+func (h *recordingHistogram) Record(_ context.Context, value float64, opts ...metric.RecordOption) {
+	h.recorder.record(h.name, value, metric.NewRecordConfig(opts).Attributes())
+}
+
+func newTestTelemetry(t *testing.T) (*Telemetry, *spanRecorder, *metricRecorder) {
+	spans, metrics := &spanRecorder{}, &metricRecorder{}
+	telemetry, err := NewTelemetry(spans, metrics)
+	assert.Nil(t, err)
+	return telemetry, spans, metrics
+}
+
+// sumCounter returns the value of a counter for the measurements having attr
+func sumCounter(metrics *metricRecorder, name string, attr attribute.KeyValue) int64 {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	var total int64
+	for _, m := range metrics.measurements[name] {
+		if v, ok := m.attrs.Value(attr.Key); ok && v == attr.Value {
+			total += int64(m.value)
+		}
+	}
+	return total
+}
+
+func countHistogram(metrics *metricRecorder, name string) uint64 {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	return uint64(len(metrics.measurements[name]))
+}
+
+func TestTelemetryMiddleware(t *testing.T) {
+	telemetry, spans, metrics := newTestTelemetry(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/bridge/transaction/permission" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid beneficiary Wu Xinli"}`))
+			return
+		}
+		w.Write([]byte(`{"supported_coins":[]}`))
+	}))
+	defer server.Close()
+
+	api, err := NewBridgeAPI(WithBaseURL(server.URL), WithTelemetry(telemetry))
+	assert.Nil(t, err)
+	_, err = api.GetCurrencies(nil)
+	assert.Nil(t, err)
+	_, err = api.PostPermission(orderedmap.New())
+	assert.NotNil(t, err)
+
+	ended := spans.Ended()
+	assert.Len(t, ended, 2)
+	assert.Equal(t, "BridgeAPI.GetCurrencies", ended[0].name)
+	assert.Equal(t, codes.Unset, ended[0].code)
+	assert.Equal(t, "BridgeAPI.PostPermission", ended[1].name)
+	assert.Equal(t, codes.Error, ended[1].code)
+	assert.Contains(t, ended[1].attrs, AttributeStatusCode.Int(http.StatusBadRequest))
+	assert.Contains(t, ended[1].attrs, AttributeErrorCode.String("400"))
+	for _, attr := range ended[1].attrs {
+		assert.NotContains(t, attr.Value.Emit(), "Wu Xinli")
+	}
+	assert.NotContains(t, ended[1].description, "Wu Xinli")
+
+	assert.Equal(t, int64(1), sumCounter(metrics, "sygna.bridge.request.errors", AttributeErrorCode.String("400")))
+	assert.Equal(t, uint64(2), countHistogram(metrics, "sygna.bridge.request.duration"))
+
+	_, err = NewBridgeAPI(WithTelemetry(nil))
+	assert.NotNil(t, err)
+}
+
+func TestTelemetryCrypto(t *testing.T) {
+	telemetry, spans, metrics := newTestTelemetry(t)
+	SetTelemetry(telemetry)
+	t.Cleanup(func() { SetTelemetry(nil) })
+
+	message := StringToOrderedMap(`{"transfer_id":"b97903fd"}`)
+	assert.Nil(t, Sign(message, fakePrivateKey))
+	valid, err := Verify(message, fakePublicKey)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	message.Set("transfer_id", "tampered")
+	valid, _ = Verify(message, fakePublicKey)
+	assert.False(t, valid)
+
+	encrypted, err := EncryptString("secret", fakePublicKey)
+	assert.Nil(t, err)
+	_, err = Decrypt(encrypted, fakePrivateKey)
+	assert.Nil(t, err)
+	_, err = Decrypt("00", fakePrivateKey)
+	assert.NotNil(t, err)
+
+	var names []string
+	for _, span := range spans.Ended() {
+		names = append(names, span.name)
+	}
+	assert.Equal(t, []string{"sygna.sign", "sygna.verify", "sygna.verify", "sygna.encrypt", "sygna.decrypt", "sygna.decrypt"}, names)
+	for _, span := range spans.Ended() {
+		assert.Nil(t, span.parent, span.name)
+	}
+	assert.Equal(t, codes.Unset, spans.Ended()[1].code)
+	assert.Equal(t, codes.Error, spans.Ended()[2].code, "an invalid signature is an error")
+	assert.Equal(t, codes.Error, spans.Ended()[5].code)
+	assert.Equal(t, int64(1), sumCounter(metrics, "sygna.signature.failures", AttributeOperation.String("verify")))
+}
+
+func TestTelemetryCryptoContext(t *testing.T) {
+	telemetry, spans, _ := newTestTelemetry(t)
+	SetTelemetry(telemetry)
+	t.Cleanup(func() { SetTelemetry(nil) })
+
+	ctx, parent := telemetry.tracer.Start(context.Background(), "callback")
+	message := StringToOrderedMap(`{"transfer_id":"b97903fd"}`)
+	assert.Nil(t, SignContext(ctx, message, fakePrivateKey))
+	valid, err := VerifyContext(ctx, message, fakePublicKey)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+	encrypted, err := EncryptContext(ctx, message, fakePublicKey)
+	assert.Nil(t, err)
+	_, err = DecryptContext(ctx, encrypted, fakePrivateKey)
+	assert.Nil(t, err)
+	parent.End()
+
+	ended := spans.Ended()
+	assert.Len(t, ended, 5)
+	for _, span := range ended[1:] {
+		assert.Same(t, parent, span.parent, span.name)
+	}
+}
+
+func TestTelemetryCallbackHandler(t *testing.T) {
+	telemetry, spans, metrics := newTestTelemetry(t)
+	handler := telemetry.CallbackHandler("permission-request", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/callback", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	ended := spans.Ended()
+	assert.Len(t, ended, 1)
+	assert.Equal(t, "callback permission-request", ended[0].name)
+	assert.Equal(t, codes.Error, ended[0].code)
+	assert.Equal(t, uint64(1), countHistogram(metrics, "sygna.callback.duration"))
+}
+
+func TestTelemetryDisabledByDefault(t *testing.T) {
+	message := StringToOrderedMap(`{"transfer_id":"b97903fd"}`)
+	assert.Nil(t, Sign(message, fakePrivateKey))
+
+	telemetry, err := NewTelemetry(nil, nil)
+	assert.Nil(t, err)
+	api, err := NewBridgeAPI(WithBaseURL("http://127.0.0.1:1"), WithTelemetry(telemetry))
+	assert.Nil(t, err)
+	_, err = api.GetCurrencies(nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,108 @@
+package travelrule
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/ivms101"
+)
+
+// sygnaToStandardKeys lists the IVMS101 keys whose standard camelCase binding
+// is not the plain camelCase form of the snake_case key Sygna Bridge uses.
+var sygnaToStandardKeys = map[string]string{
+	"account_numbers":           "accountNumber",
+	"name_identifiers":          "nameIdentifier",
+	"local_name_identifiers":    "localNameIdentifier",
+	"phonetic_name_identifiers": "phoneticNameIdentifier",
+	"originating_vasp":          "originatingVASP",
+	"beneficiary_vasp":          "beneficiaryVASP",
+}
+
+var standardToSygnaKeys = reverse(sygnaToStandardKeys)
+
+func reverse(m map[string]string) map[string]string {
+	r := make(map[string]string, len(m))
+	for k, v := range m {
+		r[v] = k
+	}
+	return r
+}
+
+// toStandardIVMS101 converts a payload to the camelCase IVMS101 json binding used by TRP and TRISA
+func toStandardIVMS101(payload *ivms101.Payload) (json.RawMessage, error) {
+	return convertKeys(payload, func(key string) string {
+		if standard, ok := sygnaToStandardKeys[key]; ok {
+			return standard
+		}
+		return snakeToCamel(key)
+	})
+}
+
+// fromStandardIVMS101 converts the camelCase IVMS101 json binding to a payload
+func fromStandardIVMS101(data json.RawMessage) (*ivms101.Payload, error) {
+	b, err := convertKeys(data, func(key string) string {
+		if sygna, ok := standardToSygnaKeys[key]; ok {
+			return sygna
+		}
+		return camelToSnake(key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ivms101.Parse(b)
+}
+
+func convertKeys(v interface{}, convert func(string) string) (json.RawMessage, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return nil, err
+	}
+	return json.Marshal(convertValue(decoded, convert))
+}
+
+func convertValue(v interface{}, convert func(string) string) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for k, child := range value {
+			converted[convert(k)] = convertValue(child, convert)
+		}
+		return converted
+	case []interface{}:
+		for i, child := range value {
+			value[i] = convertValue(child, convert)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+func snakeToCamel(key string) string {
+	parts := strings.Split(key, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func camelToSnake(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
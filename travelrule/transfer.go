@@ -0,0 +1,142 @@
+/*
+Package travelrule converts transfers between the Sygna Bridge permission
+request shape and the envelopes of other travel rule protocols (TRP and TRISA),
+so one internal model can be routed through whichever protocol a counterparty
+supports. The IVMS101 content is preserved; information a protocol cannot carry
+is reported as LossyField.
+*/
+package travelrule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/ivms101"
+	"github.com/iancoleman/orderedmap"
+)
+
+// Transfer is a protocol independent travel rule transfer
+type Transfer struct {
+	OriginatorVASP  VASP
+	BeneficiaryVASP VASP
+	CurrencyID      string
+	Amount          string
+	TxID            string
+	PrivateInfo     *ivms101.Payload
+}
+
+// VASP is one side of a transfer
+type VASP struct {
+	VASPCode string
+	Addrs    []Addr
+}
+
+// Addr is a blockchain address with its chain specific extra info such as tags or memos
+type Addr struct {
+	Address       string
+	AddrExtraInfo []map[string]interface{}
+}
+
+// LossyField is information the target protocol cannot carry
+type LossyField struct {
+	Field  string
+	Reason string
+}
+
+func (f LossyField) String() string {
+	return f.Field + ": " + f.Reason
+}
+
+// FromSygnaPermissionRequest builds a Transfer from the transaction of a permission request and its decrypted private_info
+func FromSygnaPermissionRequest(transaction *orderedmap.OrderedMap, privateInfo *ivms101.Payload) (*Transfer, error) {
+	b, err := json.Marshal(transaction)
+	if err != nil {
+		return nil, err
+	}
+	var decoded sygnaTransaction
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return nil, err
+	}
+	if decoded.CurrencyID == "" {
+		return nil, errors.New("transaction must contain currency_id")
+	}
+	return &Transfer{
+		OriginatorVASP:  decoded.OriginatorVASP.toVASP(),
+		BeneficiaryVASP: decoded.BeneficiaryVASP.toVASP(),
+		CurrencyID:      decoded.CurrencyID,
+		Amount:          decoded.Amount,
+		PrivateInfo:     privateInfo,
+	}, nil
+}
+
+// ToSygnaTransaction returns the transaction in the key order PostPermissionRequest signs.
+// PrivateInfo must be encrypted separately for the beneficiary VASP.
+func (t *Transfer) ToSygnaTransaction() *orderedmap.OrderedMap {
+	transaction := orderedmap.New()
+	transaction.Set("originator_vasp", t.OriginatorVASP.toOrderedMap())
+	transaction.Set("beneficiary_vasp", t.BeneficiaryVASP.toOrderedMap())
+	transaction.Set("currency_id", t.CurrencyID)
+	transaction.Set("amount", t.Amount)
+	return transaction
+}
+
+type sygnaTransaction struct {
+	OriginatorVASP  sygnaVASP `json:"originator_vasp"`
+	BeneficiaryVASP sygnaVASP `json:"beneficiary_vasp"`
+	CurrencyID      string    `json:"currency_id"`
+	Amount          string    `json:"amount"`
+}
+
+type sygnaVASP struct {
+	VASPCode string `json:"vasp_code"`
+	Addrs    []struct {
+		Address       string                   `json:"address"`
+		AddrExtraInfo []map[string]interface{} `json:"addr_extra_info"`
+	} `json:"addrs"`
+}
+
+func (v sygnaVASP) toVASP() VASP {
+	vasp := VASP{VASPCode: v.VASPCode}
+	for _, addr := range v.Addrs {
+		vasp.Addrs = append(vasp.Addrs, Addr{Address: addr.Address, AddrExtraInfo: addr.AddrExtraInfo})
+	}
+	return vasp
+}
+
+func (v VASP) toOrderedMap() *orderedmap.OrderedMap {
+	addrs := make([]*orderedmap.OrderedMap, len(v.Addrs))
+	for i, addr := range v.Addrs {
+		o := orderedmap.New()
+		o.Set("address", addr.Address)
+		if len(addr.AddrExtraInfo) > 0 {
+			extraInfo := make([]*orderedmap.OrderedMap, len(addr.AddrExtraInfo))
+			for j, info := range addr.AddrExtraInfo {
+				extraInfo[j] = mapToOrderedMap(info)
+			}
+			o.Set("addr_extra_info", extraInfo)
+		}
+		addrs[i] = o
+	}
+
+	o := orderedmap.New()
+	o.Set("vasp_code", v.VASPCode)
+	o.Set("addrs", addrs)
+	return o
+}
+
+func mapToOrderedMap(m map[string]interface{}) *orderedmap.OrderedMap {
+	b, _ := json.Marshal(m)
+	o := orderedmap.New()
+	o.UnmarshalJSON(b)
+	return o
+}
+
+func tagOf(addr Addr) string {
+	for _, info := range addr.AddrExtraInfo {
+		if tag, ok := info["tag"]; ok {
+			return fmt.Sprint(tag)
+		}
+	}
+	return ""
+}
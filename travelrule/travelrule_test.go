@@ -0,0 +1,70 @@
+package travelrule
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/ivms101"
+	"github.com/iancoleman/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+const fakeTransaction = `{"originator_vasp":{"vasp_code":"VASPUSNY1","addrs":[{"address":"r3kmLJN5D28dHuH8vZNUZpMC43pEHpaocV"}]},"beneficiary_vasp":{"vasp_code":"VASPUSNY2","addrs":[{"address":"rAPERVgXZavGgiGv6xBgtiZurirW2yAmY","addr_extra_info":[{"tag":"abc"}]}]},"currency_id":"sygna:0x80000090","amount":"4.51120135938784"}`
+
+const fakePrivateInfo = `{"originator":{"originator_persons":[{"natural_person":{"name":{"name_identifiers":[{"primary_identifier":"Wu Xinli","name_identifier_type":"LEGL"}]},"national_identification":{"national_identifier":"446005","national_identifier_type":"RAID","registration_authority":"RA000553"},"country_of_residence":"TZ"}}],"account_numbers":["r3kmLJN5D28dHuH8vZNUZpMC43pEHpaocV"]},"beneficiary":{"beneficiary_persons":[{"legal_person":{"name":{"name_identifiers":[{"legal_person_name":"ABC Limited","legal_person_name_identifier_type":"LEGL"}]}}}],"account_numbers":["rAPERVgXZavGgiGv6xBgtiZurirW2yAmY"]}}`
+
+func fakeTransfer(t *testing.T) *Transfer {
+	transaction := orderedmap.New()
+	assert.Nil(t, transaction.UnmarshalJSON([]byte(fakeTransaction)))
+	privateInfo, err := ivms101.Parse([]byte(fakePrivateInfo))
+	assert.Nil(t, err)
+
+	transfer, err := FromSygnaPermissionRequest(transaction, privateInfo)
+	assert.Nil(t, err)
+	return transfer
+}
+
+func TestSygnaTransaction(t *testing.T) {
+	b, err := json.Marshal(fakeTransfer(t).ToSygnaTransaction())
+	assert.Nil(t, err)
+	assert.Equal(t, fakeTransaction, string(b), "should be equal")
+}
+
+func TestTRP(t *testing.T) {
+	transfer := fakeTransfer(t)
+
+	trp, lossy, err := transfer.ToTRP("https://vasp.example.com/callback")
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(144), trp.Asset.SLIP0044)
+	assert.Contains(t, string(trp.IVMS101), `"originatorPersons"`)
+	assert.Contains(t, string(trp.IVMS101), `"nameIdentifier"`)
+	assert.Contains(t, string(trp.IVMS101), `"accountNumber"`)
+	assert.Equal(t, 3, len(lossy), "vasp codes and extra info should be lossy: %v", lossy)
+
+	back, _, err := FromTRP(trp)
+	assert.Nil(t, err)
+	assert.Equal(t, transfer.PrivateInfo, back.PrivateInfo, "IVMS101 should be preserved")
+	assert.Equal(t, transfer.CurrencyID, back.CurrencyID)
+	assert.Equal(t, transfer.Amount, back.Amount)
+	assert.Equal(t, "rAPERVgXZavGgiGv6xBgtiZurirW2yAmY", back.BeneficiaryVASP.Addrs[0].Address)
+}
+
+func TestTRISA(t *testing.T) {
+	transfer := fakeTransfer(t)
+
+	trisa, lossy, err := transfer.ToTRISA()
+	assert.Nil(t, err)
+	assert.Empty(t, lossy)
+	assert.Equal(t, "abc", trisa.Transaction.Tag)
+	assert.Equal(t, 4.51120135938784, trisa.Transaction.Amount)
+
+	back, lossy, err := FromTRISA(trisa)
+	assert.Nil(t, err)
+	assert.Empty(t, lossy)
+	assert.Equal(t, transfer, back, "should be equal")
+
+	transfer.Amount = "0.123456789012345678"
+	_, lossy, err = transfer.ToTRISA()
+	assert.Nil(t, err)
+	assert.Equal(t, "amount", lossy[0].Field)
+}
@@ -0,0 +1,145 @@
+package travelrule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/currency"
+)
+
+// TRISAPayload is the decrypted payload of a TRISA secure envelope
+type TRISAPayload struct {
+	Identity    json.RawMessage  `json:"identity"`
+	Transaction TRISATransaction `json:"transaction"`
+}
+
+// TRISATransaction is the generic TRISA transaction
+type TRISATransaction struct {
+	TxID        string  `json:"txid,omitempty"`
+	Originator  string  `json:"originator,omitempty"`
+	Beneficiary string  `json:"beneficiary,omitempty"`
+	Amount      float64 `json:"amount"`
+	Network     string  `json:"network,omitempty"`
+	AssetType   string  `json:"asset_type,omitempty"`
+	Tag         string  `json:"tag,omitempty"`
+	ExtraJSON   string  `json:"extra_json,omitempty"`
+}
+
+// trisaExtra keeps Sygna specific fields in the extra_json of a TRISA transaction
+type trisaExtra struct {
+	OriginatorVASPCode  string `json:"sygna_originator_vasp_code,omitempty"`
+	BeneficiaryVASPCode string `json:"sygna_beneficiary_vasp_code,omitempty"`
+	Amount              string `json:"sygna_amount,omitempty"`
+}
+
+// ToTRISA converts the transfer to a TRISA payload. The currency id is used as
+// network, VASP codes and the exact amount are kept in extra_json. TRISA carries a
+// single address and tag per side, and a float amount, which are reported as lossy.
+func (t *Transfer) ToTRISA() (*TRISAPayload, []LossyField, error) {
+	if t.PrivateInfo == nil {
+		return nil, nil, errors.New("transfer must contain private info")
+	}
+	identity, err := toStandardIVMS101(t.PrivateInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	amount, err := strconv.ParseFloat(t.Amount, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	var lossy []LossyField
+	if strconv.FormatFloat(amount, 'f', -1, 64) != t.Amount {
+		lossy = append(lossy, LossyField{"amount", "cannot be represented exactly as float, the exact value is kept in extra_json"})
+	}
+
+	transaction := TRISATransaction{
+		TxID:    t.TxID,
+		Amount:  amount,
+		Network: t.CurrencyID,
+	}
+	if len(t.OriginatorVASP.Addrs) > 0 {
+		transaction.Originator = t.OriginatorVASP.Addrs[0].Address
+	}
+	if len(t.OriginatorVASP.Addrs) > 1 {
+		lossy = append(lossy, LossyField{"originator_vasp.addrs", "TRISA carries a single originator address"})
+	}
+	if len(t.BeneficiaryVASP.Addrs) > 0 {
+		transaction.Beneficiary = t.BeneficiaryVASP.Addrs[0].Address
+		transaction.Tag = tagOf(t.BeneficiaryVASP.Addrs[0])
+		if len(t.BeneficiaryVASP.Addrs[0].AddrExtraInfo) > 1 || (transaction.Tag == "" && len(t.BeneficiaryVASP.Addrs[0].AddrExtraInfo) > 0) {
+			lossy = append(lossy, LossyField{"beneficiary_vasp.addrs.addr_extra_info", "TRISA carries only a tag"})
+		}
+	}
+	if len(t.BeneficiaryVASP.Addrs) > 1 {
+		lossy = append(lossy, LossyField{"beneficiary_vasp.addrs", "TRISA carries a single beneficiary address"})
+	}
+	for _, addr := range t.OriginatorVASP.Addrs {
+		if len(addr.AddrExtraInfo) > 0 {
+			lossy = append(lossy, LossyField{"originator_vasp.addrs.addr_extra_info", "TRISA has no originator address extra info"})
+			break
+		}
+	}
+
+	extra, err := json.Marshal(trisaExtra{
+		OriginatorVASPCode:  t.OriginatorVASP.VASPCode,
+		BeneficiaryVASPCode: t.BeneficiaryVASP.VASPCode,
+		Amount:              t.Amount,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	transaction.ExtraJSON = string(extra)
+
+	return &TRISAPayload{Identity: identity, Transaction: transaction}, lossy, nil
+}
+
+// FromTRISA converts a TRISA payload to a transfer, restoring Sygna fields from extra_json when present
+func FromTRISA(payload *TRISAPayload) (*Transfer, []LossyField, error) {
+	privateInfo, err := fromStandardIVMS101(payload.Identity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var lossy []LossyField
+	var extra trisaExtra
+	if payload.Transaction.ExtraJSON != "" {
+		// extra_json of other implementations may not be an object; it is simply ignored then
+		json.Unmarshal([]byte(payload.Transaction.ExtraJSON), &extra)
+	}
+
+	transfer := &Transfer{
+		OriginatorVASP:  VASP{VASPCode: extra.OriginatorVASPCode},
+		BeneficiaryVASP: VASP{VASPCode: extra.BeneficiaryVASPCode},
+		CurrencyID:      payload.Transaction.Network,
+		Amount:          extra.Amount,
+		TxID:            payload.Transaction.TxID,
+		PrivateInfo:     privateInfo,
+	}
+	if transfer.Amount == "" {
+		transfer.Amount = strconv.FormatFloat(payload.Transaction.Amount, 'f', -1, 64)
+	}
+	if _, err := currency.ParseID(transfer.CurrencyID); err != nil {
+		lossy = append(lossy, LossyField{"currency_id", fmt.Sprintf("network %q is not a sygna currency id", payload.Transaction.Network)})
+	}
+	if transfer.OriginatorVASP.VASPCode == "" {
+		lossy = append(lossy, LossyField{"originator_vasp.vasp_code", "not present in TRISA payload"})
+	}
+	if transfer.BeneficiaryVASP.VASPCode == "" {
+		lossy = append(lossy, LossyField{"beneficiary_vasp.vasp_code", "not present in TRISA payload"})
+	}
+
+	if payload.Transaction.Originator != "" {
+		transfer.OriginatorVASP.Addrs = []Addr{{Address: payload.Transaction.Originator}}
+	}
+	if payload.Transaction.Beneficiary != "" {
+		addr := Addr{Address: payload.Transaction.Beneficiary}
+		if payload.Transaction.Tag != "" {
+			addr.AddrExtraInfo = []map[string]interface{}{{"tag": payload.Transaction.Tag}}
+		}
+		transfer.BeneficiaryVASP.Addrs = []Addr{addr}
+	}
+	return transfer, lossy, nil
+}
@@ -0,0 +1,108 @@
+package travelrule
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/CoolBitX-Technology/sygna-bridge-util-go/currency"
+)
+
+// TRPTransfer is the body of an OpenVASP TRP transfer inquiry
+type TRPTransfer struct {
+	Asset    TRPAsset        `json:"asset"`
+	Amount   string          `json:"amount"`
+	Callback string          `json:"callback,omitempty"`
+	IVMS101  json.RawMessage `json:"IVMS101"`
+}
+
+// TRPAsset identifies the transferred asset by its SLIP-44 coin type
+type TRPAsset struct {
+	SLIP0044 uint32 `json:"slip0044"`
+}
+
+// ToTRP converts the transfer to a TRP transfer inquiry. TRP routes by travel
+// address and identifies the beneficiary address through IVMS101 account numbers,
+// so VASP codes, address extra info and token contracts are reported as lossy.
+func (t *Transfer) ToTRP(callback string) (*TRPTransfer, []LossyField, error) {
+	if t.PrivateInfo == nil {
+		return nil, nil, errors.New("transfer must contain private info")
+	}
+	currencyID, err := currency.ParseID(t.CurrencyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	identity, err := toStandardIVMS101(t.PrivateInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var lossy []LossyField
+	if currencyID.IsToken() {
+		lossy = append(lossy, LossyField{"currency_id", "token contract cannot be expressed as a SLIP-44 coin type"})
+	}
+	lossy = append(lossy, t.lossyVASPFields("TRP")...)
+	if t.TxID != "" {
+		lossy = append(lossy, LossyField{"txid", "TRP transfer inquiries do not carry a transaction id"})
+	}
+	if t.PrivateInfo.Beneficiary == nil || len(t.PrivateInfo.Beneficiary.AccountNumbers) == 0 {
+		lossy = append(lossy, LossyField{"beneficiary_vasp.addrs", "TRP carries the beneficiary address only in IVMS101 account_numbers, which is empty"})
+	}
+
+	return &TRPTransfer{
+		Asset:    TRPAsset{SLIP0044: currencyID.CoinType},
+		Amount:   t.Amount,
+		Callback: callback,
+		IVMS101:  identity,
+	}, lossy, nil
+}
+
+// FromTRP converts a TRP transfer inquiry to a transfer. VASP codes are unknown
+// in TRP and addresses are taken from the IVMS101 account numbers.
+func FromTRP(trp *TRPTransfer) (*Transfer, []LossyField, error) {
+	privateInfo, err := fromStandardIVMS101(trp.IVMS101)
+	if err != nil {
+		return nil, nil, err
+	}
+	transfer := &Transfer{
+		CurrencyID:  currency.NewID(trp.Asset.SLIP0044, "").String(),
+		Amount:      trp.Amount,
+		PrivateInfo: privateInfo,
+	}
+	transfer.addrsFromAccountNumbers()
+
+	lossy := []LossyField{
+		{"originator_vasp.vasp_code", "TRP identifies VASPs by travel address, not by Sygna VASP code"},
+		{"beneficiary_vasp.vasp_code", "TRP identifies VASPs by travel address, not by Sygna VASP code"},
+	}
+	return transfer, lossy, nil
+}
+
+func (t *Transfer) lossyVASPFields(protocol string) []LossyField {
+	var lossy []LossyField
+	if t.OriginatorVASP.VASPCode != "" {
+		lossy = append(lossy, LossyField{"originator_vasp.vasp_code", protocol + " has no Sygna VASP code"})
+	}
+	if t.BeneficiaryVASP.VASPCode != "" {
+		lossy = append(lossy, LossyField{"beneficiary_vasp.vasp_code", protocol + " has no Sygna VASP code"})
+	}
+	for _, addr := range append(append([]Addr{}, t.OriginatorVASP.Addrs...), t.BeneficiaryVASP.Addrs...) {
+		if len(addr.AddrExtraInfo) > 0 {
+			lossy = append(lossy, LossyField{"addrs.addr_extra_info", protocol + " has no address extra info"})
+			break
+		}
+	}
+	return lossy
+}
+
+func (t *Transfer) addrsFromAccountNumbers() {
+	if t.PrivateInfo.Originator != nil {
+		for _, account := range t.PrivateInfo.Originator.AccountNumbers {
+			t.OriginatorVASP.Addrs = append(t.OriginatorVASP.Addrs, Addr{Address: account})
+		}
+	}
+	if t.PrivateInfo.Beneficiary != nil {
+		for _, account := range t.PrivateInfo.Beneficiary.AccountNumbers {
+			t.BeneficiaryVASP.Addrs = append(t.BeneficiaryVASP.Addrs, Addr{Address: account})
+		}
+	}
+}